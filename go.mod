@@ -6,6 +6,7 @@ go 1.24
 require (
 	github.com/bwmarrin/discordgo v0.29.0
 	github.com/joho/godotenv v1.5.1
+	golang.org/x/image v0.30.0
 )
 
 require (
@@ -11,6 +11,9 @@ import (
 	"github.com/bwmarrin/discordgo"
 )
 
+// couplingMaxGroups is the number of group options offered by the /coupling command
+const couplingMaxGroups = 5
+
 type couplingHandler struct {
 	coupler       types.Coupler
 	emojiProvider types.EmojiProvider
@@ -59,22 +62,75 @@ func (h *couplingHandler) Handle(ctx context.Context, s *discordgo.Session, m *d
 
 	h.logger.Debug(ctx, "Coupling completed", types.Field{Key: "result_count", Value: len(result)})
 
-	return h.createCouplingEmbed(ctx, s, m, result)
+	embed, err := h.createCouplingEmbed(ctx, result)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.ChannelMessageSendEmbed(m.ChannelID, embed)
+	return err
 }
 
-func (h *couplingHandler) createCouplingEmbed(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, couples [][]string) error {
+func (h *couplingHandler) ApplicationCommands() []*discordgo.ApplicationCommand {
+	options := make([]*discordgo.ApplicationCommandOption, 0, couplingMaxGroups)
+	for i := 1; i <= couplingMaxGroups; i++ {
+		options = append(options, &discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        fmt.Sprintf("group%d", i),
+			Description: fmt.Sprintf("%d番目の集合[カンマ区切りで入力する]", i),
+			Required:    i <= 2,
+		})
+	}
+
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:        "coupling",
+			Description: "与えられた集合の要素で組み合わせを作る",
+			Options:     options,
+		},
+	}
+}
+
+func (h *couplingHandler) CanHandleInteraction(i *discordgo.InteractionCreate) bool {
+	return isApplicationCommand(i, "coupling")
+}
+
+func (h *couplingHandler) HandleInteraction(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	var lines []string
+	for n := 1; n <= couplingMaxGroups; n++ {
+		if line := stringOption(i, fmt.Sprintf("group%d", n)); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	itemSets := utils.ParseItemSets(lines, ",")
+	result, err := h.coupler.Couple(ctx, itemSets)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to couple items", err)
+		return respondEphemeral(s, i, "カップリング対象の集合を2つ以上記入してください")
+	}
+
+	embed, err := h.createCouplingEmbed(ctx, result)
+	if err != nil {
+		return respondEphemeral(s, i, fmt.Sprintf("組み合わせは%d組まで作成できます", h.emojiProvider.GetMaxEmojis()))
+	}
+
+	return respondEmbeds(s, i, embed)
+}
+
+func (h *couplingHandler) createCouplingEmbed(ctx context.Context, couples [][]string) (*discordgo.MessageEmbed, error) {
 	description := ""
 	maxEmojis := h.emojiProvider.GetMaxEmojis()
 
 	if len(couples) > maxEmojis {
-		return fmt.Errorf("too many couples: %d (max: %d)", len(couples), maxEmojis)
+		return nil, fmt.Errorf("too many couples: %d (max: %d)", len(couples), maxEmojis)
 	}
 
 	for i, couple := range couples {
 		emoji, err := h.emojiProvider.GetEmoji(ctx, i)
 		if err != nil {
 			h.logger.Error(ctx, "Failed to get emoji", err)
-			return err
+			return nil, err
 		}
 
 		if len(couple) == 0 {
@@ -87,12 +143,9 @@ func (h *couplingHandler) createCouplingEmbed(ctx context.Context, s *discordgo.
 		description += fmt.Sprintf("%s %s : %s\n", emoji, leader, members)
 	}
 
-	embed := &discordgo.MessageEmbed{
+	return &discordgo.MessageEmbed{
 		Title:       "カップリング結果",
 		Description: description,
 		Color:       0x141DB8,
-	}
-
-	_, err := s.ChannelMessageSendEmbed(m.ChannelID, embed)
-	return err
+	}, nil
 }
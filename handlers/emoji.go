@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"regexp"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// customEmojiPattern matches a custom emoji in Discord's message format, e.g. "<:name:id>"
+var customEmojiPattern = regexp.MustCompile(`^<(a?):(\w+):(\d+)>$`)
+
+// reactionAPIName returns the form of emoji that the reaction endpoints accept: custom emojis
+// become "name:id" while unicode emojis are used as they are
+func reactionAPIName(emoji string) string {
+	if match := customEmojiPattern.FindStringSubmatch(emoji); match != nil {
+		return match[2] + ":" + match[3]
+	}
+	return emoji
+}
+
+// componentEmoji returns emoji as it is attached to a button or select menu option
+func componentEmoji(emoji string) *discordgo.ComponentEmoji {
+	if match := customEmojiPattern.FindStringSubmatch(emoji); match != nil {
+		return &discordgo.ComponentEmoji{Name: match[2], ID: match[3], Animated: match[1] == "a"}
+	}
+	return &discordgo.ComponentEmoji{Name: emoji}
+}
+
+// matchesEmoji reports whether a reaction's emoji is the stored emoji. Custom emojis are
+// compared by ID because reaction events carry only their name and ID.
+func matchesEmoji(stored string, emoji *discordgo.Emoji) bool {
+	if emoji == nil || stored == "" {
+		return false
+	}
+	if match := customEmojiPattern.FindStringSubmatch(stored); match != nil {
+		return emoji.ID == match[3]
+	}
+	return emoji.ID == "" && emoji.Name == stored
+}
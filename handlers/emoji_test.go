@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestReactionAPIName(t *testing.T) {
+	t.Run("正常系: リアクションAPIに渡す形式に変換", func(t *testing.T) {
+		testCases := []struct {
+			emoji    string
+			expected string
+		}{
+			{"1️⃣", "1️⃣"},
+			{"<:cat:111>", "cat:111"},
+			{"<a:dog:222>", "dog:222"},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.emoji, func(t *testing.T) {
+				// Act
+				result := reactionAPIName(tc.emoji)
+
+				// Assert
+				if result != tc.expected {
+					t.Errorf("変換結果が期待値と異なります: got %v, want %v", result, tc.expected)
+				}
+			})
+		}
+	})
+}
+
+func TestComponentEmoji(t *testing.T) {
+	t.Run("正常系: コンポーネント用の絵文字に変換", func(t *testing.T) {
+		testCases := []struct {
+			emoji    string
+			expected *discordgo.ComponentEmoji
+		}{
+			{"🇦", &discordgo.ComponentEmoji{Name: "🇦"}},
+			{"<:cat:111>", &discordgo.ComponentEmoji{Name: "cat", ID: "111"}},
+			{"<a:dog:222>", &discordgo.ComponentEmoji{Name: "dog", ID: "222", Animated: true}},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.emoji, func(t *testing.T) {
+				// Act
+				result := componentEmoji(tc.emoji)
+
+				// Assert
+				if !reflect.DeepEqual(result, tc.expected) {
+					t.Errorf("変換結果が期待値と異なります: got %+v, want %+v", result, tc.expected)
+				}
+			})
+		}
+	})
+}
+
+func TestMatchesEmoji(t *testing.T) {
+	t.Run("正常系: リアクションの絵文字と照合", func(t *testing.T) {
+		testCases := []struct {
+			name     string
+			stored   string
+			emoji    *discordgo.Emoji
+			expected bool
+		}{
+			{"同じ絵文字", "1️⃣", &discordgo.Emoji{Name: "1️⃣"}, true},
+			{"異なる絵文字", "1️⃣", &discordgo.Emoji{Name: "2️⃣"}, false},
+			{"カスタム絵文字はIDで照合", "<:cat:111>", &discordgo.Emoji{Name: "renamed", ID: "111"}, true},
+			{"同名の別のカスタム絵文字", "<:cat:111>", &discordgo.Emoji{Name: "cat", ID: "999"}, false},
+			{"空の絵文字", "", &discordgo.Emoji{Name: ""}, false},
+			{"nilの絵文字", "1️⃣", nil, false},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				// Act
+				result := matchesEmoji(tc.stored, tc.emoji)
+
+				// Assert
+				if result != tc.expected {
+					t.Errorf("照合結果が期待値と異なります: got %v, want %v", result, tc.expected)
+				}
+			})
+		}
+	})
+}
@@ -27,21 +27,85 @@ func (h *helpHandler) CanHandle(command string) bool {
 }
 
 func (h *helpHandler) Handle(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
-	return h.sendHelpEmbeds(ctx, s, m)
+	for _, embed := range h.createHelpEmbeds() {
+		if _, err := s.ChannelMessageSendEmbed(m.ChannelID, embed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *helpHandler) ApplicationCommands() []*discordgo.ApplicationCommand {
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:        "help",
+			Description: "利用可能なコマンドを表示する",
+		},
+	}
+}
+
+func (h *helpHandler) CanHandleInteraction(i *discordgo.InteractionCreate) bool {
+	return isApplicationCommand(i, "help")
+}
+
+func (h *helpHandler) HandleInteraction(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	return respondEmbeds(s, i, h.createHelpEmbeds()...)
 }
 
-func (h *helpHandler) sendHelpEmbeds(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
+func (h *helpHandler) createHelpEmbeds() []*discordgo.MessageEmbed {
 	// Survey help embed
 	surveyDescription := "基本コマンドを上から順に実行することでアンケートが作成できる" + "\n" + "回答項目ごとにスタンプが作成されるため、回答の際には回答項目に対応するスタンプを押下する"
 
 	baseCommands := ""
-	baseCommands += string(types.CmdSurvey) + " : " + "アンケート作成を開始する" + "\n"
+	baseCommands += string(types.CmdSurvey) + " : " + "アンケート作成を開始する[オプションを続けて指定できる]" + "\n"
 	baseCommands += string(types.CmdTitle) + " : " + "アンケートのタイトルを入力する[改行区切りで入力する]" + "\n"
-	baseCommands += string(types.CmdContent) + " : " + "アンケートの回答項目を入力する[改行区切りで入力する]" + "\n"
+	baseCommands += string(types.CmdContent) + " : " + "アンケートの回答項目を入力してプレビューを表示する[改行区切りで入力する。!content #チャンネル で投稿先を指定できる]" + "\n"
+	baseCommands += string(types.CmdPublish) + " : " + "プレビューしたアンケートを公開する[プレビューの「公開」ボタンと同じ]" + "\n"
+	baseCommands += string(types.CmdPoll) + " : " + "1つのメッセージでアンケートを作成する[1行目にタイトル、次の行から回答項目を入力する。--multi で複数選択、--anon で匿名、--until 2h で期限を指定する]" + "\n"
+
+	surveyOptions := ""
+	surveyOptions += "--shared : チャンネル内の全員で編集する" + "\n"
+	surveyOptions += "--buttons : ボタンとセレクトメニューで投票する" + "\n"
+	surveyOptions += "--ranked : 順位を付けて投票する[即時決選投票とボルダ得点で集計する]" + "\n"
+	surveyOptions += "--anon : 匿名で投票する" + "\n"
+	surveyOptions += "--role @ロール : ロールのメンバーだけが投票できる[集計結果に投票率を表示する]" + "\n"
+	surveyOptions += "--quorum 10|60% : 定足数を投票者数か対象メンバーの割合で指定する[集計結果に達成したかを表示する]" + "\n"
+	surveyOptions += "--auto-close : 結果が確定した時点で自動で締め切る" + "\n"
+	surveyOptions += "--single : 1つだけ選択できる" + "\n"
+	surveyOptions += "--max N : N個まで選択できる" + "\n"
+	surveyOptions += "--emoji number|alphabet|circle|custom : 回答項目の絵文字を選ぶ" + "\n"
+	surveyOptions += "期限: 2h : 期限に自動で締め切る" + "\n"
+	surveyOptions += "--remind 2h [--remind-role @ロール] [--remind-dm] : 期限の前に未回答のメンバーへ自動でリマインドする" + "\n"
+
+	voteCommands := string(types.CmdRank) + " : " + "順位付けのアンケートに番号を希望順に並べて投票する[例: !rank 2 1 3。アンケートへの返信で対象を選ぶ。省略時はチャンネルの最新のアンケート]" + "\n"
+	voteCommands += string(types.CmdRemind) + " : " + "未回答のメンバーにリマインドする[@ロールで対象を絞る。--dm でダイレクトメッセージ。作成者か「メッセージの管理」権限が必要]" + "\n"
+
+	editCommands := ""
+	editCommands += string(types.CmdEdit) + " title : " + "公開済みのアンケートのタイトルを変更する[改行を挟んで新しいタイトルを入力する]" + "\n"
+	editCommands += string(types.CmdEdit) + " options : " + "公開済みのアンケートの回答項目を変更する[今の順に改行区切りで入力し、削除する項目は - と入力する。投票のある項目の削除には --force が必要]" + "\n"
+
+	resultCommands := ""
+	resultCommands += string(types.CmdClose) + " : " + "アンケートを締め切って集計結果を表示する[メッセージIDの指定かアンケートへの返信で対象を選ぶ。省略時はチャンネルの最新のアンケート]" + "\n"
+	resultCommands += string(types.CmdExport) + " : " + "集計結果をファイルで出力する[csv か json を指定できる。既定は csv。作成者か「メッセージの管理」権限が必要]" + "\n"
 
 	confirmationCommands := ""
 	confirmationCommands += string(types.CmdCheckTitle) + " : " + "アンケートのタイトルを確認する" + "\n"
 	confirmationCommands += string(types.CmdCheckState) + " : " + "アンケートの設定状況を確認する" + "\n"
+	confirmationCommands += string(types.CmdDrafts) + " : " + "サーバー内で作成中のアンケートを一覧表示する" + "\n"
+
+	templateCommands := ""
+	templateCommands += string(types.CmdTemplate) + " save <名前> : " + "タイトルと回答項目をテンプレートとして保存する[改行を挟んでタイトルと回答項目を入力するか、アンケートへの返信で実行する]" + "\n"
+	templateCommands += string(types.CmdTemplate) + " list : " + "サーバーのテンプレートを一覧表示する" + "\n"
+	templateCommands += string(types.CmdTemplate) + " delete <名前> : " + "テンプレートを削除する[作成者かサーバー管理権限が必要]" + "\n"
+	templateCommands += string(types.CmdSurvey) + " from <名前> : " + "テンプレートからアンケートを作成する[!survey のオプションを続けて指定できる]" + "\n"
+
+	scheduleCommands := ""
+	scheduleCommands += string(types.CmdSchedule) + " add <名前> <曜日> <時刻> [タイムゾーン] [#チャンネル] : " + "アンケートを定期的に投稿する[例: !schedule add standup mon 10:00 Asia/Tokyo。改行を挟んでタイトルと回答項目を入力するか from <テンプレート名> を指定する。!survey のオプションも指定できる]" + "\n"
+	scheduleCommands += string(types.CmdSchedule) + " list : " + "サーバーの定期アンケートを一覧表示する" + "\n"
+	scheduleCommands += string(types.CmdSchedule) + " pause|resume|remove <名前> : " + "定期アンケートを停止・再開・削除する" + "\n"
+	scheduleCommands += "定期アンケートの設定にはサーバー管理権限が必要" + "\n"
+
+	settingCommands := string(types.CmdEmoji) + " : " + "サーバーのアンケートで使う絵文字を確認・変更する[変更にはサーバー管理権限が必要]" + "\n"
 
 	surveyEmbed := &discordgo.MessageEmbed{
 		Title:       "アンケート機能使い方",
@@ -50,25 +114,24 @@ func (h *helpHandler) sendHelpEmbeds(ctx context.Context, s *discordgo.Session,
 		Fields: []*discordgo.MessageEmbedField{
 			{Name: "基本コマンド", Value: baseCommands, Inline: true},
 			{Name: "キャンセルコマンド", Value: string(types.CmdCancel) + " : " + "アンケートの作成を中止する" + "\n", Inline: true},
+			{Name: "!survey のオプション", Value: surveyOptions, Inline: false},
 			{Name: "確認コマンド", Value: confirmationCommands, Inline: false},
+			{Name: "投票コマンド", Value: voteCommands, Inline: false},
+			{Name: "編集コマンド", Value: editCommands, Inline: false},
+			{Name: "集計コマンド", Value: resultCommands, Inline: false},
+			{Name: "テンプレートコマンド", Value: templateCommands, Inline: false},
+			{Name: "定期アンケートコマンド", Value: scheduleCommands, Inline: false},
+			{Name: "設定コマンド", Value: settingCommands, Inline: false},
 		},
 	}
 
-	if _, err := s.ChannelMessageSendEmbed(m.ChannelID, surveyEmbed); err != nil {
-		return err
-	}
-
 	// Shuffle help embed
 	shuffleEmbed := &discordgo.MessageEmbed{
 		Title:       "シャッフル機能使い方",
-		Description: string(types.CmdShuffle) + " : " + "与えられた項目をシャッフルする[項目は改行区切りで入力する]" + "\n",
+		Description: string(types.CmdShuffle) + " : " + "与えられた項目をシャッフルする[項目は改行区切りで入力する。1行で書くときはスペースまたはカンマ区切り]" + "\n",
 		Color:       0xA4B814,
 	}
 
-	if _, err := s.ChannelMessageSendEmbed(m.ChannelID, shuffleEmbed); err != nil {
-		return err
-	}
-
 	// Coupling help embed
 	couplingEmbed := &discordgo.MessageEmbed{
 		Title: "カップリング機能使い方",
@@ -79,6 +142,5 @@ func (h *helpHandler) sendHelpEmbeds(ctx context.Context, s *discordgo.Session,
 		Color:       0xA4B814,
 	}
 
-	_, err := s.ChannelMessageSendEmbed(m.ChannelID, couplingEmbed)
-	return err
+	return []*discordgo.MessageEmbed{surveyEmbed, shuffleEmbed, couplingEmbed}
 }
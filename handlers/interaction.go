@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// isApplicationCommand reports whether i is the slash command with the given name
+func isApplicationCommand(i *discordgo.InteractionCreate, name string) bool {
+	return i.Type == discordgo.InteractionApplicationCommand && i.ApplicationCommandData().Name == name
+}
+
+// interactionUser returns the user who triggered i, whether in a guild or a DM
+func interactionUser(i *discordgo.InteractionCreate) *discordgo.User {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// subcommandName returns the subcommand of the slash command i, or "" when it has none
+func subcommandName(i *discordgo.InteractionCreate) string {
+	for _, option := range i.ApplicationCommandData().Options {
+		if option.Type == discordgo.ApplicationCommandOptionSubCommand {
+			return option.Name
+		}
+	}
+	return ""
+}
+
+// commandOption finds the named option of i, among the options of its subcommand when
+// one was used
+func commandOption(i *discordgo.InteractionCreate, name string) *discordgo.ApplicationCommandInteractionDataOption {
+	data := i.ApplicationCommandData()
+	for _, option := range data.Options {
+		if option.Type == discordgo.ApplicationCommandOptionSubCommand {
+			return option.GetOption(name)
+		}
+	}
+	return data.GetOption(name)
+}
+
+// stringOption returns the value of a string option, or "" when it was omitted
+func stringOption(i *discordgo.InteractionCreate, name string) string {
+	option := commandOption(i, name)
+	if option == nil {
+		return ""
+	}
+	return option.StringValue()
+}
+
+// intOption returns the value of an integer option, or 0 when it was omitted
+func intOption(i *discordgo.InteractionCreate, name string) int {
+	option := commandOption(i, name)
+	if option == nil {
+		return 0
+	}
+	return int(option.IntValue())
+}
+
+// roleOption returns the ID of the role chosen for a role option, or "" when it was omitted
+func roleOption(i *discordgo.InteractionCreate, name string) string {
+	option := commandOption(i, name)
+	if option == nil {
+		return ""
+	}
+	return option.RoleValue(nil, "").ID
+}
+
+// channelOption returns the ID of the channel chosen for an option, or "" when it was omitted
+func channelOption(i *discordgo.InteractionCreate, name string) string {
+	option := commandOption(i, name)
+	if option == nil {
+		return ""
+	}
+	return option.ChannelValue(nil).ID
+}
+
+// boolOption returns the value of a boolean option, or false when it was omitted
+func boolOption(i *discordgo.InteractionCreate, name string) bool {
+	option := commandOption(i, name)
+	if option == nil {
+		return false
+	}
+	return option.BoolValue()
+}
+
+// modalValue returns what was entered in the text input customID of a submitted form
+func modalValue(data discordgo.ModalSubmitInteractionData, customID string) string {
+	for _, component := range data.Components {
+		row, ok := component.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, field := range row.Components {
+			if input, ok := field.(*discordgo.TextInput); ok && input.CustomID == customID {
+				return input.Value
+			}
+		}
+	}
+	return ""
+}
+
+// respondEmbeds replies to i with embeds visible to the whole channel
+func respondEmbeds(s *discordgo.Session, i *discordgo.InteractionCreate, embeds ...*discordgo.MessageEmbed) error {
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: embeds,
+		},
+	})
+}
+
+// respondEphemeral replies to i with a message only the invoking user can see
+func respondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, content string) error {
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// deferEphemeral acknowledges i so that slow work can finish after Discord's 3 second
+// deadline; the reply is sent later with editResponse
+func deferEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// editResponse replaces the deferred reply to i with content
+func editResponse(s *discordgo.Session, i *discordgo.InteractionCreate, content string) error {
+	_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+	})
+	return err
+}
@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/bwmarrin/discordgo"
+)
+
+func newCommandInteraction(name string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type: discordgo.InteractionApplicationCommand,
+		Data: discordgo.ApplicationCommandInteractionData{Name: name, Options: options},
+	}}
+}
+
+func newInteractionHandlers() map[string]types.InteractionHandler {
+	logger := &mockLogger{}
+	emojiProvider := &mockEmojiProvider{emojis: []string{"0️⃣", "1️⃣", "2️⃣"}}
+	return map[string]types.InteractionHandler{
+		"survey":   NewSurveyHandler(&mockStateManager{}, &mockSurveyRegistry{}, &mockGuildSettingsStore{}, &mockTemplateStore{}, &mockScheduleStore{}, &mockScheduler{}, emojiProvider, logger).(types.InteractionHandler),
+		"shuffle":  NewShuffleHandler(&mockShuffler{}, emojiProvider, logger).(types.InteractionHandler),
+		"coupling": NewCouplingHandler(nil, emojiProvider, logger).(types.InteractionHandler),
+		"help":     NewHelpHandler(logger).(types.InteractionHandler),
+	}
+}
+
+func TestInteractionHandlers_ApplicationCommands(t *testing.T) {
+	t.Run("正常系: 各ハンドラーが自身のスラッシュコマンドを登録する", func(t *testing.T) {
+		// Arrange
+		handlers := newInteractionHandlers()
+
+		for name, handler := range handlers {
+			t.Run(name, func(t *testing.T) {
+				// Act
+				commands := handler.ApplicationCommands()
+
+				// Assert
+				if len(commands) != 1 {
+					t.Fatalf("コマンド数が期待値と異なります: got %v, want %v", len(commands), 1)
+				}
+				if commands[0].Name != name {
+					t.Errorf("コマンド名が期待値と異なります: got %v, want %v", commands[0].Name, name)
+				}
+				if len(commands[0].Options) > 25 {
+					t.Errorf("オプション数がDiscordの上限を超えています: %v", len(commands[0].Options))
+				}
+			})
+		}
+	})
+
+	t.Run("正常系: 必須のオプションは任意のオプションより前にある", func(t *testing.T) {
+		for name, handler := range newInteractionHandlers() {
+			t.Run(name, func(t *testing.T) {
+				// Act
+				commands := handler.ApplicationCommands()
+
+				// Assert
+				for _, command := range commands {
+					optionalSeen := false
+					for _, option := range command.Options {
+						if option.Type == discordgo.ApplicationCommandOptionSubCommand {
+							checkOptionOrder(t, command.Name+" "+option.Name, option.Options)
+							continue
+						}
+						if !option.Required {
+							optionalSeen = true
+						} else if optionalSeen {
+							t.Errorf("%sの必須オプション%sが任意のオプションの後にあります", command.Name, option.Name)
+						}
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("正常系: survey quickはタイトルと回答項目を受け取る", func(t *testing.T) {
+		// Arrange
+		handler := newInteractionHandlers()["survey"]
+
+		// Act
+		command := subcommand(handler.ApplicationCommands()[0], surveyQuickCommand)
+
+		// Assert
+		if command == nil {
+			t.Fatalf("%sサブコマンドがありません", surveyQuickCommand)
+		}
+		if command.Options[0].Name != "title" || !command.Options[0].Required {
+			t.Errorf("最初のオプションが必須のタイトルではありません: %+v", command.Options[0])
+		}
+		optionCount := 0
+		for _, option := range command.Options {
+			if strings.HasPrefix(option.Name, "option") {
+				optionCount++
+			}
+		}
+		if optionCount != surveySlashOptions {
+			t.Errorf("回答項目の数が期待値と異なります: got %v, want %v", optionCount, surveySlashOptions)
+		}
+	})
+
+	t.Run("正常系: survey createは入力フォームで内容を受け取り設定だけをオプションにする", func(t *testing.T) {
+		// Arrange
+		handler := newInteractionHandlers()["survey"]
+
+		// Act
+		command := subcommand(handler.ApplicationCommands()[0], surveyCreateCommand)
+
+		// Assert
+		if command == nil {
+			t.Fatalf("%sサブコマンドがありません", surveyCreateCommand)
+		}
+		for _, option := range command.Options {
+			if option.Required || option.Name == "title" || strings.HasPrefix(option.Name, "option") {
+				t.Errorf("入力フォームで受け取る内容がオプションになっています: %v", option.Name)
+			}
+		}
+	})
+}
+
+func subcommand(command *discordgo.ApplicationCommand, name string) *discordgo.ApplicationCommandOption {
+	for _, option := range command.Options {
+		if option.Type == discordgo.ApplicationCommandOptionSubCommand && option.Name == name {
+			return option
+		}
+	}
+	return nil
+}
+
+func checkOptionOrder(t *testing.T, name string, options []*discordgo.ApplicationCommandOption) {
+	t.Helper()
+	optionalSeen := false
+	for _, option := range options {
+		if !option.Required {
+			optionalSeen = true
+		} else if optionalSeen {
+			t.Errorf("%sの必須オプション%sが任意のオプションの後にあります", name, option.Name)
+		}
+	}
+	if len(options) > 25 {
+		t.Errorf("%sのオプション数がDiscordの上限を超えています: %v", name, len(options))
+	}
+}
+
+func TestInteractionHandlers_CanHandleInteraction(t *testing.T) {
+	t.Run("正常系: コマンド名に対応するハンドラーだけが処理する", func(t *testing.T) {
+		// Arrange
+		handlers := newInteractionHandlers()
+
+		for command := range handlers {
+			for name, handler := range handlers {
+				t.Run(command+"/"+name, func(t *testing.T) {
+					// Act
+					result := handler.CanHandleInteraction(newCommandInteraction(command))
+
+					// Assert
+					if result != (command == name) {
+						t.Errorf("判定が期待値と異なります: command=%v, handler=%v, got=%v", command, name, result)
+					}
+				})
+			}
+		}
+	})
+
+	t.Run("正常系: アンケートの入力フォームはsurveyハンドラーだけが処理する", func(t *testing.T) {
+		// Arrange
+		interaction := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			Type: discordgo.InteractionModalSubmit,
+			Data: discordgo.ModalSubmitInteractionData{CustomID: surveyModalPrefix + "m=reaction"},
+		}}
+
+		for name, handler := range newInteractionHandlers() {
+			// Act
+			result := handler.CanHandleInteraction(interaction)
+
+			// Assert
+			if result != (name == "survey") {
+				t.Errorf("判定が期待値と異なります: handler=%v, got=%v", name, result)
+			}
+		}
+	})
+
+	t.Run("正常系: コマンド以外のインタラクションは処理しない", func(t *testing.T) {
+		// Arrange
+		interaction := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			Type: discordgo.InteractionPing,
+		}}
+
+		for name, handler := range newInteractionHandlers() {
+			// Act & Assert
+			if handler.CanHandleInteraction(interaction) {
+				t.Errorf("%v ハンドラーがPingを処理しようとしています", name)
+			}
+		}
+	})
+}
+
+func TestInteractionHelpers(t *testing.T) {
+	t.Run("正常系: サーバー内とDMの両方で実行ユーザーを取得", func(t *testing.T) {
+		// Arrange
+		guild := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			Member: &discordgo.Member{User: &discordgo.User{ID: "member"}},
+		}}
+		dm := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			User: &discordgo.User{ID: "dm-user"},
+		}}
+
+		// Act & Assert
+		if user := interactionUser(guild); user.ID != "member" {
+			t.Errorf("ユーザーが期待値と異なります: got %v, want %v", user.ID, "member")
+		}
+		if user := interactionUser(dm); user.ID != "dm-user" {
+			t.Errorf("ユーザーが期待値と異なります: got %v, want %v", user.ID, "dm-user")
+		}
+	})
+
+	t.Run("正常系: 文字列オプションの取得", func(t *testing.T) {
+		// Arrange
+		interaction := newCommandInteraction("survey", &discordgo.ApplicationCommandInteractionDataOption{
+			Name:  "title",
+			Type:  discordgo.ApplicationCommandOptionString,
+			Value: "好きな言語",
+		})
+
+		// Act & Assert
+		if value := stringOption(interaction, "title"); value != "好きな言語" {
+			t.Errorf("オプションの値が期待値と異なります: got %v, want %v", value, "好きな言語")
+		}
+		if value := stringOption(interaction, "option1"); value != "" {
+			t.Errorf("省略されたオプションは空文字列が期待されていましたが、%vが返されました", value)
+		}
+	})
+
+	t.Run("正常系: サブコマンドのオプションの取得", func(t *testing.T) {
+		// Arrange
+		interaction := newCommandInteraction("survey", &discordgo.ApplicationCommandInteractionDataOption{
+			Name: surveyCreateCommand,
+			Type: discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: "max", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(2)},
+			},
+		})
+
+		// Act & Assert
+		if name := subcommandName(interaction); name != surveyCreateCommand {
+			t.Errorf("サブコマンド名が期待値と異なります: got %v, want %v", name, surveyCreateCommand)
+		}
+		if value := intOption(interaction, "max"); value != 2 {
+			t.Errorf("オプションの値が期待値と異なります: got %v, want %v", value, 2)
+		}
+		if value := stringOption(interaction, "mode"); value != "" {
+			t.Errorf("省略されたオプションは空文字列が期待されていましたが、%vが返されました", value)
+		}
+	})
+
+	t.Run("正常系: 入力フォームの値の取得", func(t *testing.T) {
+		// Arrange
+		data := discordgo.ModalSubmitInteractionData{Components: []discordgo.MessageComponent{
+			&discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				&discordgo.TextInput{CustomID: modalTitleID, Value: "好きな言語"},
+			}},
+			&discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				&discordgo.TextInput{CustomID: modalOptionsID, Value: "Go\nRust"},
+			}},
+		}}
+
+		// Act & Assert
+		if value := modalValue(data, modalOptionsID); value != "Go\nRust" {
+			t.Errorf("入力値が期待値と異なります: got %q, want %q", value, "Go\nRust")
+		}
+		if value := modalValue(data, "missing"); value != "" {
+			t.Errorf("存在しない入力欄は空文字列が期待されていましたが、%qが返されました", value)
+		}
+	})
+}
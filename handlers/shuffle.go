@@ -3,10 +3,10 @@ package handlers
 import (
 	"context"
 	"fmt"
-	"regexp"
 	"strings"
 
 	"github.com/Logta/SurveyBot/types"
+	"github.com/Logta/SurveyBot/utils"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -14,7 +14,6 @@ type shuffleHandler struct {
 	shuffler      types.Shuffler
 	emojiProvider types.EmojiProvider
 	logger        types.Logger
-	regexPattern  *regexp.Regexp
 }
 
 // NewShuffleHandler creates a new shuffle command handler
@@ -23,7 +22,6 @@ func NewShuffleHandler(shuffler types.Shuffler, emojiProvider types.EmojiProvide
 		shuffler:      shuffler,
 		emojiProvider: emojiProvider,
 		logger:        logger,
-		regexPattern:  regexp.MustCompile(`\r\n|\n| |,`),
 	}
 }
 
@@ -36,47 +34,103 @@ func (h *shuffleHandler) CanHandle(command string) bool {
 }
 
 func (h *shuffleHandler) Handle(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
-	parts := h.regexPattern.Split(m.Content, -1)
-	if len(parts) <= 1 {
+	items, err := shuffleItems(m.Content)
+	if err != nil {
+		_, err := s.ChannelMessageSend(m.ChannelID, unclosedQuoteMessage)
+		return err
+	}
+	if len(items) == 0 {
 		_, err := s.ChannelMessageSend(m.ChannelID, "コマンドの後に改行を挟んでシャッフル項目を記入してください")
 		return err
 	}
 
-	if len(parts) <= 2 {
+	if len(items) <= 1 {
 		_, err := s.ChannelMessageSend(m.ChannelID, "シャッフル項目は2つ以上記入してください")
 		return err
 	}
 
-	items := parts[1:]
 	shuffledItems := h.shuffler.Shuffle(ctx, items)
 
-	return h.createShuffleEmbed(ctx, s, m, shuffledItems)
+	embed, err := h.createShuffleEmbed(ctx, shuffledItems)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.ChannelMessageSendEmbed(m.ChannelID, embed)
+	return err
 }
 
-func (h *shuffleHandler) createShuffleEmbed(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, items []string) error {
+// shuffleItems reads the items of a !shuffle message: one per line, or separated by
+// spaces or commas when they are all written on the command's line, e.g. "!shuffle a b c"
+func shuffleItems(content string) ([]string, error) {
+	text := utils.CommandText(content)
+	if !strings.ContainsAny(text, "\r\n") {
+		return utils.ParseList(text)
+	}
+	return utils.ParseLines(text)
+}
+
+func (h *shuffleHandler) ApplicationCommands() []*discordgo.ApplicationCommand {
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:        "shuffle",
+			Description: "与えられた項目をシャッフルする",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "items",
+					Description: "シャッフルする項目[スペースまたはカンマ区切りで入力する。区切りを含む項目は \"\" で囲む]",
+					Required:    true,
+				},
+			},
+		},
+	}
+}
+
+func (h *shuffleHandler) CanHandleInteraction(i *discordgo.InteractionCreate) bool {
+	return isApplicationCommand(i, "shuffle")
+}
+
+func (h *shuffleHandler) HandleInteraction(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	// Option values cannot hold line breaks, so items are separated by spaces or commas
+	items, err := utils.ParseList(stringOption(i, "items"))
+	if err != nil {
+		return respondEphemeral(s, i, unclosedQuoteMessage)
+	}
+
+	if len(items) <= 1 {
+		return respondEphemeral(s, i, "シャッフル項目は2つ以上記入してください")
+	}
+
+	embed, err := h.createShuffleEmbed(ctx, h.shuffler.Shuffle(ctx, items))
+	if err != nil {
+		return respondEphemeral(s, i, fmt.Sprintf("シャッフル項目は%d個まで記入できます", h.emojiProvider.GetMaxEmojis()))
+	}
+
+	return respondEmbeds(s, i, embed)
+}
+
+func (h *shuffleHandler) createShuffleEmbed(ctx context.Context, items []string) (*discordgo.MessageEmbed, error) {
 	description := ""
 	maxEmojis := h.emojiProvider.GetMaxEmojis()
 
 	if len(items) > maxEmojis {
-		return fmt.Errorf("too many items: %d (max: %d)", len(items), maxEmojis)
+		return nil, fmt.Errorf("too many items: %d (max: %d)", len(items), maxEmojis)
 	}
 
 	for i, item := range items {
 		emoji, err := h.emojiProvider.GetEmoji(ctx, i)
 		if err != nil {
 			h.logger.Error(ctx, "Failed to get emoji", err)
-			return err
+			return nil, err
 		}
 
 		description += fmt.Sprintf("%s : %s\n", emoji, item)
 	}
 
-	embed := &discordgo.MessageEmbed{
+	return &discordgo.MessageEmbed{
 		Title:       "シャッフル結果",
 		Description: description,
 		Color:       0x141DB8,
-	}
-
-	_, err := s.ChannelMessageSendEmbed(m.ChannelID, embed)
-	return err
+	}, nil
 }
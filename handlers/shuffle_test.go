@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
 )
 
@@ -183,6 +184,35 @@ func TestShuffleHandler_Handle(t *testing.T) {
 	})
 }
 
+func TestShuffleItems(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{"正常系: 1行ならスペース区切り", "!shuffle 田中 佐藤 鈴木", []string{"田中", "佐藤", "鈴木"}},
+		{"正常系: 1行ならカンマ区切り", "!shuffle 田中,佐藤, 鈴木", []string{"田中", "佐藤", "鈴木"}},
+		{"正常系: 改行区切りなら項目内のスペースを残す", "!shuffle\n東京 駅\n大阪 駅", []string{"東京 駅", "大阪 駅"}},
+		{"正常系: 引用符で1行に複数の項目", "!shuffle \"東京 駅\" \"大阪 駅\"", []string{"東京 駅", "大阪 駅"}},
+		{"異常系: 項目なし", "!shuffle", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			items, err := shuffleItems(tt.content)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("期待していないエラーが発生: %v", err)
+			}
+			if !reflect.DeepEqual(items, tt.expected) {
+				t.Errorf("項目が期待値と異なります: got %q, want %q", items, tt.expected)
+			}
+		})
+	}
+}
+
 func TestShuffleHandler_CreateShuffleEmbed(t *testing.T) {
 	t.Run("正常系: 絵文字とアイテムの対応", func(t *testing.T) {
 		// Arrange
@@ -2,28 +2,46 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/Logta/SurveyBot/types"
+	"github.com/Logta/SurveyBot/utils"
 	"github.com/bwmarrin/discordgo"
 )
 
 type surveyHandler struct {
 	stateManager  types.StateManager
+	registry      types.SurveyRegistry
+	guildSettings types.GuildSettingsStore
+	templates     types.TemplateStore
+	schedules     types.ScheduleStore
+	scheduler     types.Scheduler
 	emojiProvider types.EmojiProvider
 	logger        types.Logger
-	regexPattern  *regexp.Regexp
+	// reactionMu serializes reaction votes so a member's choices are updated one event at a time
+	reactionMu sync.Mutex
+	// eligible remembers who may vote on auto-closing surveys between votes
+	eligible eligibleCache
 }
 
 // NewSurveyHandler creates a new survey command handler
-func NewSurveyHandler(stateManager types.StateManager, emojiProvider types.EmojiProvider, logger types.Logger) types.Handler {
+func NewSurveyHandler(stateManager types.StateManager, registry types.SurveyRegistry, guildSettings types.GuildSettingsStore, templates types.TemplateStore, schedules types.ScheduleStore, scheduler types.Scheduler, emojiProvider types.EmojiProvider, logger types.Logger) types.Handler {
 	return &surveyHandler{
 		stateManager:  stateManager,
+		registry:      registry,
+		guildSettings: guildSettings,
+		templates:     templates,
+		schedules:     schedules,
+		scheduler:     scheduler,
 		emojiProvider: emojiProvider,
 		logger:        logger,
-		regexPattern:  regexp.MustCompile(`\r\n|\n| |,`),
 	}
 }
 
@@ -35,74 +53,358 @@ func (h *surveyHandler) CanHandle(command string) bool {
 	return strings.HasPrefix(command, string(types.CmdSurvey)) ||
 		strings.HasPrefix(command, string(types.CmdTitle)) ||
 		strings.HasPrefix(command, string(types.CmdContent)) ||
+		strings.HasPrefix(command, string(types.CmdClose)) ||
 		command == string(types.CmdCancel) ||
+		isCommand(command, types.CmdPublish) ||
+		isCommand(command, types.CmdPoll) ||
+		isCommand(command, types.CmdEmoji) ||
+		isCommand(command, types.CmdRank) ||
+		isCommand(command, types.CmdEdit) ||
+		isCommand(command, types.CmdExport) ||
+		isCommand(command, types.CmdTemplate) ||
+		isCommand(command, types.CmdSchedule) ||
+		isCommand(command, types.CmdRemind) ||
 		command == string(types.CmdCheckState) ||
-		command == string(types.CmdCheckTitle)
+		command == string(types.CmdCheckTitle) ||
+		command == string(types.CmdDrafts)
 }
 
 func (h *surveyHandler) Handle(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
-	guildID := m.GuildID
-	if guildID == "" {
-		guildID = "dm" // Handle direct messages
-	}
-
 	switch {
-	case m.Content == string(types.CmdSurvey):
-		return h.handleSurveyStart(ctx, s, m, guildID)
+	case strings.HasPrefix(m.Content, string(types.CmdSurvey)):
+		return h.handleSurveyStart(ctx, s, m)
 
 	case m.Content == string(types.CmdCancel):
-		return h.handleCancel(ctx, s, m, guildID)
+		return h.handleCancel(ctx, s, m)
+
+	case isCommand(m.Content, types.CmdPublish):
+		return h.handlePublish(ctx, s, m)
+
+	case isCommand(m.Content, types.CmdPoll):
+		return h.handlePoll(ctx, s, m)
 
 	case m.Content == string(types.CmdCheckState):
-		return h.handleCheckState(ctx, s, m, guildID)
+		return h.handleCheckState(ctx, s, m)
 
 	case m.Content == string(types.CmdCheckTitle):
-		return h.handleCheckTitle(ctx, s, m, guildID)
+		return h.handleCheckTitle(ctx, s, m)
+
+	case m.Content == string(types.CmdDrafts):
+		return h.handleDrafts(ctx, s, m)
+
+	case isCommand(m.Content, types.CmdEmoji):
+		return h.handleEmoji(ctx, s, m)
+
+	case isCommand(m.Content, types.CmdRank):
+		return h.handleRank(ctx, s, m)
+
+	case isCommand(m.Content, types.CmdEdit):
+		return h.handleEdit(ctx, s, m)
+
+	case isCommand(m.Content, types.CmdExport):
+		return h.handleExport(ctx, s, m)
+
+	case isCommand(m.Content, types.CmdTemplate):
+		return h.handleTemplate(ctx, s, m)
+
+	case isCommand(m.Content, types.CmdSchedule):
+		return h.handleSchedule(ctx, s, m)
+
+	case isCommand(m.Content, types.CmdRemind):
+		return h.handleRemind(ctx, s, m)
 
 	case strings.HasPrefix(m.Content, string(types.CmdTitle)):
-		return h.handleTitle(ctx, s, m, guildID)
+		return h.handleTitle(ctx, s, m)
 
 	case strings.HasPrefix(m.Content, string(types.CmdContent)):
-		return h.handleContent(ctx, s, m, guildID)
+		return h.handleContent(ctx, s, m)
+
+	case strings.HasPrefix(m.Content, string(types.CmdClose)):
+		return h.handleClose(ctx, s, m)
 	}
 
 	return nil
 }
 
-func (h *surveyHandler) handleSurveyStart(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, guildID string) error {
+// isCommand reports whether content is cmd, alone or followed by whitespace and arguments
+func isCommand(content string, cmd types.Command) bool {
+	rest, ok := strings.CutPrefix(content, string(cmd))
+	if !ok {
+		return false
+	}
+	next, _ := utf8.DecodeRuneInString(rest)
+	return rest == "" || unicode.IsSpace(next)
+}
+
+// draftKey returns the key of the author's own draft, or of the channel's shared draft
+func draftKey(m *discordgo.MessageCreate, shared bool) types.DraftKey {
+	key := types.DraftKey{
+		GuildID:   m.GuildID,
+		ChannelID: m.ChannelID,
+		AuthorID:  m.Author.ID,
+	}
+	if shared {
+		key.AuthorID = ""
+	}
+	return key
+}
+
+// getDraft finds the draft the author is working on, preferring their own draft over the
+// channel's shared one
+func (h *surveyHandler) getDraft(ctx context.Context, m *discordgo.MessageCreate) (string, *types.SurveyState, error) {
+	key := draftKey(m, false).String()
+	state, err := h.stateManager.GetState(ctx, key)
+	if err != nil || state.Active {
+		return key, state, err
+	}
+
+	sharedKey := draftKey(m, true).String()
+	sharedState, err := h.stateManager.GetState(ctx, sharedKey)
+	if err != nil {
+		return sharedKey, nil, err
+	}
+	if sharedState.Active {
+		return sharedKey, sharedState, nil
+	}
+
+	return key, state, nil
+}
+
+var (
+	errInvalidMaxChoices = errors.New("invalid --max value")
+	errInvalidDeadline   = errors.New("invalid deadline")
+	errInvalidEmojiSet   = errors.New("invalid --emoji value")
+	errInvalidReminder   = errors.New("invalid --remind value")
+	errInvalidRole       = errors.New("invalid --role value")
+	errInvalidQuorum     = errors.New("invalid --quorum value")
+	errUnknownFlag       = errors.New("unknown flag")
+)
+
+// surveyFlagsUsage lists the flags of !survey, for replies to flags that cannot be read
+const surveyFlagsUsage = "使用できないオプションが指定されています。使用できるのは次のオプションです\n" +
+	"--shared --buttons --ranked --anon --single --max N --emoji 種類 --role @ロール --quorum 10|60% --auto-close " +
+	"--remind 期間 --remind-role @ロール --remind-dm 期限: 2h"
+
+// unclosedQuoteMessage answers items whose quote is never closed
+const unclosedQuoteMessage = "引用符（\"）が閉じられていません。項目を引用符で囲む場合は \"東京 駅\" のように閉じてください"
+
+// parseSurveyFlags reads the !survey flags: --shared, --buttons, --ranked, --anon, --emoji SET, --single, --max N and
+// 期限: <duration or time>. Relative deadlines are counted from now; any other word is an error.
+func parseSurveyFlags(args []string, now time.Time) (bool, types.SurveySettings, error) {
+	shared := false
+	settings := types.SurveySettings{VoteMode: types.VoteModeReaction}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--shared":
+			shared = true
+		case arg == "--buttons":
+			settings.VoteMode = types.VoteModeComponent
+		case arg == "--ranked":
+			settings.VoteMode = types.VoteModeRanked
+		case arg == "--anon":
+			settings.Anonymous = true
+			if settings.VoteMode == types.VoteModeReaction {
+				settings.VoteMode = types.VoteModeComponent
+			}
+		case arg == "--emoji" || strings.HasPrefix(arg, "--emoji="):
+			value := strings.TrimPrefix(arg, "--emoji=")
+			if arg == "--emoji" {
+				if i+1 >= len(args) {
+					return false, settings, errInvalidEmojiSet
+				}
+				i++
+				value = args[i]
+			}
+			set, ok := parseEmojiSet(value)
+			if !ok {
+				return false, settings, fmt.Errorf("%w: %q", errInvalidEmojiSet, value)
+			}
+			settings.EmojiSet = set
+		case arg == "--single":
+			settings.MaxChoices = 1
+		case arg == "--max" || strings.HasPrefix(arg, "--max="):
+			value := strings.TrimPrefix(arg, "--max=")
+			if arg == "--max" {
+				if i+1 >= len(args) {
+					return false, settings, errInvalidMaxChoices
+				}
+				i++
+				value = args[i]
+			}
+			maxChoices, err := strconv.Atoi(value)
+			if err != nil || maxChoices < 1 {
+				return false, settings, fmt.Errorf("%w: %q", errInvalidMaxChoices, value)
+			}
+			settings.MaxChoices = maxChoices
+		case arg == "--remind" || strings.HasPrefix(arg, "--remind="):
+			value := strings.TrimPrefix(arg, "--remind=")
+			if arg == "--remind" {
+				if i+1 >= len(args) {
+					return false, settings, errInvalidReminder
+				}
+				i++
+				value = args[i]
+			}
+			before, err := parseReminderBefore(value)
+			if err != nil {
+				return false, settings, err
+			}
+			settings.ReminderBefore = before
+		case arg == "--remind-role" || strings.HasPrefix(arg, "--remind-role="):
+			value := strings.TrimPrefix(arg, "--remind-role=")
+			if arg == "--remind-role" {
+				if i+1 >= len(args) {
+					return false, settings, errInvalidReminder
+				}
+				i++
+				value = args[i]
+			}
+			roleID, ok := parseRoleID(value)
+			if !ok {
+				return false, settings, fmt.Errorf("%w: %q", errInvalidReminder, value)
+			}
+			settings.ReminderRoleID = roleID
+		case arg == "--role" || strings.HasPrefix(arg, "--role="):
+			value := strings.TrimPrefix(arg, "--role=")
+			if arg == "--role" {
+				if i+1 >= len(args) {
+					return false, settings, errInvalidRole
+				}
+				i++
+				value = args[i]
+			}
+			roleID, ok := parseRoleID(value)
+			if !ok {
+				return false, settings, fmt.Errorf("%w: %q", errInvalidRole, value)
+			}
+			settings.EligibleRoleID = roleID
+		case arg == "--quorum" || strings.HasPrefix(arg, "--quorum="):
+			value := strings.TrimPrefix(arg, "--quorum=")
+			if arg == "--quorum" {
+				if i+1 >= len(args) {
+					return false, settings, errInvalidQuorum
+				}
+				i++
+				value = args[i]
+			}
+			quorum, percent, err := parseQuorum(value)
+			if err != nil {
+				return false, settings, err
+			}
+			settings.Quorum, settings.QuorumPercent = quorum, percent
+		case arg == "--auto-close":
+			settings.AutoClose = true
+		case arg == "--remind-dm":
+			settings.ReminderDM = true
+		case strings.HasPrefix(arg, deadlineLabel):
+			value := strings.TrimLeft(strings.TrimPrefix(arg, deadlineLabel), ":：")
+			if value == "" {
+				if i+1 >= len(args) {
+					return false, settings, errInvalidDeadline
+				}
+				i++
+				value = args[i]
+			}
+			deadline, err := parseDeadline(value, now)
+			if err != nil {
+				return false, settings, err
+			}
+			settings.Deadline = deadline
+		default:
+			// Publishing without a misspelt setting would be worse than asking again
+			return false, settings, fmt.Errorf("%w: %q", errUnknownFlag, arg)
+		}
+	}
+
+	// The reminder counts back from the deadline and must still lie ahead
+	if settings.ReminderBefore > 0 && (settings.Deadline.IsZero() || !settings.Deadline.Add(-settings.ReminderBefore).After(now)) {
+		return false, settings, fmt.Errorf("%w: reminder is not before the deadline", errInvalidReminder)
+	}
+	return shared, settings, nil
+}
+
+// surveyFlagsErrorMessage explains an error returned by parseSurveyFlags
+func surveyFlagsErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, errInvalidDeadline):
+		return "期限は「期限: 2h」のような期間か、「2006-01-02T15:04」のような未来の日時で指定してください"
+	case errors.Is(err, errInvalidEmojiSet):
+		return "--emoji には number, alphabet, circle, custom のいずれかを指定してください"
+	case errors.Is(err, errInvalidQuorum):
+		return "--quorum には必要な投票者数（例: 10）か、対象メンバーに対する割合（例: 60%）を指定してください"
+	case errors.Is(err, errInvalidRole):
+		return "--role には投票できるロールのメンションか ID を指定してください"
+	case errors.Is(err, errInvalidReminder):
+		return "自動リマインドは「期限: 1d --remind 2h」のように期限と、期限より短い期間を指定してください。--remind-role にはロールのメンションか ID を指定します"
+	case errors.Is(err, errInvalidMaxChoices):
+		return "--max には1以上の数値を指定してください"
+	default:
+		return surveyFlagsUsage
+	}
+}
+
+func (h *surveyHandler) handleSurveyStart(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
+	args := strings.Fields(m.Content)[1:]
+	if len(args) > 0 && args[0] == templateSource {
+		return h.handleSurveyFromTemplate(ctx, s, m, args[1:])
+	}
+
+	shared, settings, err := parseSurveyFlags(args, time.Now())
+	if err != nil {
+		_, sendErr := s.ChannelMessageSend(m.ChannelID, surveyFlagsErrorMessage(err))
+		return sendErr
+	}
+
+	key := draftKey(m, shared)
 	state := &types.SurveyState{
-		Active: true,
-		Title:  "",
+		Active:         true,
+		Title:          "",
+		GuildID:        key.GuildID,
+		ChannelID:      key.ChannelID,
+		AuthorID:       key.AuthorID,
+		SurveySettings: settings,
 	}
 
-	if err := h.stateManager.SetState(ctx, guildID, state); err != nil {
+	if err := h.stateManager.SetState(ctx, key.String(), state); err != nil {
 		h.logger.Error(ctx, "Failed to set survey state", err)
 		return err
 	}
 
-	_, err := s.ChannelMessageSend(m.ChannelID, "アンケートのタイトルを入力してください")
+	_, err = s.ChannelMessageSend(m.ChannelID, "アンケートのタイトルを入力してください")
 	return err
 }
 
-func (h *surveyHandler) handleCancel(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, guildID string) error {
-	if err := h.stateManager.ClearState(ctx, guildID); err != nil {
+func (h *surveyHandler) handleCancel(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
+	key, state, err := h.getDraft(ctx, m)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get survey state", err)
+		return err
+	}
+
+	if err := h.stateManager.ClearState(ctx, key); err != nil {
 		h.logger.Error(ctx, "Failed to clear survey state", err)
 		return err
 	}
+	if state.PreviewMessageID != "" {
+		h.closePreview(ctx, s, state.ChannelID, state.PreviewMessageID, "アンケート作成をキャンセルしました")
+	}
 
-	_, err := s.ChannelMessageSend(m.ChannelID, "アンケート作成をキャンセルしました")
+	_, err = s.ChannelMessageSend(m.ChannelID, "アンケート作成をキャンセルしました")
 	return err
 }
 
-func (h *surveyHandler) handleCheckState(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, guildID string) error {
-	state, err := h.stateManager.GetState(ctx, guildID)
+func (h *surveyHandler) handleCheckState(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
+	_, state, err := h.getDraft(ctx, m)
 	if err != nil {
 		h.logger.Error(ctx, "Failed to get survey state", err)
 		return err
 	}
 
 	var message string
-	if state.Active && state.Title != "" {
+	if state.Active && len(state.Options) > 0 {
+		message = "プレビューを確認して公開してください"
+	} else if state.Active && state.Title != "" {
 		message = "アンケート内容を記入してください"
 	} else if state.Active && state.Title == "" {
 		message = "アンケートタイトルを入力してください"
@@ -114,8 +416,8 @@ func (h *surveyHandler) handleCheckState(ctx context.Context, s *discordgo.Sessi
 	return err
 }
 
-func (h *surveyHandler) handleCheckTitle(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, guildID string) error {
-	state, err := h.stateManager.GetState(ctx, guildID)
+func (h *surveyHandler) handleCheckTitle(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
+	_, state, err := h.getDraft(ctx, m)
 	if err != nil {
 		h.logger.Error(ctx, "Failed to get survey state", err)
 		return err
@@ -126,8 +428,48 @@ func (h *surveyHandler) handleCheckTitle(ctx context.Context, s *discordgo.Sessi
 	return err
 }
 
-func (h *surveyHandler) handleTitle(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, guildID string) error {
-	state, err := h.stateManager.GetState(ctx, guildID)
+func (h *surveyHandler) handleDrafts(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if m.GuildID == "" {
+		_, err := s.ChannelMessageSend(m.ChannelID, "作成中のアンケート一覧はサーバー内で確認してください")
+		return err
+	}
+
+	states, err := h.stateManager.ListActiveStates(ctx, m.GuildID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list survey states", err)
+		return err
+	}
+
+	if len(states) == 0 {
+		_, err := s.ChannelMessageSend(m.ChannelID, "作成中のアンケートはありません")
+		return err
+	}
+
+	description := ""
+	for _, state := range states {
+		author := "共有"
+		if state.AuthorID != "" {
+			author = "<@" + state.AuthorID + ">"
+		}
+		title := state.Title
+		if title == "" {
+			title = "(タイトル未設定)"
+		}
+		description += fmt.Sprintf("<#%s> %s : %s\n", state.ChannelID, author, title)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "作成中のアンケート",
+		Description: description,
+		Color:       0x141DB8,
+	}
+
+	_, err = s.ChannelMessageSendEmbed(m.ChannelID, embed)
+	return err
+}
+
+func (h *surveyHandler) handleTitle(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
+	key, state, err := h.getDraft(ctx, m)
 	if err != nil {
 		h.logger.Error(ctx, "Failed to get survey state", err)
 		return err
@@ -137,23 +479,30 @@ func (h *surveyHandler) handleTitle(ctx context.Context, s *discordgo.Session, m
 		return nil // Ignore if survey is not active
 	}
 
-	parts := h.regexPattern.Split(m.Content, -1)
-	if len(parts) <= 1 {
+	// The title is everything after the command, spaces and commas included
+	title := utils.CommandText(m.Content)
+	if title == "" {
 		_, err := s.ChannelMessageSend(m.ChannelID, "コマンドの後に改行を挟んでタイトルを記入してください")
 		return err
 	}
 
-	state.Title = parts[1]
-	if err := h.stateManager.SetState(ctx, guildID, state); err != nil {
+	state.Title = title
+	if err := h.stateManager.SetState(ctx, key, state); err != nil {
 		h.logger.Error(ctx, "Failed to update survey state", err)
 		return err
 	}
 
+	// A retitled draft that was already previewed is previewed again
+	if len(state.Options) > 0 {
+		return h.sendPreview(ctx, s, key, state, m.Author.ID)
+	}
 	return nil
 }
 
-func (h *surveyHandler) handleContent(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, guildID string) error {
-	state, err := h.stateManager.GetState(ctx, guildID)
+// handleContent stores the options in the draft and previews it; the survey is only
+// published once the preview is confirmed
+func (h *surveyHandler) handleContent(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
+	key, state, err := h.getDraft(ctx, m)
 	if err != nil {
 		h.logger.Error(ctx, "Failed to get survey state", err)
 		return err
@@ -163,58 +512,198 @@ func (h *surveyHandler) handleContent(ctx context.Context, s *discordgo.Session,
 		return nil // Ignore if survey is not active
 	}
 
-	parts := h.regexPattern.Split(m.Content, -1)
-	if len(parts) <= 1 {
+	targetChannelID, text := cutChannelMention(utils.CommandText(m.Content))
+	options, err := utils.ParseLines(text)
+	if err != nil {
+		_, err := s.ChannelMessageSend(m.ChannelID, unclosedQuoteMessage)
+		return err
+	}
+	if len(options) == 0 {
 		_, err := s.ChannelMessageSend(m.ChannelID, "コマンドの後に改行を挟んで回答項目を記入してください")
 		return err
 	}
 
-	return h.createSurveyEmbed(ctx, s, m, state.Title, parts[1:])
+	if !state.Deadline.IsZero() && !state.Deadline.After(time.Now()) {
+		_, err := s.ChannelMessageSend(m.ChannelID, draftExpiredMessage)
+		return err
+	}
+
+	// The channel is checked again on publishing, but a problem is better reported now
+	if targetChannelID != "" {
+		draft := *state
+		draft.TargetChannelID = targetChannelID
+		if err := h.checkTargetChannel(s, draftSurvey(&draft, m.Author.ID), m.Author.ID); err != nil {
+			if message, ok := surveyErrorMessage(err); ok {
+				_, err := s.ChannelMessageSend(m.ChannelID, message)
+				return err
+			}
+			return err
+		}
+	}
+
+	state.Options = options
+	state.TargetChannelID = targetChannelID
+	if err := h.stateManager.SetState(ctx, key, state); err != nil {
+		h.logger.Error(ctx, "Failed to update survey state", err)
+		return err
+	}
+
+	return h.sendPreview(ctx, s, key, state, m.Author.ID)
 }
 
-func (h *surveyHandler) createSurveyEmbed(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, title string, options []string) error {
-	description := ""
-	indices := []int{}
+// publishSurvey posts survey in reply to a text command, explaining problems with its
+// contents to the author
+func (h *surveyHandler) publishSurvey(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, survey *types.Survey) error {
+	if err := h.createSurveyEmbed(ctx, s, survey); err != nil {
+		if message, ok := surveyErrorMessage(err); ok {
+			_, sendErr := s.ChannelMessageSend(m.ChannelID, message)
+			return sendErr
+		}
+		return err
+	}
+	return nil
+}
 
-	maxEmojis := h.emojiProvider.GetMaxEmojis()
-	if len(options) > maxEmojis {
-		return fmt.Errorf("too many options: %d (max: %d)", len(options), maxEmojis)
+// optionMarker returns the emoji shown in front of an option, or its number if it has none
+func optionMarker(survey *types.Survey, index int) string {
+	if index < len(survey.Emojis) && survey.Emojis[index] != "" {
+		return survey.Emojis[index]
 	}
+	return fmt.Sprintf("%d.", index+1)
+}
 
-	for i, option := range options {
-		emoji, err := h.emojiProvider.GetEmoji(ctx, i+1) // Start from 1
+// createSurveyEmbed posts the survey described by survey, adds a reaction per option or
+// the voting components, and records it in the registry
+func (h *surveyHandler) createSurveyEmbed(ctx context.Context, s *discordgo.Session, survey *types.Survey) error {
+	if survey.VoteMode == "" {
+		survey.VoteMode = types.VoteModeReaction
+	}
+	if survey.Anonymous {
+		// Reactions show who voted, so anonymous surveys always vote through components
+		if survey.VoteMode == types.VoteModeReaction {
+			survey.VoteMode = types.VoteModeComponent
+		}
+		salt, err := utils.NewVoterSalt()
 		if err != nil {
-			h.logger.Error(ctx, "Failed to get emoji", err)
 			return err
 		}
-
-		indices = append(indices, i+1)
-		description += fmt.Sprintf("%s : %s\n", emoji, option)
+		survey.VoterSalt = salt
 	}
 
-	embed := &discordgo.MessageEmbed{
-		Title:       title,
-		Description: description,
-		Color:       0x141DB8,
+	emojiSet, err := h.resolveEmojiSet(ctx, s, survey)
+	if err != nil {
+		return err
 	}
+	emojis, err := emojiSet.optionEmojis(ctx, survey)
+	if err != nil {
+		return err
+	}
+	survey.Emojis = emojis
 
-	message, err := s.ChannelMessageSendEmbed(m.ChannelID, embed)
+	message, err := s.ChannelMessageSendComplex(survey.ChannelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{createSurveyMessageEmbed(survey)},
+		Components: surveyComponents(survey),
+	})
 	if err != nil {
 		return err
 	}
 
-	// Add reactions
-	for _, idx := range indices {
-		emoji, err := h.emojiProvider.GetEmoji(ctx, idx)
-		if err != nil {
-			h.logger.Error(ctx, "Failed to get emoji for reaction", err)
-			continue
-		}
+	survey.MessageID = message.ID
+	survey.CreatedAt = time.Now()
+	if err := h.registry.SaveSurvey(ctx, survey); err != nil {
+		h.logger.Error(ctx, "Failed to register survey", err, types.Field{Key: "message_id", Value: message.ID})
+	} else {
+		h.scheduleDeadline(s, survey)
+		h.scheduleReminder(s, survey)
+	}
+
+	if survey.VoteMode != types.VoteModeReaction {
+		return nil
+	}
 
-		if err := s.MessageReactionAdd(m.ChannelID, message.ID, emoji); err != nil {
+	// Add reactions
+	for _, emoji := range emojis {
+		if err := s.MessageReactionAdd(survey.ChannelID, message.ID, reactionAPIName(emoji)); err != nil {
 			h.logger.Error(ctx, "Failed to add reaction", err)
 		}
 	}
 
 	return nil
 }
+
+// createSurveyMessageEmbed builds the survey embed; component surveys also show live counts,
+// except anonymous ones whose counts only appear once they are closed
+func createSurveyMessageEmbed(survey *types.Survey) *discordgo.MessageEmbed {
+	description := ""
+	if survey.VoteMode == types.VoteModeComponent && (!survey.Anonymous || survey.Closed) {
+		counts := utils.CountVotes(len(survey.Options), survey.Votes)
+		for i, option := range survey.Options {
+			description += fmt.Sprintf("%s : %s (%d票)\n", optionMarker(survey, i), option, counts[i])
+		}
+	} else {
+		for i, option := range survey.Options {
+			description += fmt.Sprintf("%s : %s\n", optionMarker(survey, i), option)
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       survey.Title,
+		Description: description,
+		Color:       0x141DB8,
+	}
+	if footer := surveyFooterText(survey); footer != "" {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: footer}
+	}
+	// Footers cannot show mentions, so the eligible role gets a field of its own
+	if survey.EligibleRoleID != "" {
+		embed.Fields = []*discordgo.MessageEmbedField{
+			{Name: "投票できるメンバー", Value: "<@&" + survey.EligibleRoleID + ">"},
+		}
+	}
+	// Discord renders the timestamp after the footer in each reader's own time zone
+	if !survey.Closed && !survey.Deadline.IsZero() {
+		embed.Timestamp = survey.Deadline.Format(time.RFC3339)
+	}
+	return embed
+}
+
+// surveyFooterText describes how the survey accepts votes
+func surveyFooterText(survey *types.Survey) string {
+	if survey.Closed {
+		return "締め切り済み"
+	}
+
+	var parts []string
+	if survey.Anonymous {
+		parts = append(parts, "匿名アンケート")
+	}
+	if survey.VoteMode == types.VoteModeRanked {
+		parts = append(parts, "順位付け投票")
+		if rankLength(survey) < len(survey.Options) {
+			parts = append(parts, fmt.Sprintf("第%d希望まで選択できます", rankLength(survey)))
+		}
+	} else if limit := choiceLimitText(survey.MaxChoices); limit != "" {
+		parts = append(parts, limit)
+	}
+	if quorum := quorumText(survey); quorum != "" {
+		parts = append(parts, quorum)
+	}
+	if survey.AutoClose {
+		parts = append(parts, "結果が確定すると自動で締め切り")
+	}
+	if !survey.Deadline.IsZero() {
+		parts = append(parts, "締め切り")
+	}
+	return strings.Join(parts, " / ")
+}
+
+// choiceLimitText describes how many options a member may choose; it is empty without a limit
+func choiceLimitText(maxChoices int) string {
+	switch {
+	case maxChoices == 1:
+		return "1つだけ選択できます"
+	case maxChoices > 1:
+		return fmt.Sprintf("最大%d個まで選択できます", maxChoices)
+	}
+	return ""
+}
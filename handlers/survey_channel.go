@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/bwmarrin/discordgo"
+)
+
+// errForeignChannel is returned when a survey is sent to a channel outside its guild, or
+// to one that cannot be found
+var errForeignChannel = errors.New("channel is not in the survey's guild")
+
+// channelPermission is a permission the bot needs to post a survey, with its name as
+// Discord shows it to Japanese users
+type channelPermission struct {
+	bit  int64
+	name string
+}
+
+// surveyPostPermissions are needed to post any survey; reaction surveys also need
+// reactionPermission for the bot's own option reactions
+var (
+	surveyPostPermissions = []channelPermission{
+		{discordgo.PermissionViewChannel, "チャンネルを見る"},
+		{discordgo.PermissionSendMessages, "メッセージを送信"},
+		{discordgo.PermissionEmbedLinks, "埋め込みリンク"},
+	}
+	reactionPermission = channelPermission{discordgo.PermissionAddReactions, "リアクションの追加"}
+)
+
+// channelPermissionError reports the permissions missing to post a survey to a channel
+// other than the one it was written in, by the bot or by its author
+type channelPermissionError struct {
+	channelID string
+	author    bool // the author may not send messages there, whatever the bot may do
+	missing   []string
+}
+
+func (e *channelPermissionError) Error() string {
+	if e.author {
+		return fmt.Sprintf("author cannot send messages to channel %s", e.channelID)
+	}
+	return fmt.Sprintf("bot lacks permissions in channel %s: %s", e.channelID, strings.Join(e.missing, ", "))
+}
+
+// channelPermissionMessage tells the author why the survey cannot be posted to the channel
+func channelPermissionMessage(e *channelPermissionError) string {
+	if e.author {
+		return fmt.Sprintf("<#%s> にメッセージを送信できないため、そのチャンネルにはアンケートを投稿できません", e.channelID)
+	}
+	return fmt.Sprintf("<#%s> にアンケートを投稿するには Bot に次の権限が必要です: %s", e.channelID, strings.Join(e.missing, "、"))
+}
+
+// parseChannelID reads a channel mention such as <#123456789>
+func parseChannelID(value string) (string, bool) {
+	id, ok := strings.CutPrefix(value, "<#")
+	if !ok {
+		return "", false
+	}
+	id, ok = strings.CutSuffix(id, ">")
+	if !ok || id == "" {
+		return "", false
+	}
+	for _, r := range id {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+	}
+	return id, true
+}
+
+// cutChannelMention splits a leading channel mention off the text after a command,
+// e.g. "<#123> \nGo\nRust" into "123" and "Go\nRust"
+func cutChannelMention(text string) (string, string) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "", text
+	}
+	channelID, ok := parseChannelID(fields[0])
+	if !ok {
+		return "", text
+	}
+	return channelID, strings.TrimSpace(strings.TrimPrefix(text, fields[0]))
+}
+
+// missingPermissions names the permissions a survey with mode needs that permissions lacks
+func missingPermissions(permissions int64, mode types.VoteMode) []string {
+	required := surveyPostPermissions
+	if mode == types.VoteModeReaction {
+		required = append(required[:len(required):len(required)], reactionPermission)
+	}
+
+	var missing []string
+	for _, permission := range required {
+		if permissions&permission.bit == 0 {
+			missing = append(missing, permission.name)
+		}
+	}
+	return missing
+}
+
+// checkTargetChannel makes sure survey can be posted to its channel in its guild, by the
+// bot and on behalf of authorID. Otherwise a member could post to a channel closed to them.
+func (h *surveyHandler) checkTargetChannel(s *discordgo.Session, survey *types.Survey, authorID string) error {
+	channel, err := s.State.Channel(survey.ChannelID)
+	if err != nil {
+		channel, err = s.Channel(survey.ChannelID)
+	}
+	if err != nil || survey.GuildID == "" || channel.GuildID != survey.GuildID {
+		return errForeignChannel
+	}
+
+	authorPermissions, err := s.UserChannelPermissions(authorID, survey.ChannelID)
+	if err != nil {
+		return err
+	}
+	if authorPermissions&discordgo.PermissionViewChannel == 0 || authorPermissions&discordgo.PermissionSendMessages == 0 {
+		return &channelPermissionError{channelID: survey.ChannelID, author: true}
+	}
+
+	botPermissions, err := s.UserChannelPermissions(s.State.User.ID, survey.ChannelID)
+	if err != nil {
+		return err
+	}
+	// As createSurveyEmbed decides, anonymous surveys vote through components instead of reactions
+	mode := survey.VoteMode
+	if mode == "" {
+		mode = types.VoteModeReaction
+	}
+	if survey.Anonymous && mode == types.VoteModeReaction {
+		mode = types.VoteModeComponent
+	}
+	if missing := missingPermissions(botPermissions, mode); len(missing) > 0 {
+		return &channelPermissionError{channelID: survey.ChannelID, missing: missing}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestCutChannelMention(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		channelID string
+		rest      string
+	}{
+		{"正常系: 改行の前のチャンネル", "<#123456789>\nGo\nRust", "123456789", "Go\nRust"},
+		{"正常系: 同じ行のチャンネルと回答項目", "<#123> Go\nRust", "123", "Go\nRust"},
+		{"正常系: チャンネルなし", "Go\nRust", "", "Go\nRust"},
+		{"正常系: 途中のチャンネルは回答項目のまま", "Go\n<#123>", "", "Go\n<#123>"},
+		{"異常系: チャンネルでないメンション", "<@123>\nGo", "", "<@123>\nGo"},
+		{"異常系: 数字でないID", "<#abc>\nGo", "", "<#abc>\nGo"},
+		{"異常系: 空", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			channelID, rest := cutChannelMention(tt.text)
+
+			// Assert
+			if channelID != tt.channelID || rest != tt.rest {
+				t.Errorf("cutChannelMention() = %q, %q, want %q, %q", channelID, rest, tt.channelID, tt.rest)
+			}
+		})
+	}
+}
+
+func TestMissingPermissions(t *testing.T) {
+	const posting = discordgo.PermissionViewChannel | discordgo.PermissionSendMessages | discordgo.PermissionEmbedLinks
+
+	tests := []struct {
+		name        string
+		permissions int64
+		mode        types.VoteMode
+		expected    []string
+	}{
+		{"正常系: リアクション投票に必要な権限がそろっている", posting | discordgo.PermissionAddReactions, types.VoteModeReaction, nil},
+		{"正常系: ボタン投票にリアクションの権限はいらない", posting, types.VoteModeComponent, nil},
+		{"異常系: リアクション投票でリアクションの権限がない", posting, types.VoteModeReaction, []string{"リアクションの追加"}},
+		{"異常系: メッセージを送信できない", discordgo.PermissionViewChannel | discordgo.PermissionAddReactions, types.VoteModeReaction, []string{"メッセージを送信", "埋め込みリンク"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			missing := missingPermissions(tt.permissions, tt.mode)
+
+			// Assert
+			if !reflect.DeepEqual(missing, tt.expected) {
+				t.Errorf("missingPermissions() = %q, want %q", missing, tt.expected)
+			}
+		})
+	}
+
+	t.Run("正常系: 必要な権限の一覧を書き換えない", func(t *testing.T) {
+		// Act
+		missingPermissions(0, types.VoteModeReaction)
+
+		// Assert
+		if len(surveyPostPermissions) != 3 {
+			t.Errorf("surveyPostPermissionsが変更されています: %v", surveyPostPermissions)
+		}
+	})
+}
+
+func TestSurveyErrorMessageChannel(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			"異常系: Botの権限が足りない",
+			&channelPermissionError{channelID: "123", missing: []string{"メッセージを送信", "リアクションの追加"}},
+			"<#123> にアンケートを投稿するには Bot に次の権限が必要です: メッセージを送信、リアクションの追加",
+		},
+		{
+			"異常系: 作成者がメッセージを送信できない",
+			&channelPermissionError{channelID: "123", author: true},
+			"<#123> にメッセージを送信できないため、そのチャンネルにはアンケートを投稿できません",
+		},
+		{
+			"異常系: ほかのサーバーのチャンネル",
+			errForeignChannel,
+			"投稿先のチャンネルが見つからないか、このサーバーのチャンネルではありません",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			message, ok := surveyErrorMessage(tt.err)
+
+			// Assert
+			if !ok {
+				t.Fatalf("作成者に伝えるエラーが期待されていましたが、falseが返されました")
+			}
+			if message != tt.expected {
+				t.Errorf("surveyErrorMessage() = %q, want %q", message, tt.expected)
+			}
+		})
+	}
+}
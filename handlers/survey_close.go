@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/Logta/SurveyBot/utils"
+	"github.com/bwmarrin/discordgo"
+)
+
+func (h *surveyHandler) handleClose(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
+	survey, err := h.findSurvey(ctx, m)
+	if errors.Is(err, types.ErrSurveyNotFound) {
+		return h.closeUnregisteredSurvey(ctx, s, m)
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to find survey", err)
+		return err
+	}
+
+	refusal, err := h.closeRefusal(s, m, survey)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get member permissions", err)
+		return err
+	}
+	if refusal != "" {
+		_, err := s.ChannelMessageSend(m.ChannelID, refusal)
+		return err
+	}
+
+	// The survey is claimed before it is tallied, so a deadline or a deciding vote at the
+	// same moment does not post a second result
+	survey, err = h.claimClose(ctx, survey.MessageID)
+	if errors.Is(err, types.ErrSurveyClosed) {
+		_, err := s.ChannelMessageSend(m.ChannelID, alreadyClosedMessage)
+		return err
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to mark survey as closed", err, types.Field{Key: "message_id", Value: survey.MessageID})
+		return err
+	}
+
+	message, err := h.surveyResultMessage(ctx, s, survey)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to fetch survey message", err, types.Field{Key: "message_id", Value: survey.MessageID})
+		h.reopen(ctx, survey.MessageID)
+		_, err := s.ChannelMessageSend(m.ChannelID, "アンケートが見つかりませんでした")
+		return err
+	}
+
+	h.finishClose(ctx, s, survey)
+
+	_, err = s.ChannelMessageSendComplex(m.ChannelID, message)
+	return err
+}
+
+// alreadyClosedMessage answers !close on a survey whose result was already posted
+const alreadyClosedMessage = "このアンケートはすでに締め切られています"
+
+// closeRefusal explains why the author of m may not close survey, or returns "" when they
+// may. Only surveys of the guild the command was sent in can be closed, by their creator
+// or by members with the Manage Messages permission, as with !edit.
+func (h *surveyHandler) closeRefusal(s *discordgo.Session, m *discordgo.MessageCreate, survey *types.Survey) (string, error) {
+	if survey.GuildID != m.GuildID {
+		return "アンケートが見つかりませんでした", nil
+	}
+
+	allowed, err := h.canManageSurvey(s, m, survey)
+	if err != nil {
+		return "", err
+	}
+	if !allowed {
+		return "アンケートを締め切れるのは作成者か「メッセージの管理」権限を持つメンバーだけです", nil
+	}
+	return "", nil
+}
+
+// surveyResultMessage tallies a registered survey and builds the message announcing its result
+func (h *surveyHandler) surveyResultMessage(ctx context.Context, s *discordgo.Session, survey *types.Survey) (*discordgo.MessageSend, error) {
+	if survey.VoteMode == types.VoteModeRanked {
+		result := h.tallyRanked(ctx, s, survey)
+		return h.resultMessage(ctx, createRankedResultEmbed(result), result.Options, result.Winners), nil
+	}
+
+	result, err := h.tallySurvey(ctx, s, survey)
+	if err != nil {
+		return nil, err
+	}
+	return h.resultMessage(ctx, h.createResultEmbed(result), result.Options, result.Winners), nil
+}
+
+// resultChartName is the file name of the chart attached to a result embed
+const resultChartName = "chart.png"
+
+// resultMessage shows a chart image of options in the result embed. The result is still
+// sent without it if the chart cannot be drawn.
+func (h *surveyHandler) resultMessage(ctx context.Context, embed *discordgo.MessageEmbed, options []types.OptionResult, winners []int) *discordgo.MessageSend {
+	message := &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}}
+
+	chart, err := utils.RenderBarChartPNG(options, winners, utils.SurveyChartTheme)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to render result chart", err)
+		return message
+	}
+
+	embed.Image = &discordgo.MessageEmbedImage{URL: "attachment://" + resultChartName}
+	message.Files = []*discordgo.File{
+		{Name: resultChartName, ContentType: "image/png", Reader: bytes.NewReader(chart)},
+	}
+	return message
+}
+
+// surveyOptionResults returns the emoji and label of each option, without counts
+func surveyOptionResults(survey *types.Survey) []types.OptionResult {
+	options := make([]types.OptionResult, len(survey.Options))
+	for i, option := range survey.Options {
+		options[i] = types.OptionResult{Emoji: optionMarker(survey, i), Label: option}
+	}
+	return options
+}
+
+// tallySurvey counts the votes of a registered survey: component votes are recorded by the
+// bot, reaction votes are read back from the survey message
+func (h *surveyHandler) tallySurvey(ctx context.Context, s *discordgo.Session, survey *types.Survey) (*types.SurveyResult, error) {
+	if survey.EligibleRoleID != "" || hasQuorum(survey) {
+		return h.tallyVoters(ctx, s, survey)
+	}
+
+	options := surveyOptionResults(survey)
+
+	if survey.VoteMode == types.VoteModeComponent {
+		for i, count := range utils.CountVotes(len(survey.Options), survey.Votes) {
+			options[i].Count = count
+		}
+	} else {
+		message, err := s.ChannelMessage(survey.ChannelID, survey.MessageID)
+		if err != nil {
+			return nil, err
+		}
+		countReactions(options, message.Reactions)
+	}
+
+	result := utils.TallyResults(survey.Title, options)
+
+	h.logger.Debug(ctx, "Survey tallied",
+		types.Field{Key: "message_id", Value: survey.MessageID},
+		types.Field{Key: "total_votes", Value: result.TotalVotes},
+	)
+
+	return result, nil
+}
+
+// claimClose records the survey posted as messageID as closed and returns it. Only the
+// caller that claims a survey posts its result; the others get types.ErrSurveyClosed.
+func (h *surveyHandler) claimClose(ctx context.Context, messageID string) (*types.Survey, error) {
+	return h.registry.UpdateSurvey(ctx, messageID, closeSurvey(time.Now()))
+}
+
+// reopen gives up a claim on closing a survey whose result could not be tallied
+func (h *surveyHandler) reopen(ctx context.Context, messageID string) {
+	_, err := h.registry.UpdateSurvey(ctx, messageID, func(survey *types.Survey) error {
+		survey.Closed = false
+		survey.ClosedAt = time.Time{}
+		return nil
+	})
+	if err != nil {
+		h.logger.Error(ctx, "Failed to reopen survey", err, types.Field{Key: "message_id", Value: messageID})
+	}
+}
+
+// finishClose cancels the deadline and reminder of a claimed survey and updates the survey
+// message to show that it no longer accepts votes
+func (h *surveyHandler) finishClose(ctx context.Context, s *discordgo.Session, survey *types.Survey) {
+	h.scheduler.Cancel(survey.MessageID)
+	h.scheduler.Cancel(reminderJobID(survey.MessageID))
+
+	if err := h.updateSurveyMessage(s, survey); err != nil {
+		h.logger.Error(ctx, "Failed to update closed survey message", err, types.Field{Key: "message_id", Value: survey.MessageID})
+	}
+}
+
+// closeSurvey returns the registry update that closes a survey at now, which fails with
+// types.ErrSurveyClosed when the survey is already closed
+func closeSurvey(now time.Time) func(*types.Survey) error {
+	return func(survey *types.Survey) error {
+		if survey.Closed {
+			return types.ErrSurveyClosed
+		}
+		survey.Closed = true
+		survey.ClosedAt = now
+		return nil
+	}
+}
+
+// findSurvey resolves the survey targeted by a command from its argument, the replied-to
+// message, or the latest survey in the channel
+func (h *surveyHandler) findSurvey(ctx context.Context, m *discordgo.MessageCreate) (*types.Survey, error) {
+	messageID := h.surveyMessageID(m)
+	if messageID == "" {
+		return h.registry.GetLatestSurvey(ctx, m.ChannelID)
+	}
+	return h.registry.GetSurvey(ctx, messageID)
+}
+
+func (h *surveyHandler) surveyMessageID(m *discordgo.MessageCreate) string {
+	if fields := strings.Fields(m.Content); len(fields) > 1 {
+		return fields[1]
+	}
+	if m.MessageReference != nil {
+		return m.MessageReference.MessageID
+	}
+	return ""
+}
+
+// closeUnregisteredSurvey tallies a survey posted before the registry existed by reading
+// its options back from the embed
+func (h *surveyHandler) closeUnregisteredSurvey(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
+	messageID := h.surveyMessageID(m)
+	if messageID == "" {
+		_, err := s.ChannelMessageSend(m.ChannelID, "このチャンネルにはアンケートがありません")
+		return err
+	}
+
+	message, err := s.ChannelMessage(m.ChannelID, messageID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to fetch survey message", err, types.Field{Key: "message_id", Value: messageID})
+		_, err := s.ChannelMessageSend(m.ChannelID, "アンケートが見つかりませんでした")
+		return err
+	}
+
+	if message.Author == nil || message.Author.ID != s.State.User.ID || len(message.Embeds) == 0 {
+		_, err := s.ChannelMessageSend(m.ChannelID, "指定されたメッセージはアンケートではありません")
+		return err
+	}
+
+	embed := message.Embeds[0]
+	options := parseSurveyDescription(embed.Description)
+	if len(options) == 0 {
+		_, err := s.ChannelMessageSend(m.ChannelID, "指定されたメッセージはアンケートではありません")
+		return err
+	}
+
+	countReactions(options, message.Reactions)
+	result := utils.TallyResults(embed.Title, options)
+
+	_, err = s.ChannelMessageSendComplex(m.ChannelID, h.resultMessage(ctx, h.createResultEmbed(result), result.Options, result.Winners))
+	return err
+}
+
+func (h *surveyHandler) createResultEmbed(result *types.SurveyResult) *discordgo.MessageEmbed {
+	winners := "投票がありませんでした"
+	if len(result.Winners) > 0 {
+		labels := make([]string, 0, len(result.Winners))
+		for _, idx := range result.Winners {
+			option := result.Options[idx]
+			labels = append(labels, fmt.Sprintf("%s : %s", option.Emoji, option.Label))
+		}
+		winners = strings.Join(labels, "\n")
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       result.Title + " の集計結果",
+		Description: utils.RenderBarChart(result.Options, utils.EmbedDescriptionLimit),
+		Color:       0x141DB8,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "最多得票", Value: winners, Inline: true},
+			{Name: "総投票数", Value: fmt.Sprintf("%d票", result.TotalVotes), Inline: true},
+		},
+	}
+	if field := turnoutField(result.Turnout); field != nil {
+		embed.Fields = append(embed.Fields, field)
+	}
+	if field := quorumField(result.Quorum); field != nil {
+		embed.Fields = append(embed.Fields, field)
+	}
+	return embed
+}
+
+// parseSurveyDescription recovers the emoji and label of each option from a survey embed
+func parseSurveyDescription(description string) []types.OptionResult {
+	var options []types.OptionResult
+	for _, line := range strings.Split(description, "\n") {
+		pair := strings.SplitN(line, " : ", 2)
+		if len(pair) != 2 {
+			continue
+		}
+		options = append(options, types.OptionResult{
+			Emoji: strings.TrimSpace(pair[0]),
+			Label: strings.TrimSpace(pair[1]),
+		})
+	}
+	return options
+}
+
+// countReactions sets each option's count, excluding the reaction seeded by the bot
+func countReactions(options []types.OptionResult, reactions []*discordgo.MessageReactions) {
+	for _, reaction := range reactions {
+		if reaction.Emoji == nil {
+			continue
+		}
+		for i := range options {
+			if !matchesEmoji(options[i].Emoji, reaction.Emoji) {
+				continue
+			}
+			options[i].Count = reaction.Count
+			if reaction.Me {
+				options[i].Count--
+			}
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestParseSurveyDescription(t *testing.T) {
+	t.Run("正常系: アンケートの説明文から選択肢を復元", func(t *testing.T) {
+		// Arrange
+		description := "1️⃣ : Go\n2️⃣ : TypeScript\n"
+
+		// Act
+		options := parseSurveyDescription(description)
+
+		// Assert
+		if len(options) != 2 {
+			t.Fatalf("選択肢の数が期待値と異なります: got %v, want %v", len(options), 2)
+		}
+		if options[0].Emoji != "1️⃣" || options[0].Label != "Go" {
+			t.Errorf("選択肢が期待値と異なります: got %+v", options[0])
+		}
+		if options[1].Emoji != "2️⃣" || options[1].Label != "TypeScript" {
+			t.Errorf("選択肢が期待値と異なります: got %+v", options[1])
+		}
+	})
+
+	t.Run("異常系: アンケート形式でない説明文", func(t *testing.T) {
+		// Act
+		options := parseSurveyDescription("ただのメッセージ")
+
+		// Assert
+		if len(options) != 0 {
+			t.Errorf("選択肢が空ではありません: %v", options)
+		}
+	})
+}
+
+func TestCountReactions(t *testing.T) {
+	t.Run("正常系: Bot自身のリアクションを除いて集計", func(t *testing.T) {
+		// Arrange
+		options := []types.OptionResult{
+			{Emoji: "1️⃣", Label: "Go"},
+			{Emoji: "2️⃣", Label: "Rust"},
+		}
+		reactions := []*discordgo.MessageReactions{
+			{Count: 4, Me: true, Emoji: &discordgo.Emoji{Name: "1️⃣"}},
+			{Count: 1, Me: true, Emoji: &discordgo.Emoji{Name: "2️⃣"}},
+			{Count: 2, Me: false, Emoji: &discordgo.Emoji{Name: "👍"}},
+		}
+
+		// Act
+		countReactions(options, reactions)
+
+		// Assert
+		if options[0].Count != 3 {
+			t.Errorf("得票数が期待値と異なります: got %v, want %v", options[0].Count, 3)
+		}
+		if options[1].Count != 0 {
+			t.Errorf("得票数が期待値と異なります: got %v, want %v", options[1].Count, 0)
+		}
+	})
+}
+
+func TestSurveyHandler_ResultMessage(t *testing.T) {
+	t.Run("正常系: 集計結果にグラフ画像を添付する", func(t *testing.T) {
+		// Arrange
+		handler := &surveyHandler{logger: &mockLogger{}}
+		embed := &discordgo.MessageEmbed{Title: "集計結果"}
+		options := []types.OptionResult{{Label: "A", Count: 2, Percentage: 100}, {Label: "B"}}
+
+		// Act
+		message := handler.resultMessage(context.Background(), embed, options, []int{0})
+
+		// Assert
+		if len(message.Files) != 1 || message.Files[0].Name != resultChartName {
+			t.Fatalf("添付ファイルが期待値と異なります: got %+v", message.Files)
+		}
+		if embed.Image == nil || embed.Image.URL != "attachment://"+resultChartName {
+			t.Errorf("埋め込みの画像が添付ファイルを指していません: got %+v", embed.Image)
+		}
+		if len(message.Embeds) != 1 || message.Embeds[0] != embed {
+			t.Errorf("埋め込みが期待値と異なります: got %+v", message.Embeds)
+		}
+	})
+}
+
+// newPermissionSession returns a session whose state knows a guild with one channel and
+// the given members, each with a role granting their permissions in it
+func newPermissionSession(guildID, channelID string, permissions map[string]int64) *discordgo.Session {
+	state := discordgo.NewState()
+	guild := &discordgo.Guild{
+		ID:       guildID,
+		OwnerID:  "owner",
+		Roles:    []*discordgo.Role{{ID: guildID}},
+		Channels: []*discordgo.Channel{{ID: channelID, GuildID: guildID}},
+	}
+	for userID, permission := range permissions {
+		roleID := "role-" + userID
+		guild.Roles = append(guild.Roles, &discordgo.Role{ID: roleID, Permissions: permission})
+		guild.Members = append(guild.Members, &discordgo.Member{GuildID: guildID, User: &discordgo.User{ID: userID}, Roles: []string{roleID}})
+	}
+	state.GuildAdd(guild)
+	return &discordgo.Session{State: state}
+}
+
+func TestSurveyHandler_CloseRefusal(t *testing.T) {
+	survey := &types.Survey{MessageID: "message-1", GuildID: "guild-1", ChannelID: "channel-1", AuthorID: "author"}
+	s := newPermissionSession("guild-1", "channel-1", map[string]int64{
+		"author":    discordgo.PermissionSendMessages,
+		"moderator": discordgo.PermissionSendMessages | discordgo.PermissionManageMessages,
+		"member":    discordgo.PermissionSendMessages,
+	})
+
+	tests := []struct {
+		name    string
+		userID  string
+		guildID string
+		refused bool
+	}{
+		{"正常系: 作成者は締め切れる", "author", "guild-1", false},
+		{"正常系: メッセージの管理権限を持つメンバーは締め切れる", "moderator", "guild-1", false},
+		{"異常系: 権限のないメンバーは締め切れない", "member", "guild-1", true},
+		{"異常系: ほかのサーバーからは締め切れない", "author", "guild-2", true},
+		{"異常系: DMからは締め切れない", "author", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler := &surveyHandler{logger: &mockLogger{}}
+			m := &discordgo.MessageCreate{Message: &discordgo.Message{
+				GuildID: tt.guildID,
+				Author:  &discordgo.User{ID: tt.userID},
+			}}
+
+			// Act
+			refusal, err := handler.closeRefusal(s, m, survey)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("期待していないエラーが発生: %v", err)
+			}
+			if (refusal != "") != tt.refused {
+				t.Errorf("締め切りの可否が期待値と異なります: got %q, refused %v", refusal, tt.refused)
+			}
+		})
+	}
+}
+
+func TestSurveyHandler_HandleClose_AlreadyClosed(t *testing.T) {
+	t.Run("異常系: 締め切り済みのアンケートは集計し直さない", func(t *testing.T) {
+		// Arrange
+		closedAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		registry := &mockSurveyRegistry{surveys: map[string]*types.Survey{
+			"message-1": {
+				MessageID: "message-1", GuildID: "guild-1", ChannelID: "channel-1", AuthorID: "author",
+				Title: "ランチ", Options: []string{"和食", "洋食"}, Closed: true, ClosedAt: closedAt,
+				SurveySettings: types.SurveySettings{VoteMode: types.VoteModeComponent},
+			},
+		}}
+		handler := NewSurveyHandler(&mockStateManager{}, registry, &mockGuildSettingsStore{}, &mockTemplateStore{}, &mockScheduleStore{}, &mockScheduler{}, &mockEmojiProvider{}, &mockLogger{}).(*surveyHandler)
+
+		s := newRESTSession(http.StatusOK, `{"id": "reply-1", "channel_id": "channel-1"}`)
+		s.State = newPermissionSession("guild-1", "channel-1", map[string]int64{"author": discordgo.PermissionSendMessages}).State
+		transport := s.Client.Transport
+		var sent []string
+		s.Client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			if r.Body != nil {
+				body, _ := io.ReadAll(r.Body)
+				sent = append(sent, string(body))
+			}
+			return transport.RoundTrip(r)
+		})
+		m := &discordgo.MessageCreate{Message: &discordgo.Message{
+			GuildID:   "guild-1",
+			ChannelID: "channel-1",
+			Author:    &discordgo.User{ID: "author"},
+			Content:   string(types.CmdClose) + " message-1",
+		}}
+
+		// Act
+		err := handler.handleClose(context.Background(), s, m)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if len(sent) != 1 || !strings.Contains(sent[0], alreadyClosedMessage) {
+			t.Errorf("締め切り済みの返信だけが期待されていましたが、%qが送られました", sent)
+		}
+		if got := registry.surveys["message-1"].ClosedAt; !got.Equal(closedAt) {
+			t.Errorf("ClosedAtが上書きされました: got %v, want %v", got, closedAt)
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/bwmarrin/discordgo"
+)
+
+// deadlineLabel introduces a deadline in !survey, e.g. "期限: 2h" or "期限:2030-01-02T15:04"
+const deadlineLabel = "期限"
+
+// deadlineLayouts are the absolute times accepted as deadlines, besides durations
+var deadlineLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+}
+
+// parseDeadline resolves value into the time a survey closes. Durations such as "90m",
+// "2h" or "3d" count from now; times without an offset are read in the local time zone.
+func parseDeadline(value string, now time.Time) (time.Time, error) {
+	deadline, err := parseDeadlineValue(value, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !deadline.After(now) {
+		return time.Time{}, fmt.Errorf("%w: %q is not in the future", errInvalidDeadline, value)
+	}
+	return deadline, nil
+}
+
+func parseDeadlineValue(value string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return now.AddDate(0, 0, n), nil
+		}
+	}
+
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(duration), nil
+	}
+
+	for _, layout := range deadlineLayouts {
+		if deadline, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return deadline, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %q", errInvalidDeadline, value)
+}
+
+// OnStart resumes the deadlines of open surveys, closing any that passed while the bot
+// was offline, and the guilds' recurring surveys
+func (h *surveyHandler) OnStart(ctx context.Context, s *discordgo.Session) error {
+	surveys, err := h.registry.ListOpenSurveys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list open surveys: %w", err)
+	}
+
+	scheduled := 0
+	for _, survey := range surveys {
+		if survey.Deadline.IsZero() {
+			continue
+		}
+		h.scheduleDeadline(s, survey)
+		h.scheduleReminder(s, survey)
+		scheduled++
+	}
+
+	h.logger.Info(ctx, "Survey deadlines resumed", types.Field{Key: "count", Value: scheduled})
+
+	return h.resumeSchedules(ctx, s)
+}
+
+// scheduleDeadline arranges for the survey to close itself at its deadline
+func (h *surveyHandler) scheduleDeadline(s *discordgo.Session, survey *types.Survey) {
+	if survey.Deadline.IsZero() || survey.Closed {
+		return
+	}
+
+	messageID := survey.MessageID
+	h.scheduler.Schedule(messageID, survey.Deadline, func() {
+		h.closeAutomatically(context.Background(), s, messageID, "", 0)
+	})
+}
+
+// closeRetryDelay is how long to wait before the attempt-th retry of closing a survey,
+// doubling from a minute up to an hour
+func closeRetryDelay(attempt int) time.Duration {
+	return min(time.Minute<<min(attempt, 6), time.Hour)
+}
+
+// surveyGone reports whether err says that the survey's message or channel was deleted
+func surveyGone(err error) bool {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) || restErr.Message == nil {
+		return false
+	}
+	return restErr.Message.Code == discordgo.ErrCodeUnknownMessage || restErr.Message.Code == discordgo.ErrCodeUnknownChannel
+}
+
+// closeAutomatically closes the survey without a command, at its deadline or once its
+// result is settled, and posts the result with notice. attempt counts the earlier tries
+// that failed to tally it. The survey is claimed before it is tallied, so when deciding
+// votes, the deadline and !close race only one of them posts the result.
+func (h *surveyHandler) closeAutomatically(ctx context.Context, s *discordgo.Session, messageID, notice string, attempt int) {
+	survey, err := h.claimClose(ctx, messageID)
+	if err != nil {
+		// Closed by hand or by another vote in the meantime, or deleted
+		if !errors.Is(err, types.ErrSurveyClosed) && !errors.Is(err, types.ErrSurveyNotFound) {
+			h.logger.Error(ctx, "Failed to mark survey as closed", err, types.Field{Key: "message_id", Value: messageID})
+		}
+		return
+	}
+
+	message, err := h.surveyResultMessage(ctx, s, survey)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to tally survey", err,
+			types.Field{Key: "message_id", Value: messageID},
+			types.Field{Key: "attempt", Value: attempt},
+		)
+		// The survey was deleted, so it stays closed and is not retried on every start
+		if surveyGone(err) {
+			return
+		}
+		// Discord may be slow or rate limiting, so the survey is reopened and tallied again later
+		h.reopen(ctx, messageID)
+		h.scheduler.Schedule(messageID, time.Now().Add(closeRetryDelay(attempt)), func() {
+			h.closeAutomatically(context.Background(), s, messageID, notice, attempt+1)
+		})
+		return
+	}
+	h.finishClose(ctx, s, survey)
+
+	message.Content = notice
+	if _, err := s.ChannelMessageSendComplex(survey.ChannelID, message); err != nil {
+		h.logger.Error(ctx, "Failed to send survey result", err, types.Field{Key: "message_id", Value: messageID})
+		return
+	}
+
+	h.logger.Info(ctx, "Survey closed automatically",
+		types.Field{Key: "message_id", Value: messageID},
+		types.Field{Key: "early", Value: notice != ""},
+	)
+}
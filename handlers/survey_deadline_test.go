@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestSurveyHandler_OnStart(t *testing.T) {
+	t.Run("正常系: 締め切られていない期限付きアンケートを再登録する", func(t *testing.T) {
+		// Arrange
+		deadline := time.Now().Add(time.Hour)
+		registry := &mockSurveyRegistry{surveys: map[string]*types.Survey{
+			"with-deadline": {MessageID: "with-deadline", SurveySettings: types.SurveySettings{Deadline: deadline}},
+			"no-deadline":   {MessageID: "no-deadline"},
+			"closed":        {MessageID: "closed", Closed: true, SurveySettings: types.SurveySettings{Deadline: deadline}},
+		}}
+		scheduler := &mockScheduler{}
+		handler := NewSurveyHandler(&mockStateManager{}, registry, &mockGuildSettingsStore{}, &mockTemplateStore{}, &mockScheduleStore{}, scheduler, &mockEmojiProvider{}, &mockLogger{}).(types.StartupHandler)
+
+		// Act
+		err := handler.OnStart(context.Background(), nil)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if len(scheduler.jobs) != 1 {
+			t.Fatalf("登録されたジョブ数が期待値と異なります: got %v, want %v", len(scheduler.jobs), 1)
+		}
+		if at, exists := scheduler.jobs["with-deadline"]; !exists || !at.Equal(deadline) {
+			t.Errorf("ジョブの実行時刻が期待値と異なります: got %v, want %v", at, deadline)
+		}
+	})
+
+	t.Run("異常系: アンケートの取得に失敗", func(t *testing.T) {
+		// Arrange
+		registry := &mockSurveyRegistry{err: context.DeadlineExceeded}
+		handler := NewSurveyHandler(&mockStateManager{}, registry, &mockGuildSettingsStore{}, &mockTemplateStore{}, &mockScheduleStore{}, &mockScheduler{}, &mockEmojiProvider{}, &mockLogger{}).(types.StartupHandler)
+
+		// Act
+		err := handler.OnStart(context.Background(), nil)
+
+		// Assert
+		if err == nil {
+			t.Error("エラーが期待されていましたが、nilが返されました")
+		}
+	})
+}
+
+// roundTripFunc answers a session's REST requests without a network
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// newRESTSession returns a session whose every REST request is answered with status and body
+func newRESTSession(status int, body string) *discordgo.Session {
+	s, _ := discordgo.New("Bot test")
+	s.MaxRestRetries = 0
+	s.Client = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    r,
+		}, nil
+	})}
+	return s
+}
+
+func TestSurveyHandler_CloseAutomatically(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		closed     bool
+		retryAfter time.Duration
+	}{
+		{"異常系: メッセージが削除されていれば締め切る", http.StatusNotFound, `{"code": 10008, "message": "Unknown Message"}`, true, 0},
+		{"異常系: チャンネルが削除されていれば締め切る", http.StatusNotFound, `{"code": 10003, "message": "Unknown Channel"}`, true, 0},
+		{"異常系: Discordの障害では後でやり直す", http.StatusInternalServerError, `{"message": "Internal Server Error"}`, false, time.Minute},
+		{"異常系: 権限がなければ後でやり直す", http.StatusForbidden, `{"code": 50001, "message": "Missing Access"}`, false, time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			registry := &mockSurveyRegistry{surveys: map[string]*types.Survey{
+				"message-1": {MessageID: "message-1", ChannelID: "channel-1", Options: []string{"A", "B"}, SurveySettings: types.SurveySettings{VoteMode: types.VoteModeReaction}},
+			}}
+			scheduler := &mockScheduler{}
+			handler := &surveyHandler{registry: registry, scheduler: scheduler, logger: &mockLogger{}}
+			before := time.Now()
+
+			// Act
+			handler.closeAutomatically(context.Background(), newRESTSession(tt.status, tt.body), "message-1", "", 0)
+
+			// Assert
+			if registry.surveys["message-1"].Closed != tt.closed {
+				t.Errorf("締め切りの状態が期待値と異なります: got %v, want %v", registry.surveys["message-1"].Closed, tt.closed)
+			}
+			at, retried := scheduler.jobs["message-1"]
+			if retried != (tt.retryAfter > 0) {
+				t.Fatalf("再試行の登録が期待値と異なります: got %v", scheduler.jobs)
+			}
+			if retried && at.Before(before.Add(tt.retryAfter)) {
+				t.Errorf("再試行の時刻が早すぎます: got %v", at)
+			}
+		})
+	}
+}
+
+func TestCloseRetryDelay(t *testing.T) {
+	t.Run("正常系: 1分から倍にして1時間で頭打ちにする", func(t *testing.T) {
+		expected := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 16 * time.Minute, 32 * time.Minute, time.Hour, time.Hour}
+		for attempt, want := range expected {
+			// Act
+			delay := closeRetryDelay(attempt)
+
+			// Assert
+			if delay != want {
+				t.Errorf("closeRetryDelay(%d) = %v, want %v", attempt, delay, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/Logta/SurveyBot/utils"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// removeOptionMarker stands in for an option to delete in !edit options
+	removeOptionMarker = "-"
+	forceFlag          = "--force"
+	closedEditMessage  = "締め切られたアンケートは編集できません"
+)
+
+// optionEdit is the outcome of rewriting a survey's option list in !edit options. Each line
+// replaces the option at the same position, "-" deletes it and lines past the end add options.
+type optionEdit struct {
+	options []string // the new option labels
+	moved   []int    // new index of each old option, or -1 when it is deleted
+	removed []int    // old indices of the deleted options
+	added   int      // options appended after the kept ones
+}
+
+// planOptionEdit works out how lines change the options of survey
+func planOptionEdit(survey *types.Survey, lines []string) optionEdit {
+	edit := optionEdit{moved: make([]int, len(survey.Options))}
+	for i := range survey.Options {
+		if i >= len(lines) || lines[i] == removeOptionMarker {
+			edit.moved[i] = -1
+			edit.removed = append(edit.removed, i)
+			continue
+		}
+		edit.moved[i] = len(edit.options)
+		edit.options = append(edit.options, lines[i])
+	}
+
+	for i := len(survey.Options); i < len(lines); i++ {
+		if lines[i] == removeOptionMarker {
+			continue
+		}
+		edit.options = append(edit.options, lines[i])
+		edit.added++
+	}
+
+	return edit
+}
+
+// remapVotes moves each vote's choices to the options' new positions, dropping choices of
+// deleted options and votes left without any choice
+func (e optionEdit) remapVotes(votes []types.Vote) []types.Vote {
+	remapped := make([]types.Vote, 0, len(votes))
+	for _, vote := range votes {
+		choices := make([]int, 0, len(vote.Choices))
+		for _, choice := range vote.Choices {
+			if choice >= 0 && choice < len(e.moved) && e.moved[choice] >= 0 {
+				choices = append(choices, e.moved[choice])
+			}
+		}
+		if len(choices) == 0 {
+			continue
+		}
+		vote.Choices = choices
+		remapped = append(remapped, vote)
+	}
+	return remapped
+}
+
+// unusedEmojis returns up to count emojis of the set that mark none of the used options
+func (e optionEmojiSet) unusedEmojis(ctx context.Context, used []string, count int) ([]string, error) {
+	inUse := make(map[string]bool, len(used))
+	for _, emoji := range used {
+		inUse[emoji] = true
+	}
+
+	var emojis []string
+	for index := e.offset; index < e.provider.GetMaxEmojis() && len(emojis) < count; index++ {
+		emoji, err := e.provider.GetEmoji(ctx, index)
+		if err != nil {
+			return nil, err
+		}
+		if !inUse[emoji] {
+			emojis = append(emojis, emoji)
+		}
+	}
+	return emojis, nil
+}
+
+// handleEdit changes the title or options of a published survey, keeping its votes:
+//
+//	!edit title [message ID]
+//	新しいタイトル
+//
+//	!edit options [message ID] [--force]
+//	選択肢1
+//	-
+//	選択肢3
+func (h *surveyHandler) handleEdit(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
+	header, body, _ := strings.Cut(strings.ReplaceAll(m.Content, "\r\n", "\n"), "\n")
+	args := strings.Fields(header)[1:]
+	if len(args) == 0 || (args[0] != "title" && args[0] != "options") {
+		_, err := s.ChannelMessageSend(m.ChannelID, editUsage)
+		return err
+	}
+
+	messageID := ""
+	force := false
+	for _, arg := range args[1:] {
+		if arg == forceFlag {
+			force = true
+		} else {
+			messageID = arg
+		}
+	}
+
+	survey, err := h.targetSurvey(ctx, m, messageID)
+	if errors.Is(err, types.ErrSurveyNotFound) {
+		_, err := s.ChannelMessageSend(m.ChannelID, "編集できるアンケートが見つかりませんでした")
+		return err
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to find survey", err)
+		return err
+	}
+
+	if survey.Closed {
+		_, err := s.ChannelMessageSend(m.ChannelID, closedEditMessage)
+		return err
+	}
+
+	allowed, err := h.canManageSurvey(s, m, survey)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get member permissions", err)
+		return err
+	}
+	if !allowed {
+		_, err := s.ChannelMessageSend(m.ChannelID, "アンケートを編集できるのは作成者か「メッセージの管理」権限を持つメンバーだけです")
+		return err
+	}
+
+	if args[0] == "title" {
+		return h.editTitle(ctx, s, m, survey, strings.TrimSpace(body))
+	}
+
+	lines, err := utils.ParseLines(body)
+	if err != nil {
+		_, err := s.ChannelMessageSend(m.ChannelID, unclosedQuoteMessage)
+		return err
+	}
+	return h.editOptions(ctx, s, m, survey, lines, force)
+}
+
+const editUsage = "!edit title または !edit options の後に改行を挟んで新しい内容を記入してください\n" +
+	"回答項目は今の順に1行ずつ記入し、削除する項目は - と記入します"
+
+// targetSurvey resolves the survey named by messageID, the replied-to message, or the latest
+// survey in the channel
+func (h *surveyHandler) targetSurvey(ctx context.Context, m *discordgo.MessageCreate, messageID string) (*types.Survey, error) {
+	if messageID != "" {
+		return h.registry.GetSurvey(ctx, messageID)
+	}
+	if m.MessageReference != nil {
+		return h.registry.GetSurvey(ctx, m.MessageReference.MessageID)
+	}
+	return h.registry.GetLatestSurvey(ctx, m.ChannelID)
+}
+
+// canManageSurvey reports whether the author of m may edit or export survey: its creator always can,
+// other members need the Manage Messages permission in the survey's channel
+func (h *surveyHandler) canManageSurvey(s *discordgo.Session, m *discordgo.MessageCreate, survey *types.Survey) (bool, error) {
+	if m.Author.ID == survey.AuthorID {
+		return true, nil
+	}
+	if survey.GuildID == "" {
+		return false, nil
+	}
+
+	permissions, err := s.UserChannelPermissions(m.Author.ID, survey.ChannelID)
+	if err != nil {
+		return false, err
+	}
+	return permissions&discordgo.PermissionManageMessages != 0, nil
+}
+
+func (h *surveyHandler) editTitle(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, survey *types.Survey, title string) error {
+	if title == "" {
+		_, err := s.ChannelMessageSend(m.ChannelID, editUsage)
+		return err
+	}
+
+	survey, err := h.registry.UpdateSurvey(ctx, survey.MessageID, func(survey *types.Survey) error {
+		if survey.Closed {
+			return types.ErrSurveyClosed
+		}
+		survey.Title = title
+		return nil
+	})
+	if errors.Is(err, types.ErrSurveyClosed) {
+		_, err := s.ChannelMessageSend(m.ChannelID, closedEditMessage)
+		return err
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to save survey", err)
+		return err
+	}
+	if err := h.updateSurveyMessage(s, survey); err != nil {
+		h.logger.Error(ctx, "Failed to update survey message", err, types.Field{Key: "message_id", Value: survey.MessageID})
+		return err
+	}
+
+	_, err = s.ChannelMessageSend(m.ChannelID, "アンケートのタイトルを変更しました")
+	return err
+}
+
+func (h *surveyHandler) editOptions(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, survey *types.Survey, lines []string, force bool) error {
+	// Hold reaction votes back while the options move, so none lands on an old position
+	h.reactionMu.Lock()
+	defer h.reactionMu.Unlock()
+
+	// Re-read the survey now that no reaction vote can change it
+	survey, err := h.registry.GetSurvey(ctx, survey.MessageID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to find survey", err)
+		return err
+	}
+
+	edit := planOptionEdit(survey, lines)
+	if len(edit.options) == 0 {
+		_, err := s.ChannelMessageSend(m.ChannelID, editUsage)
+		return err
+	}
+
+	if !force {
+		counts, err := optionVoteCounts(s, survey)
+		if err != nil {
+			h.logger.Error(ctx, "Failed to fetch survey message", err, types.Field{Key: "message_id", Value: survey.MessageID})
+			_, err := s.ChannelMessageSend(m.ChannelID, "アンケートが見つかりませんでした")
+			return err
+		}
+		if warning := removedVotesWarning(survey, counts, edit.removed); warning != "" {
+			_, err := s.ChannelMessageSend(m.ChannelID, warning)
+			return err
+		}
+	}
+
+	emojiSet, err := h.resolveEmojiSet(ctx, s, survey)
+	if err != nil {
+		if message, ok := surveyErrorMessage(err); ok {
+			_, err := s.ChannelMessageSend(m.ChannelID, message)
+			return err
+		}
+		return err
+	}
+	if max := emojiSet.maxOptions(survey.VoteMode); len(edit.options) > max {
+		message, _ := surveyErrorMessage(&tooManyOptionsError{count: len(edit.options), max: max})
+		_, err := s.ChannelMessageSend(m.ChannelID, message)
+		return err
+	}
+
+	emojis := make([]string, 0, len(edit.options))
+	var removedEmojis []string
+	for i, emoji := range survey.Emojis {
+		if edit.moved[i] >= 0 {
+			emojis = append(emojis, emoji)
+		} else if emoji != "" {
+			removedEmojis = append(removedEmojis, emoji)
+		}
+	}
+	newEmojis, err := emojiSet.unusedEmojis(ctx, emojis, edit.added)
+	if err != nil {
+		return err
+	}
+	// Reaction surveys need an emoji per option; component surveys leave the rest unmarked
+	if len(newEmojis) < edit.added && survey.VoteMode == types.VoteModeReaction {
+		message, _ := surveyErrorMessage(&tooManyOptionsError{count: len(edit.options), max: len(emojis) + len(newEmojis)})
+		_, err := s.ChannelMessageSend(m.ChannelID, message)
+		return err
+	}
+	emojis = append(emojis, newEmojis...)
+	for len(emojis) < len(edit.options) {
+		emojis = append(emojis, "")
+	}
+
+	// Votes cast through components since the survey was read are remapped as well
+	survey, err = h.registry.UpdateSurvey(ctx, survey.MessageID, func(survey *types.Survey) error {
+		if survey.Closed {
+			return types.ErrSurveyClosed
+		}
+		survey.Options = edit.options
+		survey.Emojis = emojis
+		survey.Votes = edit.remapVotes(survey.Votes)
+		return nil
+	})
+	if errors.Is(err, types.ErrSurveyClosed) {
+		_, err := s.ChannelMessageSend(m.ChannelID, closedEditMessage)
+		return err
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to save survey", err)
+		return err
+	}
+	if err := h.updateSurveyMessage(s, survey); err != nil {
+		h.logger.Error(ctx, "Failed to update survey message", err, types.Field{Key: "message_id", Value: survey.MessageID})
+		return err
+	}
+
+	if survey.VoteMode == types.VoteModeReaction {
+		for _, emoji := range removedEmojis {
+			if err := s.MessageReactionsRemoveEmoji(survey.ChannelID, survey.MessageID, reactionAPIName(emoji)); err != nil {
+				h.logger.Error(ctx, "Failed to remove reactions", err, types.Field{Key: "message_id", Value: survey.MessageID})
+			}
+		}
+		for _, emoji := range newEmojis {
+			if err := s.MessageReactionAdd(survey.ChannelID, survey.MessageID, reactionAPIName(emoji)); err != nil {
+				h.logger.Error(ctx, "Failed to add reaction", err)
+			}
+		}
+	}
+
+	h.logger.Info(ctx, "Survey options edited",
+		types.Field{Key: "message_id", Value: survey.MessageID},
+		types.Field{Key: "removed", Value: len(edit.removed)},
+		types.Field{Key: "added", Value: edit.added},
+	)
+
+	_, err = s.ChannelMessageSend(m.ChannelID, "アンケートの回答項目を変更しました")
+	return err
+}
+
+// optionVoteCounts returns how many votes each option has: reaction surveys are counted
+// from the message, which also holds reactions added while the bot was offline, and other
+// surveys from their recorded votes
+func optionVoteCounts(s *discordgo.Session, survey *types.Survey) ([]int, error) {
+	if survey.VoteMode != types.VoteModeReaction {
+		return utils.CountVotes(len(survey.Options), survey.Votes), nil
+	}
+
+	message, err := s.ChannelMessage(survey.ChannelID, survey.MessageID)
+	if err != nil {
+		return nil, err
+	}
+	options := surveyOptionResults(survey)
+	countReactions(options, message.Reactions)
+
+	counts := make([]int, len(options))
+	for i, option := range options {
+		counts[i] = option.Count
+	}
+	return counts, nil
+}
+
+// removedVotesWarning lists the options about to be deleted that have votes in counts, or
+// returns "" when deleting them loses none
+func removedVotesWarning(survey *types.Survey, counts []int, removed []int) string {
+
+	var lines []string
+	for _, i := range removed {
+		if counts[i] > 0 {
+			lines = append(lines, fmt.Sprintf("%s %s (%d票)", optionMarker(survey, i), survey.Options[i], counts[i]))
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return "次の回答項目には投票があり、削除するとその投票も取り消されます\n" +
+		strings.Join(lines, "\n") + "\n" +
+		"削除してよければ " + string(types.CmdEdit) + " options " + forceFlag + " で再実行してください"
+}
+
+// updateSurveyMessage redraws the survey message, and its voting components when it has any
+func (h *surveyHandler) updateSurveyMessage(s *discordgo.Session, survey *types.Survey) error {
+	if survey.VoteMode == types.VoteModeReaction {
+		_, err := s.ChannelMessageEditEmbed(survey.ChannelID, survey.MessageID, createSurveyMessageEmbed(survey))
+		return err
+	}
+
+	components := surveyComponents(survey)
+	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         survey.MessageID,
+		Channel:    survey.ChannelID,
+		Embeds:     &[]*discordgo.MessageEmbed{createSurveyMessageEmbed(survey)},
+		Components: &components,
+	})
+	return err
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/Logta/SurveyBot/utils"
+)
+
+func TestPlanOptionEdit(t *testing.T) {
+	t.Run("正常系: 同じ位置の項目を書き換え、末尾に追加する", func(t *testing.T) {
+		// Arrange
+		survey := &types.Survey{Options: []string{"Pyton", "Go"}}
+
+		// Act
+		edit := planOptionEdit(survey, []string{"Python", "Go", "Rust"})
+
+		// Assert
+		if !reflect.DeepEqual(edit.options, []string{"Python", "Go", "Rust"}) {
+			t.Errorf("回答項目が期待値と異なります: got %v", edit.options)
+		}
+		if !reflect.DeepEqual(edit.moved, []int{0, 1}) || len(edit.removed) != 0 || edit.added != 1 {
+			t.Errorf("変更内容が期待値と異なります: got %+v", edit)
+		}
+	})
+
+	t.Run("正常系: - と省略した末尾の項目を削除する", func(t *testing.T) {
+		// Arrange
+		survey := &types.Survey{Options: []string{"A", "B", "C", "D"}}
+
+		// Act
+		edit := planOptionEdit(survey, []string{"-", "B", "C"})
+
+		// Assert
+		if !reflect.DeepEqual(edit.options, []string{"B", "C"}) {
+			t.Errorf("回答項目が期待値と異なります: got %v", edit.options)
+		}
+		if !reflect.DeepEqual(edit.moved, []int{-1, 0, 1, -1}) {
+			t.Errorf("移動先が期待値と異なります: got %v", edit.moved)
+		}
+		if !reflect.DeepEqual(edit.removed, []int{0, 3}) {
+			t.Errorf("削除された項目が期待値と異なります: got %v", edit.removed)
+		}
+	})
+}
+
+func TestOptionEdit_RemapVotes(t *testing.T) {
+	t.Run("正常系: 投票を新しい位置に移し、削除された項目の投票を取り消す", func(t *testing.T) {
+		// Arrange
+		survey := &types.Survey{Options: []string{"A", "B", "C"}}
+		edit := planOptionEdit(survey, []string{"-", "B", "C"})
+		votes := []types.Vote{
+			{UserID: "user-1", Choices: []int{2, 0, 1}},
+			{UserID: "user-2", Choices: []int{0}},
+		}
+
+		// Act
+		remapped := edit.remapVotes(votes)
+
+		// Assert
+		if len(remapped) != 1 {
+			t.Fatalf("投票数が期待値と異なります: got %v, want %v", len(remapped), 1)
+		}
+		if !reflect.DeepEqual(remapped[0].Choices, []int{1, 0}) {
+			t.Errorf("選択が期待値と異なります: got %v, want %v", remapped[0].Choices, []int{1, 0})
+		}
+		if !reflect.DeepEqual(votes[0].Choices, []int{2, 0, 1}) {
+			t.Errorf("元の投票が変更されています: got %v", votes[0].Choices)
+		}
+	})
+}
+
+func TestRemovedVotesWarning(t *testing.T) {
+	t.Run("正常系: 投票のある削除項目を警告する", func(t *testing.T) {
+		// Arrange
+		survey := &types.Survey{
+			Options: []string{"A", "B", "C"},
+			Emojis:  []string{"1️⃣", "2️⃣", "3️⃣"},
+			Votes:   []types.Vote{{UserID: "user-1", Choices: []int{1}}},
+		}
+
+		// Act
+		warning := removedVotesWarning(survey, utils.CountVotes(len(survey.Options), survey.Votes), []int{0, 1})
+
+		// Assert
+		if !strings.Contains(warning, "2️⃣ B (1票)") || strings.Contains(warning, "1️⃣ A") {
+			t.Errorf("警告が期待値と異なります: got %v", warning)
+		}
+	})
+
+	t.Run("正常系: 投票のない項目の削除は警告しない", func(t *testing.T) {
+		// Arrange
+		survey := &types.Survey{
+			Options: []string{"A", "B"},
+			Votes:   []types.Vote{{UserID: "user-1", Choices: []int{1}}},
+		}
+
+		// Act
+		warning := removedVotesWarning(survey, utils.CountVotes(len(survey.Options), survey.Votes), []int{0})
+
+		// Assert
+		if warning != "" {
+			t.Errorf("警告が期待されていませんでした: got %v", warning)
+		}
+	})
+}
+
+func TestOptionVoteCounts(t *testing.T) {
+	t.Run("正常系: リアクションのアンケートはメッセージのリアクションから数える", func(t *testing.T) {
+		// Arrange
+		survey := &types.Survey{
+			MessageID:      "message-1",
+			ChannelID:      "channel-1",
+			Options:        []string{"A", "B"},
+			Emojis:         []string{"1️⃣", "2️⃣"},
+			SurveySettings: types.SurveySettings{VoteMode: types.VoteModeReaction},
+		}
+		// Reacted while the bot was offline, so nothing is recorded
+		s := newRESTSession(http.StatusOK, `{"id": "message-1", "reactions": [{"count": 3, "me": true, "emoji": {"name": "2️⃣"}}]}`)
+
+		// Act
+		counts, err := optionVoteCounts(s, survey)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if !reflect.DeepEqual(counts, []int{0, 2}) {
+			t.Errorf("得票数が期待値と異なります: got %v, want %v", counts, []int{0, 2})
+		}
+		if warning := removedVotesWarning(survey, counts, []int{1}); !strings.Contains(warning, "2️⃣ B (2票)") {
+			t.Errorf("警告が期待値と異なります: got %v", warning)
+		}
+	})
+
+	t.Run("正常系: ボタンのアンケートは記録された投票から数える", func(t *testing.T) {
+		// Arrange
+		survey := newComponentSurvey(2)
+		survey.Votes = []types.Vote{{UserID: "user-1", Choices: []int{1}}}
+
+		// Act
+		counts, err := optionVoteCounts(nil, survey)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if !reflect.DeepEqual(counts, []int{0, 1}) {
+			t.Errorf("得票数が期待値と異なります: got %v, want %v", counts, []int{0, 1})
+		}
+	})
+}
+
+func TestOptionEmojiSet_UnusedEmojis(t *testing.T) {
+	t.Run("正常系: 使用中の絵文字を飛ばして割り当てる", func(t *testing.T) {
+		// Arrange
+		emojiSet := optionEmojiSet{provider: &mockEmojiProvider{emojis: []string{"0️⃣", "1️⃣", "2️⃣", "3️⃣", "4️⃣"}}, offset: 1}
+
+		// Act
+		emojis, err := emojiSet.unusedEmojis(context.Background(), []string{"2️⃣"}, 2)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if !reflect.DeepEqual(emojis, []string{"1️⃣", "3️⃣"}) {
+			t.Errorf("絵文字が期待値と異なります: got %v", emojis)
+		}
+	})
+
+	t.Run("正常系: 絵文字が足りない場合は割り当てられた分だけ返す", func(t *testing.T) {
+		// Arrange
+		emojiSet := optionEmojiSet{provider: &mockEmojiProvider{emojis: []string{"🔴", "🟠"}}}
+
+		// Act
+		emojis, err := emojiSet.unusedEmojis(context.Background(), []string{"🔴"}, 3)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if !reflect.DeepEqual(emojis, []string{"🟠"}) {
+			t.Errorf("絵文字が期待値と異なります: got %v", emojis)
+		}
+	})
+}
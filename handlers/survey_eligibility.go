@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/Logta/SurveyBot/utils"
+	"github.com/bwmarrin/discordgo"
+)
+
+// isEligible reports whether member may vote on survey. The guild ID as the role stands
+// for @everyone.
+func isEligible(survey *types.Survey, member *discordgo.Member) bool {
+	if survey.EligibleRoleID == "" || survey.EligibleRoleID == survey.GuildID {
+		return true
+	}
+	return member != nil && hasRole(member, survey.EligibleRoleID)
+}
+
+// ineligibleMessage tells a member without the survey's role that their vote is not taken
+func ineligibleMessage(survey *types.Survey) string {
+	return fmt.Sprintf("このアンケートに投票できるのは <@&%s> のメンバーだけです", survey.EligibleRoleID)
+}
+
+// reactionVotes reads the votes of a reaction survey back from its reactions, leaving out
+// bots such as the one that seeded them
+func reactionVotes(s *discordgo.Session, survey *types.Survey) ([]types.Vote, error) {
+	choices := make(map[string][]int)
+	var order []string
+	for option, emoji := range survey.Emojis {
+		after := ""
+		for {
+			users, err := s.MessageReactions(survey.ChannelID, survey.MessageID, reactionAPIName(emoji), reactionUsersPageSize, "", after)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list reactions: %w", err)
+			}
+			for _, user := range users {
+				if user.Bot {
+					continue
+				}
+				if _, seen := choices[user.ID]; !seen {
+					order = append(order, user.ID)
+				}
+				choices[user.ID] = append(choices[user.ID], option)
+			}
+			if len(users) < reactionUsersPageSize {
+				break
+			}
+			after = users[len(users)-1].ID
+		}
+	}
+
+	votes := make([]types.Vote, len(order))
+	for i, id := range order {
+		votes[i] = types.Vote{UserID: id, Choices: choices[id]}
+	}
+	return votes, nil
+}
+
+// eligibleVoters returns the voter IDs, hashed on anonymous surveys, of the members other
+// than bots who may vote on survey
+func eligibleVoters(s *discordgo.Session, survey *types.Survey) (map[string]bool, error) {
+	members, err := guildMembers(s, survey.GuildID)
+	if err != nil {
+		return nil, err
+	}
+
+	eligible := make(map[string]bool)
+	for _, member := range members {
+		if member.User == nil || member.User.Bot || !isEligible(survey, member) {
+			continue
+		}
+		eligible[voterID(survey, member.User.ID)] = true
+	}
+	return eligible, nil
+}
+
+// eligibleVotes keeps the votes of eligible voters and measures the turnout of the role,
+// or of everyone when voting is not limited
+func eligibleVotes(survey *types.Survey, votes []types.Vote, eligible map[string]bool) ([]types.Vote, *types.Turnout) {
+	roleID := survey.EligibleRoleID
+	if roleID == "" {
+		roleID = survey.GuildID
+	}
+	turnout := &types.Turnout{RoleID: roleID, Members: len(eligible)}
+	kept := make([]types.Vote, 0, len(votes))
+	for _, vote := range votes {
+		if len(vote.Choices) == 0 {
+			continue
+		}
+		if !eligible[vote.UserID] {
+			turnout.Ignored++
+			continue
+		}
+		kept = append(kept, vote)
+		turnout.Voters++
+	}
+	return kept, turnout
+}
+
+// countVoters keeps the votes that count and measures turnout and quorum. The eligible
+// members are listed when voting is limited to a role or the quorum is a percentage of
+// them; if they cannot be listed every vote counts and the turnout is left out.
+func (h *surveyHandler) countVoters(ctx context.Context, s *discordgo.Session, survey *types.Survey, votes []types.Vote) ([]types.Vote, *types.Turnout, *types.QuorumResult) {
+	var kept []types.Vote
+	for _, vote := range votes {
+		if len(vote.Choices) > 0 {
+			kept = append(kept, vote)
+		}
+	}
+
+	var turnout *types.Turnout
+	if survey.EligibleRoleID != "" || survey.QuorumPercent > 0 {
+		eligible, err := eligibleVoters(s, survey)
+		if err != nil {
+			// Votes were already checked when cast, so counting them all is the fallback
+			h.logger.Error(ctx, "Failed to list eligible members", err, types.Field{Key: "message_id", Value: survey.MessageID})
+		} else {
+			kept, turnout = eligibleVotes(survey, votes, eligible)
+		}
+	}
+
+	return kept, turnout, surveyQuorum(survey, len(kept), turnout)
+}
+
+// tallyVoters counts the survey vote by vote, so that only eligible members count and
+// the turnout and quorum can be reported
+func (h *surveyHandler) tallyVoters(ctx context.Context, s *discordgo.Session, survey *types.Survey) (*types.SurveyResult, error) {
+	votes := survey.Votes
+	if survey.VoteMode == types.VoteModeReaction {
+		var err error
+		votes, err = reactionVotes(s, survey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return h.tallyVoterVotes(ctx, s, survey, votes), nil
+}
+
+// tallyVoterVotes counts votes, already read for the survey, like tallyVoters
+func (h *surveyHandler) tallyVoterVotes(ctx context.Context, s *discordgo.Session, survey *types.Survey, votes []types.Vote) *types.SurveyResult {
+	kept, turnout, quorum := h.countVoters(ctx, s, survey, votes)
+
+	options := surveyOptionResults(survey)
+	for i, count := range utils.CountVotes(len(options), kept) {
+		options[i].Count = count
+	}
+	result := utils.TallyResults(survey.Title, options)
+	result.Turnout = turnout
+	result.Quorum = quorum
+
+	h.logger.Debug(ctx, "Survey tallied by voter",
+		types.Field{Key: "message_id", Value: survey.MessageID},
+		types.Field{Key: "voters", Value: len(kept)},
+	)
+
+	return result
+}
+
+// turnoutField shows how many eligible members voted, or nil when they were not counted
+func turnoutField(turnout *types.Turnout) *discordgo.MessageEmbedField {
+	if turnout == nil {
+		return nil
+	}
+
+	rate := 0.0
+	if turnout.Members > 0 {
+		rate = float64(turnout.Voters) / float64(turnout.Members) * 100
+	}
+	value := fmt.Sprintf("<@&%s> %d / %d人 (%.1f%%)", turnout.RoleID, turnout.Voters, turnout.Members, rate)
+	if turnout.Ignored > 0 {
+		value += fmt.Sprintf("\n対象外のメンバーの投票 %d件は集計していません", turnout.Ignored)
+	}
+	return &discordgo.MessageEmbedField{Name: "投票率", Value: value, Inline: false}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestIsEligible(t *testing.T) {
+	member := &discordgo.Member{User: &discordgo.User{ID: "1"}, Roles: []string{"engineers"}}
+
+	tests := []struct {
+		name     string
+		roleID   string
+		member   *discordgo.Member
+		expected bool
+	}{
+		{"正常系: ロール指定なし", "", nil, true},
+		{"正常系: ロールを持つメンバー", "engineers", member, true},
+		{"正常系: @everyone", "guild-1", nil, true},
+		{"異常系: ロールを持たないメンバー", "designers", member, false},
+		{"異常系: メンバー情報なし", "engineers", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			survey := &types.Survey{GuildID: "guild-1", SurveySettings: types.SurveySettings{EligibleRoleID: tt.roleID}}
+
+			// Act
+			result := isEligible(survey, tt.member)
+
+			// Assert
+			if result != tt.expected {
+				t.Errorf("isEligible() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEligibleVotes(t *testing.T) {
+	t.Run("正常系: 対象のメンバーの投票だけを残して投票率を数える", func(t *testing.T) {
+		// Arrange
+		survey := &types.Survey{SurveySettings: types.SurveySettings{EligibleRoleID: "engineers"}}
+		votes := []types.Vote{
+			{UserID: "1", Choices: []int{0}},
+			{UserID: "2", Choices: []int{1}},
+			{UserID: "3", Choices: []int{0}},
+			{UserID: "4"},
+		}
+		eligible := map[string]bool{"1": true, "2": true, "4": true, "5": true}
+
+		// Act
+		kept, turnout := eligibleVotes(survey, votes, eligible)
+
+		// Assert
+		if len(kept) != 2 || kept[0].UserID != "1" || kept[1].UserID != "2" {
+			t.Errorf("残った投票が期待値と異なります: got %+v", kept)
+		}
+		expected := types.Turnout{RoleID: "engineers", Voters: 2, Members: 4, Ignored: 1}
+		if *turnout != expected {
+			t.Errorf("投票率が期待値と異なります: got %+v, want %+v", *turnout, expected)
+		}
+	})
+}
+
+func TestTurnoutField(t *testing.T) {
+	t.Run("正常系: 投票率と除外した投票を表示", func(t *testing.T) {
+		// Act
+		field := turnoutField(&types.Turnout{RoleID: "engineers", Voters: 3, Members: 4, Ignored: 2})
+
+		// Assert
+		if !strings.Contains(field.Value, "<@&engineers> 3 / 4人 (75.0%)") || !strings.Contains(field.Value, "2件") {
+			t.Errorf("表示が期待値と異なります: got %q", field.Value)
+		}
+	})
+
+	t.Run("正常系: ロール指定がなければ表示しない", func(t *testing.T) {
+		// Act
+		field := turnoutField(nil)
+
+		// Assert
+		if field != nil {
+			t.Errorf("nilが期待されていましたが、%+vが返されました", field)
+		}
+	})
+}
+
+func TestParseSurveyFlags_Role(t *testing.T) {
+	t.Run("正常系: 投票できるロールを読み取る", func(t *testing.T) {
+		// Act
+		_, settings, err := parseSurveyFlags([]string{"--role", "<@&42>"}, time.Now())
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if settings.EligibleRoleID != "42" {
+			t.Errorf("ロールが期待値と異なります: got %v, want %v", settings.EligibleRoleID, "42")
+		}
+	})
+
+	t.Run("異常系: ロールでない値", func(t *testing.T) {
+		// Act
+		_, _, err := parseSurveyFlags([]string{"--role=engineers"}, time.Now())
+
+		// Assert
+		if err == nil {
+			t.Error("エラーが期待されていましたが、nilが返されました")
+		}
+	})
+}
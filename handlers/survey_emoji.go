@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/Logta/SurveyBot/utils"
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxReactions is the most distinct reactions Discord allows on one message
+const maxReactions = 20
+
+// errNoCustomEmojis is returned when the custom emoji set is chosen in a guild without
+// custom emojis the bot can use
+var errNoCustomEmojis = errors.New("no usable custom emojis")
+
+// tooManyOptionsError is returned when a survey has more options than it can mark
+type tooManyOptionsError struct {
+	count int
+	max   int
+}
+
+func (e *tooManyOptionsError) Error() string {
+	return fmt.Sprintf("too many options: %d (max: %d)", e.count, e.max)
+}
+
+// optionEmojiSet is the emoji provider marking a survey's options. Number emojis skip
+// 0️⃣ so that numbering starts from 1.
+type optionEmojiSet struct {
+	provider types.EmojiProvider
+	offset   int
+}
+
+// maxOptions returns how many options a survey can have. Reaction surveys need an emoji
+// per option within Discord's reaction limit; component and ranked surveys leave options
+// beyond the emoji set unmarked.
+func (e optionEmojiSet) maxOptions(mode types.VoteMode) int {
+	if mode == types.VoteModeComponent || mode == types.VoteModeRanked {
+		return maxSelectOptions
+	}
+	return min(e.provider.GetMaxEmojis()-e.offset, maxReactions)
+}
+
+// optionEmojis returns the emoji for each option. Component surveys may have more options
+// than emojis, in which case the remaining options are left without one.
+func (e optionEmojiSet) optionEmojis(ctx context.Context, survey *types.Survey) ([]string, error) {
+	if max := e.maxOptions(survey.VoteMode); len(survey.Options) > max {
+		return nil, &tooManyOptionsError{count: len(survey.Options), max: max}
+	}
+
+	emojis := make([]string, len(survey.Options))
+	for i := range survey.Options {
+		index := i + e.offset
+		if index >= e.provider.GetMaxEmojis() {
+			continue
+		}
+
+		emoji, err := e.provider.GetEmoji(ctx, index)
+		if err != nil {
+			return nil, err
+		}
+		emojis[i] = emoji
+	}
+
+	return emojis, nil
+}
+
+// parseEmojiSet reads an emoji set name as given to --emoji or !emoji
+func parseEmojiSet(name string) (types.EmojiSet, bool) {
+	switch set := types.EmojiSet(strings.ToLower(name)); set {
+	case types.EmojiSetNumber, types.EmojiSetAlphabet, types.EmojiSetCircle, types.EmojiSetCustom:
+		return set, true
+	}
+	return "", false
+}
+
+// resolveEmojiSet picks the survey's emoji set, falling back to the guild's default and
+// then to numbers, and records the choice on the survey
+func (h *surveyHandler) resolveEmojiSet(ctx context.Context, s *discordgo.Session, survey *types.Survey) (optionEmojiSet, error) {
+	if survey.EmojiSet == "" && survey.GuildID != "" {
+		settings, err := h.guildSettings.GetGuildSettings(ctx, survey.GuildID)
+		if err != nil {
+			return optionEmojiSet{}, err
+		}
+		survey.EmojiSet = settings.EmojiSet
+	}
+	if survey.EmojiSet == "" {
+		survey.EmojiSet = types.EmojiSetNumber
+	}
+
+	switch survey.EmojiSet {
+	case types.EmojiSetAlphabet:
+		return optionEmojiSet{provider: utils.NewAlphabetEmojiProvider()}, nil
+	case types.EmojiSetCircle:
+		return optionEmojiSet{provider: utils.NewCircleEmojiProvider()}, nil
+	case types.EmojiSetCustom:
+		emojis, err := guildCustomEmojis(s, survey.GuildID)
+		if err != nil {
+			return optionEmojiSet{}, err
+		}
+		return optionEmojiSet{provider: utils.NewCustomEmojiProvider(emojis)}, nil
+	default:
+		return optionEmojiSet{provider: h.emojiProvider, offset: 1}, nil
+	}
+}
+
+// guildCustomEmojis returns the guild's custom emojis that anyone, including the bot, may use
+func guildCustomEmojis(s *discordgo.Session, guildID string) ([]string, error) {
+	if guildID == "" {
+		return nil, errNoCustomEmojis
+	}
+
+	guildEmojis, err := s.GuildEmojis(guildID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch guild emojis: %w", err)
+	}
+
+	var emojis []string
+	for _, emoji := range guildEmojis {
+		if emoji.Available && len(emoji.Roles) == 0 {
+			emojis = append(emojis, emoji.MessageFormat())
+		}
+	}
+	if len(emojis) == 0 {
+		return nil, errNoCustomEmojis
+	}
+	return emojis, nil
+}
+
+// surveyErrorMessage explains errors caused by the survey's contents to its author
+func surveyErrorMessage(err error) (string, bool) {
+	var tooMany *tooManyOptionsError
+	var permission *channelPermissionError
+	switch {
+	case errors.As(err, &permission):
+		return channelPermissionMessage(permission), true
+	case errors.Is(err, errForeignChannel):
+		return "投稿先のチャンネルが見つからないか、このサーバーのチャンネルではありません", true
+	case errors.As(err, &tooMany):
+		message := fmt.Sprintf("回答項目は%d個まで記入できます", tooMany.max)
+		if tooMany.max < maxReactions {
+			message += fmt.Sprintf("（--emoji alphabet を付けると%d個まで、--buttons を付けると%d個まで使えます）", maxReactions, maxSelectOptions)
+		}
+		return message, true
+	case errors.Is(err, errNoCustomEmojis):
+		return "このサーバーにはアンケートに使えるカスタム絵文字がありません", true
+	}
+	return "", false
+}
+
+func (h *surveyHandler) handleEmoji(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if m.GuildID == "" {
+		_, err := s.ChannelMessageSend(m.ChannelID, "このコマンドはサーバー内で実行してください")
+		return err
+	}
+
+	args := strings.Fields(strings.TrimPrefix(m.Content, string(types.CmdEmoji)))
+	if len(args) == 0 {
+		settings, err := h.guildSettings.GetGuildSettings(ctx, m.GuildID)
+		if err != nil {
+			h.logger.Error(ctx, "Failed to get guild settings", err)
+			return err
+		}
+		current := settings.EmojiSet
+		if current == "" {
+			current = types.EmojiSetNumber
+		}
+		_, err = s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("このサーバーのアンケートの絵文字: %s\n変更するには !emoji number|alphabet|circle|custom を実行してください", current))
+		return err
+	}
+
+	set, ok := parseEmojiSet(args[0])
+	if !ok {
+		_, err := s.ChannelMessageSend(m.ChannelID, "絵文字は number, alphabet, circle, custom のいずれかを指定してください")
+		return err
+	}
+
+	permissions, err := s.UserChannelPermissions(m.Author.ID, m.ChannelID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get member permissions", err)
+		return err
+	}
+	if permissions&discordgo.PermissionManageGuild == 0 {
+		_, err := s.ChannelMessageSend(m.ChannelID, "サーバーの絵文字の設定には「サーバー管理」権限が必要です")
+		return err
+	}
+
+	settings, err := h.guildSettings.GetGuildSettings(ctx, m.GuildID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get guild settings", err)
+		return err
+	}
+	settings.EmojiSet = set
+	if err := h.guildSettings.SetGuildSettings(ctx, m.GuildID, settings); err != nil {
+		h.logger.Error(ctx, "Failed to save guild settings", err)
+		return err
+	}
+
+	_, err = s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("このサーバーのアンケートの絵文字を %s に変更しました", set))
+	return err
+}
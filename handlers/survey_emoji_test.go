@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+func newEmojiTestHandler(guildSettings types.GuildSettingsStore) *surveyHandler {
+	emojiProvider := &mockEmojiProvider{emojis: []string{"0️⃣", "1️⃣", "2️⃣", "3️⃣"}}
+	return NewSurveyHandler(&mockStateManager{}, &mockSurveyRegistry{}, guildSettings, &mockTemplateStore{}, &mockScheduleStore{}, &mockScheduler{}, emojiProvider, &mockLogger{}).(*surveyHandler)
+}
+
+func TestSurveyHandler_ResolveEmojiSet(t *testing.T) {
+	t.Run("正常系: アンケート、サーバー、既定の順に絵文字セットを決める", func(t *testing.T) {
+		// Arrange
+		guildSettings := &mockGuildSettingsStore{settings: map[string]*types.GuildSettings{
+			"guild-circle": {EmojiSet: types.EmojiSetCircle},
+		}}
+		handler := newEmojiTestHandler(guildSettings)
+
+		testCases := []struct {
+			name          string
+			survey        *types.Survey
+			expectedSet   types.EmojiSet
+			expectedFirst string
+		}{
+			{"指定なし", &types.Survey{GuildID: "guild-none"}, types.EmojiSetNumber, "1️⃣"},
+			{"サーバーの既定", &types.Survey{GuildID: "guild-circle"}, types.EmojiSetCircle, "🔴"},
+			{"アンケートの指定を優先", &types.Survey{GuildID: "guild-circle", SurveySettings: types.SurveySettings{EmojiSet: types.EmojiSetAlphabet}}, types.EmojiSetAlphabet, "🇦"},
+			{"DMでは既定", &types.Survey{}, types.EmojiSetNumber, "1️⃣"},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				// Act
+				emojiSet, err := handler.resolveEmojiSet(context.Background(), nil, tc.survey)
+
+				// Assert
+				if err != nil {
+					t.Fatalf("期待していないエラーが発生: %v", err)
+				}
+				if tc.survey.EmojiSet != tc.expectedSet {
+					t.Errorf("絵文字セットが期待値と異なります: got %v, want %v", tc.survey.EmojiSet, tc.expectedSet)
+				}
+				first, _ := emojiSet.provider.GetEmoji(context.Background(), emojiSet.offset)
+				if first != tc.expectedFirst {
+					t.Errorf("最初の絵文字が期待値と異なります: got %v, want %v", first, tc.expectedFirst)
+				}
+			})
+		}
+	})
+
+	t.Run("異常系: DMでカスタム絵文字を指定", func(t *testing.T) {
+		// Arrange
+		handler := newEmojiTestHandler(&mockGuildSettingsStore{})
+		survey := &types.Survey{SurveySettings: types.SurveySettings{EmojiSet: types.EmojiSetCustom}}
+
+		// Act
+		_, err := handler.resolveEmojiSet(context.Background(), nil, survey)
+
+		// Assert
+		if !errors.Is(err, errNoCustomEmojis) {
+			t.Errorf("errNoCustomEmojisが期待されていましたが、%vが返されました", err)
+		}
+	})
+}
+
+func TestOptionEmojiSet_OptionEmojis(t *testing.T) {
+	t.Run("正常系: 数字は1から割り当てる", func(t *testing.T) {
+		// Arrange
+		emojiSet := optionEmojiSet{provider: &mockEmojiProvider{emojis: []string{"0️⃣", "1️⃣", "2️⃣"}}, offset: 1}
+		survey := &types.Survey{Options: []string{"A", "B"}}
+
+		// Act
+		emojis, err := emojiSet.optionEmojis(context.Background(), survey)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if !reflect.DeepEqual(emojis, []string{"1️⃣", "2️⃣"}) {
+			t.Errorf("絵文字が期待値と異なります: got %v", emojis)
+		}
+	})
+
+	t.Run("正常系: コンポーネント方式では絵文字が足りない項目を空にする", func(t *testing.T) {
+		// Arrange
+		emojiSet := optionEmojiSet{provider: &mockEmojiProvider{emojis: []string{"🔴"}}}
+		survey := &types.Survey{
+			Options:        []string{"A", "B"},
+			SurveySettings: types.SurveySettings{VoteMode: types.VoteModeComponent},
+		}
+
+		// Act
+		emojis, err := emojiSet.optionEmojis(context.Background(), survey)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if !reflect.DeepEqual(emojis, []string{"🔴", ""}) {
+			t.Errorf("絵文字が期待値と異なります: got %v", emojis)
+		}
+	})
+
+	t.Run("異常系: リアクション方式で絵文字より多い項目", func(t *testing.T) {
+		// Arrange
+		emojiSet := optionEmojiSet{provider: &mockEmojiProvider{emojis: []string{"0️⃣", "1️⃣"}}, offset: 1}
+		survey := &types.Survey{Options: []string{"A", "B"}}
+
+		// Act
+		_, err := emojiSet.optionEmojis(context.Background(), survey)
+
+		// Assert
+		var tooMany *tooManyOptionsError
+		if !errors.As(err, &tooMany) || tooMany.max != 1 {
+			t.Errorf("tooManyOptionsErrorが期待されていましたが、%vが返されました", err)
+		}
+	})
+}
+
+func TestOptionEmojiSet_MaxOptions(t *testing.T) {
+	t.Run("正常系: リアクション方式はDiscordのリアクション上限までに制限する", func(t *testing.T) {
+		// Arrange
+		emojis := make([]string, 30)
+		emojiSet := optionEmojiSet{provider: &mockEmojiProvider{emojis: emojis}}
+
+		// Act
+		reactionMax := emojiSet.maxOptions(types.VoteModeReaction)
+		componentMax := emojiSet.maxOptions(types.VoteModeComponent)
+
+		// Assert
+		if reactionMax != maxReactions {
+			t.Errorf("リアクション方式の上限が期待値と異なります: got %v, want %v", reactionMax, maxReactions)
+		}
+		if componentMax != maxSelectOptions {
+			t.Errorf("コンポーネント方式の上限が期待値と異なります: got %v, want %v", componentMax, maxSelectOptions)
+		}
+	})
+}
+
+func TestSurveyErrorMessage(t *testing.T) {
+	t.Run("正常系: 作成者に伝えるエラーを説明する", func(t *testing.T) {
+		testCases := []struct {
+			name     string
+			err      error
+			expected string
+		}{
+			{"項目が多すぎる", &tooManyOptionsError{count: 12, max: 10}, "回答項目は10個まで記入できます"},
+			{"カスタム絵文字がない", errNoCustomEmojis, "カスタム絵文字がありません"},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				// Act
+				message, ok := surveyErrorMessage(tc.err)
+
+				// Assert
+				if !ok || !strings.Contains(message, tc.expected) {
+					t.Errorf("メッセージが期待値と異なります: got %v, want %v", message, tc.expected)
+				}
+			})
+		}
+	})
+
+	t.Run("正常系: それ以外のエラーは説明しない", func(t *testing.T) {
+		// Act
+		_, ok := surveyErrorMessage(errors.New("network error"))
+
+		// Assert
+		if ok {
+			t.Error("説明しないエラーが説明されています")
+		}
+	})
+}
+
+func TestParseEmojiSet(t *testing.T) {
+	t.Run("正常系: 絵文字セット名を読み取る", func(t *testing.T) {
+		testCases := []struct {
+			name     string
+			expected types.EmojiSet
+			ok       bool
+		}{
+			{"number", types.EmojiSetNumber, true},
+			{"Alphabet", types.EmojiSetAlphabet, true},
+			{"custom", types.EmojiSetCustom, true},
+			{"hearts", "", false},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				// Act
+				result, ok := parseEmojiSet(tc.name)
+
+				// Assert
+				if result != tc.expected || ok != tc.ok {
+					t.Errorf("結果が期待値と異なります: got %v %v, want %v %v", result, ok, tc.expected, tc.ok)
+				}
+			})
+		}
+	})
+}
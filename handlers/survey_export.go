@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/Logta/SurveyBot/utils"
+	"github.com/bwmarrin/discordgo"
+)
+
+// exportFormat is the file format produced by !export
+type exportFormat string
+
+const (
+	exportCSV  exportFormat = "csv"
+	exportJSON exportFormat = "json"
+)
+
+// handleExport uploads a survey's results, e.g. "!export", "!export json" or
+// "!export 123456789 csv". The survey is chosen like !edit and CSV is the default.
+func (h *surveyHandler) handleExport(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
+	format := exportCSV
+	messageID := ""
+	for _, arg := range strings.Fields(m.Content)[1:] {
+		switch f := exportFormat(strings.ToLower(arg)); f {
+		case exportCSV, exportJSON:
+			format = f
+		default:
+			messageID = arg
+		}
+	}
+
+	survey, err := h.targetSurvey(ctx, m, messageID)
+	if errors.Is(err, types.ErrSurveyNotFound) {
+		_, err := s.ChannelMessageSend(m.ChannelID, "出力できるアンケートが見つかりませんでした")
+		return err
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to find survey", err)
+		return err
+	}
+
+	// Component and ranked votes are hidden from other members, so only those who may
+	// manage the survey can download them
+	allowed, err := h.canManageSurvey(s, m, survey)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get member permissions", err)
+		return err
+	}
+	if !allowed {
+		_, err := s.ChannelMessageSend(m.ChannelID, "集計結果を出力できるのは作成者か「メッセージの管理」権限を持つメンバーだけです")
+		return err
+	}
+
+	votes, err := exportVotes(s, survey)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to fetch survey message", err, types.Field{Key: "message_id", Value: survey.MessageID})
+		_, err := s.ChannelMessageSend(m.ChannelID, "アンケートが見つかりませんでした")
+		return err
+	}
+	options, totalVotes := h.exportCounts(ctx, s, survey, votes)
+
+	var names map[string]string
+	if !survey.Anonymous {
+		names = h.displayNames(ctx, s, survey, votes)
+	}
+	export := buildSurveyExport(survey, votes, options, totalVotes, names, time.Now())
+
+	files, err := exportFiles(export, format)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to export survey", err, types.Field{Key: "message_id", Value: survey.MessageID})
+		return err
+	}
+
+	_, err = s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Content: survey.Title + " の集計結果",
+		Files:   files,
+	})
+	return err
+}
+
+// exportVotes returns the votes to export: the members who react with an option emoji on
+// reaction surveys, which may have changed while the bot was offline, and the recorded
+// votes otherwise
+func exportVotes(s *discordgo.Session, survey *types.Survey) ([]types.Vote, error) {
+	if survey.VoteMode == types.VoteModeReaction {
+		return reactionVotes(s, survey)
+	}
+	return survey.Votes, nil
+}
+
+// exportCounts tallies votes like !close would, without closing the survey, so that the
+// counts agree with the exported rows
+func (h *surveyHandler) exportCounts(ctx context.Context, s *discordgo.Session, survey *types.Survey, votes []types.Vote) ([]types.OptionResult, int) {
+	if survey.VoteMode == types.VoteModeRanked {
+		result := h.tallyRanked(ctx, s, survey)
+		return result.Options, result.TotalVotes
+	}
+
+	if survey.EligibleRoleID != "" || hasQuorum(survey) {
+		result := h.tallyVoterVotes(ctx, s, survey, votes)
+		return result.Options, result.TotalVotes
+	}
+
+	options := surveyOptionResults(survey)
+	for i, count := range utils.CountVotes(len(options), votes) {
+		options[i].Count = count
+	}
+	result := utils.TallyResults(survey.Title, options)
+	return result.Options, result.TotalVotes
+}
+
+// displayNames looks up the server nickname, or else the user name, of every voter.
+// Voters who cannot be found are left out.
+func (h *surveyHandler) displayNames(ctx context.Context, s *discordgo.Session, survey *types.Survey, votes []types.Vote) map[string]string {
+	names := make(map[string]string, len(votes))
+	for _, vote := range votes {
+		if _, done := names[vote.UserID]; done {
+			continue
+		}
+
+		if survey.GuildID != "" {
+			member, err := s.State.Member(survey.GuildID, vote.UserID)
+			if err != nil {
+				member, err = s.GuildMember(survey.GuildID, vote.UserID)
+			}
+			if err == nil {
+				names[vote.UserID] = member.DisplayName()
+				continue
+			}
+		}
+
+		user, err := s.User(vote.UserID)
+		if err != nil {
+			h.logger.Debug(ctx, "Failed to look up voter", types.Field{Key: "user_id", Value: vote.UserID})
+			names[vote.UserID] = ""
+			continue
+		}
+		names[vote.UserID] = user.DisplayName()
+	}
+	return names
+}
+
+// buildSurveyExport collects the survey's counts and, unless it is anonymous, a row per
+// option chosen in votes
+func buildSurveyExport(survey *types.Survey, votes []types.Vote, options []types.OptionResult, totalVotes int, names map[string]string, now time.Time) *types.SurveyExport {
+	export := &types.SurveyExport{
+		MessageID:  survey.MessageID,
+		Title:      survey.Title,
+		VoteMode:   survey.VoteMode,
+		Anonymous:  survey.Anonymous,
+		Closed:     survey.Closed,
+		TotalVotes: totalVotes,
+		Options:    make([]types.ExportOption, len(options)),
+		ExportedAt: now,
+	}
+	for i, option := range options {
+		export.Options[i] = types.ExportOption{
+			Emoji:      option.Emoji,
+			Label:      option.Label,
+			Count:      option.Count,
+			Percentage: option.Percentage,
+		}
+	}
+
+	// Anonymous votes are keyed by hashes that mean nothing outside the bot
+	if survey.Anonymous {
+		return export
+	}
+
+	for _, vote := range votes {
+		for n, choice := range vote.Choices {
+			if choice < 0 || choice >= len(survey.Options) {
+				continue
+			}
+			row := types.ExportVote{
+				UserID:      vote.UserID,
+				DisplayName: names[vote.UserID],
+				Option:      survey.Options[choice],
+				VotedAt:     vote.VotedAt,
+			}
+			if survey.VoteMode == types.VoteModeRanked {
+				row.Rank = n + 1
+			}
+			export.Votes = append(export.Votes, row)
+		}
+	}
+	return export
+}
+
+// exportFiles encodes export as attachments: one JSON file, or CSV files for the counts
+// and, unless the survey is anonymous, the votes
+func exportFiles(export *types.SurveyExport, format exportFormat) ([]*discordgo.File, error) {
+	name := "survey-" + export.MessageID
+
+	if format == exportJSON {
+		data, err := utils.ExportJSON(export)
+		if err != nil {
+			return nil, err
+		}
+		return []*discordgo.File{
+			{Name: name + ".json", ContentType: "application/json", Reader: bytes.NewReader(data)},
+		}, nil
+	}
+
+	results, err := utils.ExportResultsCSV(export)
+	if err != nil {
+		return nil, err
+	}
+	files := []*discordgo.File{
+		{Name: name + "-results.csv", ContentType: "text/csv", Reader: bytes.NewReader(results)},
+	}
+
+	if export.Anonymous {
+		return files, nil
+	}
+
+	votes, err := utils.ExportVotesCSV(export)
+	if err != nil {
+		return nil, err
+	}
+	return append(files, &discordgo.File{Name: name + "-votes.csv", ContentType: "text/csv", Reader: bytes.NewReader(votes)}), nil
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+func newExportSurvey() *types.Survey {
+	return &types.Survey{
+		MessageID: "message-1",
+		Title:     "好きな言語",
+		Options:   []string{"Go", "Rust"},
+		Votes: []types.Vote{
+			{UserID: "user-1", Choices: []int{1, 0}},
+			{UserID: "user-2", Choices: []int{0}},
+		},
+		SurveySettings: types.SurveySettings{VoteMode: types.VoteModeComponent},
+	}
+}
+
+func TestBuildSurveyExport(t *testing.T) {
+	options := []types.OptionResult{{Emoji: "1️⃣", Label: "Go", Count: 2}, {Emoji: "2️⃣", Label: "Rust", Count: 1}}
+	names := map[string]string{"user-1": "太郎", "user-2": "花子"}
+	now := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("正常系: 選択した項目ごとに投票を出力する", func(t *testing.T) {
+		// Arrange
+		survey := newExportSurvey()
+
+		// Act
+		export := buildSurveyExport(survey, survey.Votes, options, 3, names, now)
+
+		// Assert
+		expected := []types.ExportVote{
+			{UserID: "user-1", DisplayName: "太郎", Option: "Rust"},
+			{UserID: "user-1", DisplayName: "太郎", Option: "Go"},
+			{UserID: "user-2", DisplayName: "花子", Option: "Go"},
+		}
+		if !reflect.DeepEqual(export.Votes, expected) {
+			t.Errorf("投票が期待値と異なります: got %+v, want %+v", export.Votes, expected)
+		}
+		if export.TotalVotes != 3 || export.Options[0].Count != 2 || !export.ExportedAt.Equal(now) {
+			t.Errorf("集計が期待値と異なります: got %+v", export)
+		}
+	})
+
+	t.Run("正常系: 順位付けのアンケートは希望順位を出力する", func(t *testing.T) {
+		// Arrange
+		survey := newExportSurvey()
+		survey.VoteMode = types.VoteModeRanked
+
+		// Act
+		export := buildSurveyExport(survey, survey.Votes, options, 2, names, now)
+
+		// Assert
+		if export.Votes[0].Rank != 1 || export.Votes[1].Rank != 2 || export.Votes[2].Rank != 1 {
+			t.Errorf("順位が期待値と異なります: got %+v", export.Votes)
+		}
+	})
+
+	t.Run("正常系: 匿名アンケートは投票を出力しない", func(t *testing.T) {
+		// Arrange
+		survey := newExportSurvey()
+		survey.Anonymous = true
+
+		// Act
+		export := buildSurveyExport(survey, survey.Votes, options, 3, nil, now)
+
+		// Assert
+		if len(export.Votes) != 0 || !export.Anonymous {
+			t.Errorf("匿名アンケートの投票が出力されています: got %+v", export.Votes)
+		}
+	})
+}
+
+func TestSurveyHandler_ExportReactionSurvey(t *testing.T) {
+	t.Run("正常系: リアクションのアンケートは得票数と投票を同じリアクションから出力する", func(t *testing.T) {
+		// Arrange
+		survey := newExportSurvey()
+		survey.VoteMode = types.VoteModeReaction
+		survey.Emojis = []string{"1️⃣", "2️⃣"}
+		// Recorded while the bot was online; user-3 reacted to both options while it was offline
+		survey.Votes = []types.Vote{{UserID: "user-1", Choices: []int{0}}}
+		handler := &surveyHandler{logger: &mockLogger{}}
+		s := newRESTSession(http.StatusOK, `[{"id": "user-3"}, {"id": "bot", "bot": true}]`)
+
+		// Act
+		votes, err := exportVotes(s, survey)
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		options, total := handler.exportCounts(context.Background(), s, survey, votes)
+		export := buildSurveyExport(survey, votes, options, total, nil, time.Now())
+
+		// Assert
+		expected := []types.ExportVote{
+			{UserID: "user-3", Option: "Go"},
+			{UserID: "user-3", Option: "Rust"},
+		}
+		if !reflect.DeepEqual(export.Votes, expected) {
+			t.Errorf("投票が期待値と異なります: got %+v, want %+v", export.Votes, expected)
+		}
+		if export.Options[0].Count != 1 || export.Options[1].Count != 1 || export.TotalVotes != 2 {
+			t.Errorf("得票数が投票と一致しません: got %+v", export.Options)
+		}
+	})
+}
+
+func TestExportFiles(t *testing.T) {
+	t.Run("正常系: CSVは得票数と投票の2ファイルになる", func(t *testing.T) {
+		// Arrange
+		export := &types.SurveyExport{MessageID: "message-1"}
+
+		// Act
+		files, err := exportFiles(export, exportCSV)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if len(files) != 2 || files[0].Name != "survey-message-1-results.csv" || files[1].Name != "survey-message-1-votes.csv" {
+			t.Errorf("ファイルが期待値と異なります: got %+v", files)
+		}
+	})
+
+	t.Run("正常系: 匿名アンケートのCSVは得票数だけになる", func(t *testing.T) {
+		// Arrange
+		export := &types.SurveyExport{MessageID: "message-1", Anonymous: true}
+
+		// Act
+		files, err := exportFiles(export, exportCSV)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if len(files) != 1 {
+			t.Errorf("ファイル数が期待値と異なります: got %v, want %v", len(files), 1)
+		}
+	})
+
+	t.Run("正常系: JSONは1ファイルになる", func(t *testing.T) {
+		// Arrange
+		export := &types.SurveyExport{MessageID: "message-1", Title: "好きな言語"}
+
+		// Act
+		files, err := exportFiles(export, exportJSON)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if len(files) != 1 || files[0].Name != "survey-message-1.json" {
+			t.Fatalf("ファイルが期待値と異なります: got %+v", files)
+		}
+		data, _ := io.ReadAll(files[0].Reader)
+		if len(data) == 0 {
+			t.Errorf("ファイルが空です")
+		}
+	})
+}
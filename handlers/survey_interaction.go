@@ -0,0 +1,392 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/Logta/SurveyBot/utils"
+	"github.com/bwmarrin/discordgo"
+)
+
+// surveySlashOptions is the number of option fields offered by /survey quick
+const surveySlashOptions = 10
+
+const (
+	surveyCreateCommand = "create"
+	surveyQuickCommand  = "quick"
+
+	// surveyModalPrefix starts the custom ID of the /survey create form, followed by the
+	// settings chosen with the command, as the form's answers carry nothing else
+	surveyModalPrefix = "survey_modal:"
+	modalTitleID      = "title"
+	modalOptionsID    = "options"
+)
+
+func (h *surveyHandler) ApplicationCommands() []*discordgo.ApplicationCommand {
+	quickOptions := []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "title",
+			Description: "アンケートのタイトル",
+			Required:    true,
+		},
+	}
+	// Discord requires the required options to come before every optional one
+	for i := 1; i <= surveySlashOptions; i++ {
+		quickOptions = append(quickOptions, &discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        fmt.Sprintf("option%d", i),
+			Description: fmt.Sprintf("%d番目の回答項目", i),
+			Required:    i <= 2,
+		})
+	}
+
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:        "survey",
+			Description: "アンケートを作成する",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        surveyCreateCommand,
+					Description: "入力フォームでタイトルと回答項目を入力してアンケートを作成する",
+					Options:     surveySettingOptions(),
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        surveyQuickCommand,
+					Description: "タイトルと回答項目をオプションで指定してアンケートを作成する",
+					Options:     append(quickOptions, surveySettingOptions()...),
+				},
+			},
+		},
+	}
+}
+
+// surveySettingOptions returns the options both /survey subcommands take besides the contents
+func surveySettingOptions() []*discordgo.ApplicationCommandOption {
+	minChoices := 1.0
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "mode",
+			Description: "投票方式",
+			Choices: []*discordgo.ApplicationCommandOptionChoice{
+				{Name: "リアクション", Value: string(types.VoteModeReaction)},
+				{Name: "ボタン・セレクトメニュー", Value: string(types.VoteModeComponent)},
+				{Name: "順位付け", Value: string(types.VoteModeRanked)},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "max",
+			Description: "1人が選択できる回答項目の数",
+			MinValue:    &minChoices,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "deadline",
+			Description: "締め切り（例: 2h, 3d, 2030-01-02T15:04）",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionBoolean,
+			Name:        "anonymous",
+			Description: "誰がどれに投票したかを記録しない匿名アンケートにする",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "emoji",
+			Description: "回答項目の絵文字",
+			Choices: []*discordgo.ApplicationCommandOptionChoice{
+				{Name: "数字", Value: string(types.EmojiSetNumber)},
+				{Name: "アルファベット", Value: string(types.EmojiSetAlphabet)},
+				{Name: "色付きの丸", Value: string(types.EmojiSetCircle)},
+				{Name: "サーバーのカスタム絵文字", Value: string(types.EmojiSetCustom)},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionRole,
+			Name:        "role",
+			Description: "投票できるメンバーのロール",
+		},
+		{
+			Type:         discordgo.ApplicationCommandOptionChannel,
+			Name:         "channel",
+			Description:  "アンケートを投稿するチャンネル（省略時はこのチャンネル）",
+			ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+		},
+	}
+}
+
+func (h *surveyHandler) CanHandleInteraction(i *discordgo.InteractionCreate) bool {
+	return isApplicationCommand(i, "survey") || isSurveyModal(i) || isVoteComponent(i) || isRankComponent(i) || isDraftComponent(i)
+}
+
+func (h *surveyHandler) HandleInteraction(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	if isVoteComponent(i) {
+		return h.handleVoteInteraction(ctx, s, i)
+	}
+	if isRankComponent(i) {
+		return h.handleRankInteraction(ctx, s, i)
+	}
+	if isDraftComponent(i) {
+		return h.handleDraftInteraction(ctx, s, i)
+	}
+	if isSurveyModal(i) {
+		return h.handleSurveyModal(ctx, s, i)
+	}
+	if subcommandName(i) == surveyCreateCommand {
+		return h.openSurveyModal(s, i)
+	}
+	return h.handleSurveyCommand(ctx, s, i)
+}
+
+// isSurveyModal reports whether i is the submitted /survey create form
+func isSurveyModal(i *discordgo.InteractionCreate) bool {
+	return i.Type == discordgo.InteractionModalSubmit && strings.HasPrefix(i.ModalSubmitData().CustomID, surveyModalPrefix)
+}
+
+const invalidDeadlineMessage = "締め切りは 2h のような期間か、2030-01-02T15:04 のような未来の日時で指定してください"
+
+// slashSurveySettings reads the settings chosen with a /survey subcommand
+func slashSurveySettings(i *discordgo.InteractionCreate, now time.Time) (types.SurveySettings, error) {
+	anonymous := boolOption(i, "anonymous")
+	mode := types.VoteMode(stringOption(i, "mode"))
+	if mode == "" {
+		mode = types.VoteModeReaction
+	}
+	if anonymous && mode == types.VoteModeReaction {
+		mode = types.VoteModeComponent
+	}
+
+	var deadline time.Time
+	if value := strings.TrimSpace(stringOption(i, "deadline")); value != "" {
+		parsed, err := parseDeadline(value, now)
+		if err != nil {
+			return types.SurveySettings{}, err
+		}
+		deadline = parsed
+	}
+
+	return types.SurveySettings{
+		VoteMode:       mode,
+		MaxChoices:     intOption(i, "max"),
+		Deadline:       deadline,
+		Anonymous:      anonymous,
+		EmojiSet:       types.EmojiSet(stringOption(i, "emoji")),
+		EligibleRoleID: roleOption(i, "role"),
+	}, nil
+}
+
+func (h *surveyHandler) handleSurveyCommand(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	settings, err := slashSurveySettings(i, time.Now())
+	if err != nil {
+		return respondEphemeral(s, i, invalidDeadlineMessage)
+	}
+
+	var options []string
+	for n := 1; n <= surveySlashOptions; n++ {
+		if option := strings.TrimSpace(stringOption(i, fmt.Sprintf("option%d", n))); option != "" {
+			options = append(options, option)
+		}
+	}
+
+	return h.publishSlashSurvey(ctx, s, i, stringOption(i, "title"), options, settings, channelOption(i, "channel"))
+}
+
+// openSurveyModal answers /survey create with a form for the title and the options
+func (h *surveyHandler) openSurveyModal(s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	settings, err := slashSurveySettings(i, time.Now())
+	if err != nil {
+		return respondEphemeral(s, i, invalidDeadlineMessage)
+	}
+
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: surveyModalPrefix + encodeModalSettings(settings, channelOption(i, "channel")),
+			Title:    "アンケートを作成",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:  modalTitleID,
+						Label:     "タイトル",
+						Style:     discordgo.TextInputShort,
+						Required:  true,
+						MaxLength: 256,
+					},
+				}},
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:    modalOptionsID,
+						Label:       "回答項目（1行に1つ）",
+						Style:       discordgo.TextInputParagraph,
+						Placeholder: "Go, with generics\nTypeScript\nRust",
+						Required:    true,
+					},
+				}},
+			},
+		},
+	})
+}
+
+// handleSurveyModal publishes the survey entered in the /survey create form
+func (h *surveyHandler) handleSurveyModal(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	data := i.ModalSubmitData()
+	settings, channelID, err := decodeModalSettings(strings.TrimPrefix(data.CustomID, surveyModalPrefix))
+	if err != nil {
+		return respondEphemeral(s, i, "無効な入力フォームです。もう一度 /survey create を実行してください")
+	}
+	if !settings.Deadline.IsZero() && !settings.Deadline.After(time.Now()) {
+		return respondEphemeral(s, i, "入力中に締め切りを過ぎました。もう一度 /survey create を実行してください")
+	}
+
+	options, err := utils.ParseLines(modalValue(data, modalOptionsID))
+	if errors.Is(err, utils.ErrUnclosedQuote) {
+		return respondEphemeral(s, i, unclosedQuoteMessage)
+	}
+	if err != nil {
+		return err
+	}
+	if len(options) < 2 {
+		return respondEphemeral(s, i, "回答項目は1行に1つずつ、2つ以上入力してください")
+	}
+
+	return h.publishSlashSurvey(ctx, s, i, strings.TrimSpace(modalValue(data, modalTitleID)), options, settings, channelID)
+}
+
+// publishSlashSurvey posts the survey created by a slash command or its form, to channelID
+// when one was chosen. Creating it can take longer than Discord waits for a reply, so the
+// reply is deferred.
+func (h *surveyHandler) publishSlashSurvey(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, title string, options []string, settings types.SurveySettings, channelID string) error {
+	if err := deferEphemeral(s, i); err != nil {
+		return err
+	}
+
+	survey := &types.Survey{
+		ChannelID:      i.ChannelID,
+		GuildID:        i.GuildID,
+		AuthorID:       interactionUser(i).ID,
+		Title:          title,
+		Options:        options,
+		SurveySettings: settings,
+	}
+	var err error
+	if channelID != "" && channelID != i.ChannelID {
+		survey.ChannelID = channelID
+		err = h.checkTargetChannel(s, survey, survey.AuthorID)
+	}
+	// The option count is checked against the emoji set in use while the survey is created
+	if err == nil {
+		err = h.createSurveyEmbed(ctx, s, survey)
+	}
+	if err != nil {
+		if message, ok := surveyErrorMessage(err); ok {
+			return editResponse(s, i, message)
+		}
+		h.logger.Error(ctx, "Failed to create survey", err)
+		if editErr := editResponse(s, i, "アンケートの作成に失敗しました"); editErr != nil {
+			return editErr
+		}
+		return err
+	}
+
+	if survey.ChannelID != i.ChannelID {
+		return editResponse(s, i, "アンケートを <#"+survey.ChannelID+"> に作成しました")
+	}
+	return editResponse(s, i, "アンケートを作成しました")
+}
+
+// encodeModalSettings packs settings and the chosen channel into the form's custom ID,
+// within Discord's 100 characters: the deadline is kept as Unix seconds, IDs in base 36,
+// and unset settings are left out
+func encodeModalSettings(settings types.SurveySettings, channelID string) string {
+	values := url.Values{}
+	values.Set("m", string(settings.VoteMode))
+	if settings.MaxChoices > 0 {
+		values.Set("x", strconv.Itoa(settings.MaxChoices))
+	}
+	if !settings.Deadline.IsZero() {
+		values.Set("d", strconv.FormatInt(settings.Deadline.Unix(), 10))
+	}
+	if settings.Anonymous {
+		values.Set("a", "1")
+	}
+	if settings.EmojiSet != "" {
+		values.Set("e", string(settings.EmojiSet))
+	}
+	if settings.EligibleRoleID != "" {
+		values.Set("r", encodeSnowflake(settings.EligibleRoleID))
+	}
+	if channelID != "" {
+		values.Set("c", encodeSnowflake(channelID))
+	}
+	return values.Encode()
+}
+
+// encodeSnowflake shortens a Discord ID to base 36. IDs are always numbers, so anything
+// else is kept as it is and fails to decode.
+func encodeSnowflake(id string) string {
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return id
+	}
+	return strconv.FormatUint(n, 36)
+}
+
+// decodeSnowflake reads back an ID shortened by encodeSnowflake; it is "" when unset
+func decodeSnowflake(encoded string) (string, error) {
+	if encoded == "" {
+		return "", nil
+	}
+	n, err := strconv.ParseUint(encoded, 36, 64)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(n, 10), nil
+}
+
+// decodeModalSettings reads back the settings packed by encodeModalSettings
+func decodeModalSettings(encoded string) (types.SurveySettings, string, error) {
+	values, err := url.ParseQuery(encoded)
+	if err != nil {
+		return types.SurveySettings{}, "", err
+	}
+
+	settings := types.SurveySettings{
+		VoteMode:  types.VoteMode(values.Get("m")),
+		Anonymous: values.Get("a") == "1",
+		EmojiSet:  types.EmojiSet(values.Get("e")),
+	}
+	switch settings.VoteMode {
+	case types.VoteModeReaction, types.VoteModeComponent, types.VoteModeRanked:
+	default:
+		return types.SurveySettings{}, "", fmt.Errorf("unknown vote mode %q", settings.VoteMode)
+	}
+	if value := values.Get("x"); value != "" {
+		if settings.MaxChoices, err = strconv.Atoi(value); err != nil {
+			return types.SurveySettings{}, "", err
+		}
+	}
+	if value := values.Get("d"); value != "" {
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return types.SurveySettings{}, "", err
+		}
+		settings.Deadline = time.Unix(seconds, 0)
+	}
+	if settings.EligibleRoleID, err = decodeSnowflake(values.Get("r")); err != nil {
+		return types.SurveySettings{}, "", err
+	}
+	channelID, err := decodeSnowflake(values.Get("c"))
+	if err != nil {
+		return types.SurveySettings{}, "", err
+	}
+	return settings, channelID, nil
+}
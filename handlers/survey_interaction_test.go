@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+func TestModalSettings(t *testing.T) {
+	t.Run("正常系: 入力フォームのカスタムIDに設定を詰めて読み戻す", func(t *testing.T) {
+		testCases := []struct {
+			name      string
+			settings  types.SurveySettings
+			channelID string
+		}{
+			{"既定の設定", types.SurveySettings{VoteMode: types.VoteModeReaction}, ""},
+			{
+				"すべての設定",
+				types.SurveySettings{
+					VoteMode:       types.VoteModeComponent,
+					MaxChoices:     25,
+					Deadline:       time.Unix(1893456000, 0),
+					Anonymous:      true,
+					EmojiSet:       types.EmojiSetAlphabet,
+					EligibleRoleID: "1234567890123456789",
+				},
+				"9876543210987654321",
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				// Act
+				encoded := encodeModalSettings(tc.settings, tc.channelID)
+				settings, channelID, err := decodeModalSettings(encoded)
+
+				// Assert
+				if err != nil {
+					t.Fatalf("期待していないエラーが発生: %v", err)
+				}
+				if len(surveyModalPrefix+encoded) > 100 {
+					t.Errorf("カスタムIDがDiscordの上限を超えています: %v", len(surveyModalPrefix+encoded))
+				}
+				if settings != tc.settings {
+					t.Errorf("設定が期待値と異なります: got %+v, want %+v", settings, tc.settings)
+				}
+				if channelID != tc.channelID {
+					t.Errorf("投稿先のチャンネルが期待値と異なります: got %q, want %q", channelID, tc.channelID)
+				}
+			})
+		}
+	})
+
+	t.Run("異常系: 不正なカスタムID", func(t *testing.T) {
+		for _, encoded := range []string{"", "m=poll", "m=reaction&x=abc", "m=reaction&d=soon", "m=reaction&c=<#1>", "%zz"} {
+			// Act
+			_, _, err := decodeModalSettings(encoded)
+
+			// Assert
+			if err == nil {
+				t.Errorf("エラーが期待されていましたが、nilが返されました: %q", encoded)
+			}
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/Logta/SurveyBot/utils"
+	"github.com/bwmarrin/discordgo"
+)
+
+const pollUsage = "!poll タイトル の後に改行を挟んで回答項目を1行ずつ記入してください\n" +
+	"--multi で複数選択、--anon で匿名、--until 2h で期限を指定できます"
+
+// pollFlagsUsage lists the flags of !poll, for replies to flags that cannot be read
+const pollFlagsUsage = "使用できないオプションが指定されています。使用できるのは次のオプションです\n" +
+	"--multi --anon --until 期間"
+
+// parsePoll reads a survey written in a single message: the title and flags on the first
+// line and one option per following line, e.g.
+//
+//	!poll お昼はどこにする？ --until 30m
+//	カレー
+//	そば
+//
+// Polls take a single choice unless --multi is given; any other --word is an error, as with !survey.
+func parsePoll(content string, now time.Time) (string, []string, types.SurveySettings, error) {
+	settings := types.SurveySettings{VoteMode: types.VoteModeReaction, MaxChoices: 1}
+	header, body, _ := strings.Cut(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	args := strings.Fields(header)[1:]
+	var words []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--multi":
+			settings.MaxChoices = 0
+		case arg == "--anon":
+			settings.Anonymous = true
+		case arg == "--until" || strings.HasPrefix(arg, "--until="):
+			value := strings.TrimPrefix(arg, "--until=")
+			if arg == "--until" {
+				if i+1 >= len(args) {
+					return "", nil, settings, errInvalidDeadline
+				}
+				i++
+				value = args[i]
+			}
+			deadline, err := parseDeadline(value, now)
+			if err != nil {
+				return "", nil, settings, err
+			}
+			settings.Deadline = deadline
+		case strings.HasPrefix(arg, "--") && len(arg) > len("--"):
+			return "", nil, settings, fmt.Errorf("%w: %q", errUnknownFlag, arg)
+		default:
+			words = append(words, arg)
+		}
+	}
+
+	options, err := utils.ParseLines(body)
+	if err != nil {
+		return "", nil, settings, err
+	}
+	return strings.Join(words, " "), options, settings, nil
+}
+
+// handlePoll publishes a survey written in a single message, without a draft
+func (h *surveyHandler) handlePoll(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
+	title, options, settings, err := parsePoll(m.Content, time.Now())
+	switch {
+	case errors.Is(err, utils.ErrUnclosedQuote):
+		_, err := s.ChannelMessageSend(m.ChannelID, unclosedQuoteMessage)
+		return err
+	case errors.Is(err, errInvalidDeadline):
+		_, err := s.ChannelMessageSend(m.ChannelID, "--until には 2h のような期間か、2030-01-02T15:04 のような未来の日時を指定してください")
+		return err
+	case errors.Is(err, errUnknownFlag):
+		_, err := s.ChannelMessageSend(m.ChannelID, pollFlagsUsage)
+		return err
+	case err != nil:
+		return err
+	}
+
+	if title == "" || len(options) == 0 {
+		_, err := s.ChannelMessageSend(m.ChannelID, pollUsage)
+		return err
+	}
+
+	return h.publishSurvey(ctx, s, m, &types.Survey{
+		ChannelID:      m.ChannelID,
+		GuildID:        m.GuildID,
+		AuthorID:       m.Author.ID,
+		Title:          title,
+		Options:        options,
+		SurveySettings: settings,
+	})
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/Logta/SurveyBot/utils"
+)
+
+func TestParsePoll(t *testing.T) {
+	now := time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	t.Run("正常系: 1つのメッセージからタイトルと回答項目と設定を読み取る", func(t *testing.T) {
+		testCases := []struct {
+			name            string
+			content         string
+			expectedTitle   string
+			expectedOptions []string
+			expected        types.SurveySettings
+		}{
+			{
+				"フラグなしは単一選択",
+				"!poll お昼は どこにする？\nカレー\n東京 駅の そば",
+				"お昼は どこにする？",
+				[]string{"カレー", "東京 駅の そば"},
+				types.SurveySettings{VoteMode: types.VoteModeReaction, MaxChoices: 1},
+			},
+			{
+				"複数選択と匿名と期限",
+				"!poll --multi 好きな言語は？ --anon --until 2h\r\nGo, with generics\r\nRust",
+				"好きな言語は？",
+				[]string{"Go, with generics", "Rust"},
+				types.SurveySettings{VoteMode: types.VoteModeReaction, Anonymous: true, Deadline: now.Add(2 * time.Hour)},
+			},
+			{
+				"イコール区切りの期限",
+				"!poll 出欠 --until=1d\n出席\n欠席",
+				"出欠",
+				[]string{"出席", "欠席"},
+				types.SurveySettings{VoteMode: types.VoteModeReaction, MaxChoices: 1, Deadline: now.AddDate(0, 0, 1)},
+			},
+			{
+				"回答項目なし",
+				"!poll タイトルだけ",
+				"タイトルだけ",
+				nil,
+				types.SurveySettings{VoteMode: types.VoteModeReaction, MaxChoices: 1},
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				// Act
+				title, options, settings, err := parsePoll(tc.content, now)
+
+				// Assert
+				if err != nil {
+					t.Fatalf("期待していないエラーが発生: %v", err)
+				}
+				if title != tc.expectedTitle {
+					t.Errorf("タイトルが期待値と異なります: got %q, want %q", title, tc.expectedTitle)
+				}
+				if !reflect.DeepEqual(options, tc.expectedOptions) {
+					t.Errorf("回答項目が期待値と異なります: got %q, want %q", options, tc.expectedOptions)
+				}
+				if settings != tc.expected {
+					t.Errorf("設定が期待値と異なります: got %+v, want %+v", settings, tc.expected)
+				}
+			})
+		}
+	})
+
+	t.Run("正常系: フラグでない「--」はタイトルに残す", func(t *testing.T) {
+		// Act
+		title, _, _, err := parsePoll("!poll A -- B\n出席", now)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if title != "A -- B" {
+			t.Errorf("タイトルが期待値と異なります: got %q", title)
+		}
+	})
+
+	t.Run("異常系: 不正な期限と閉じていない引用符と不明なフラグ", func(t *testing.T) {
+		testCases := []struct {
+			content  string
+			expected error
+		}{
+			{"!poll 出欠 --until\n出席", errInvalidDeadline},
+			{"!poll 出欠 --until yesterday\n出席", errInvalidDeadline},
+			{"!poll 出欠 --until -1h\n出席", errInvalidDeadline},
+			{"!poll 出欠\n\"出席\n欠席", utils.ErrUnclosedQuote},
+			{"!poll 出欠 --mulit\n出席\n欠席", errUnknownFlag},
+			{"!poll --shared 出欠\n出席", errUnknownFlag},
+		}
+
+		for _, tc := range testCases {
+			// Act
+			_, _, _, err := parsePoll(tc.content, now)
+
+			// Assert
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("%vが期待されていましたが、%vが返されました: %q", tc.expected, err, tc.content)
+			}
+		}
+	})
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/bwmarrin/discordgo"
+)
+
+// The preview buttons carry the draft's author after the prefix, or nothing for a shared
+// draft; the guild and channel come from the interaction
+const (
+	draftPublishPrefix = "survey_draft_publish:"
+	draftEditPrefix    = "survey_draft_edit:"
+	draftCancelPrefix  = "survey_draft_cancel:"
+)
+
+const (
+	previewContent      = "アンケートのプレビューです。内容を確認して「公開」を押してください（" + string(types.CmdPublish) + " でも公開できます）"
+	draftExpiredMessage = "アンケートの期限が過ぎています。!cancel で取り消してから作り直してください"
+)
+
+var (
+	// errDraftExpired is returned when a draft's deadline passes before it is published
+	errDraftExpired = errors.New("draft deadline has passed")
+	// errDraftTaken is returned when the draft was published or changed by another event
+	// after it was read
+	errDraftTaken = errors.New("draft was published or changed meanwhile")
+)
+
+// draftSurvey returns the survey a draft would publish, posted by authorID
+func draftSurvey(state *types.SurveyState, authorID string) *types.Survey {
+	channelID := state.ChannelID
+	if state.TargetChannelID != "" {
+		channelID = state.TargetChannelID
+	}
+	return &types.Survey{
+		ChannelID:      channelID,
+		GuildID:        state.GuildID,
+		AuthorID:       authorID,
+		Title:          state.Title,
+		Options:        state.Options,
+		SurveySettings: state.SurveySettings,
+	}
+}
+
+// previewComponents returns the buttons that publish, edit or cancel the draft of authorID
+func previewComponents(authorID string) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "公開", Style: discordgo.SuccessButton, CustomID: draftPublishPrefix + authorID},
+			discordgo.Button{Label: "修正", Style: discordgo.SecondaryButton, CustomID: draftEditPrefix + authorID},
+			discordgo.Button{Label: "キャンセル", Style: discordgo.DangerButton, CustomID: draftCancelPrefix + authorID},
+		}},
+	}
+}
+
+// sendPreview shows the draft as it would be published, with the buttons that confirm it,
+// and remembers the preview so that only its buttons publish the draft
+func (h *surveyHandler) sendPreview(ctx context.Context, s *discordgo.Session, key string, state *types.SurveyState, authorID string) error {
+	embed := createSurveyMessageEmbed(draftSurvey(state, authorID))
+	if embed.Title == "" {
+		embed.Title = "(タイトル未設定)"
+	}
+
+	content := previewContent
+	if state.TargetChannelID != "" {
+		content += "\n投稿先: <#" + state.TargetChannelID + ">"
+	}
+	preview, err := s.ChannelMessageSendComplex(state.ChannelID, &discordgo.MessageSend{
+		Content:    content,
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: previewComponents(state.AuthorID),
+	})
+	if err != nil {
+		return err
+	}
+
+	previous := state.PreviewMessageID
+	state.PreviewMessageID = preview.ID
+	if err := h.stateManager.SetState(ctx, key, state); err != nil {
+		h.logger.Error(ctx, "Failed to update survey state", err)
+		return err
+	}
+
+	if previous != "" {
+		h.closePreview(ctx, s, state.ChannelID, previous, "新しいプレビューに置き換えました")
+	}
+	return nil
+}
+
+// closePreview removes the buttons of a preview once it no longer stands for the draft.
+// The preview may have been deleted, so failures are only logged.
+func (h *surveyHandler) closePreview(ctx context.Context, s *discordgo.Session, channelID, messageID, content string) {
+	components := []discordgo.MessageComponent{}
+	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         messageID,
+		Channel:    channelID,
+		Content:    &content,
+		Components: &components,
+	})
+	if err != nil {
+		h.logger.Debug(ctx, "Failed to close survey preview", types.Field{Key: "message_id", Value: messageID})
+	}
+}
+
+// publishDraft posts the survey written in the draft stored under key and ends the draft.
+// The draft is taken from the state manager before posting, so a command and a button
+// pressed together publish it once; the one that loses gets errDraftTaken.
+func (h *surveyHandler) publishDraft(ctx context.Context, s *discordgo.Session, key string, state *types.SurveyState, authorID string) error {
+	if !state.Deadline.IsZero() && !state.Deadline.After(time.Now()) {
+		return errDraftExpired
+	}
+
+	survey := draftSurvey(state, authorID)
+	if state.TargetChannelID != "" {
+		if err := h.checkTargetChannel(s, survey, authorID); err != nil {
+			return err
+		}
+	}
+
+	taken, err := h.stateManager.CompareAndClearState(ctx, key, state)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to clear survey state", err)
+		return err
+	}
+	if !taken {
+		return errDraftTaken
+	}
+
+	if err := h.createSurveyEmbed(ctx, s, survey); err != nil {
+		// Put the draft back so the author can publish it again
+		if err := h.stateManager.SetState(ctx, key, state); err != nil {
+			h.logger.Error(ctx, "Failed to restore survey state", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// publishedMessage confirms that the draft was published, and where when it went elsewhere
+func publishedMessage(state *types.SurveyState) string {
+	if state.TargetChannelID != "" {
+		return "アンケートを <#" + state.TargetChannelID + "> に公開しました"
+	}
+	return "アンケートを公開しました"
+}
+
+// draftErrorMessage explains why a draft could not be published, or returns false when
+// the author cannot fix the problem
+func draftErrorMessage(err error) (string, bool) {
+	if errors.Is(err, errDraftExpired) {
+		return draftExpiredMessage, true
+	}
+	return surveyErrorMessage(err)
+}
+
+// handlePublish publishes the previewed draft, like its 公開 button
+func (h *surveyHandler) handlePublish(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
+	key, state, err := h.getDraft(ctx, m)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get survey state", err)
+		return err
+	}
+
+	if !state.Active {
+		_, err := s.ChannelMessageSend(m.ChannelID, "作成中のアンケートはありません")
+		return err
+	}
+	if len(state.Options) == 0 {
+		_, err := s.ChannelMessageSend(m.ChannelID, string(types.CmdContent)+" で回答項目を入力してから公開してください")
+		return err
+	}
+
+	if err := h.publishDraft(ctx, s, key, state, m.Author.ID); err != nil {
+		if errors.Is(err, errDraftTaken) {
+			// Whoever took the draft reports the outcome
+			return nil
+		}
+		if message, ok := draftErrorMessage(err); ok {
+			_, err := s.ChannelMessageSend(m.ChannelID, message)
+			return err
+		}
+		return err
+	}
+
+	if state.PreviewMessageID != "" {
+		h.closePreview(ctx, s, state.ChannelID, state.PreviewMessageID, publishedMessage(state))
+	}
+	// The channel it was written in only learns of a survey published elsewhere from this
+	if state.TargetChannelID != "" && state.PreviewMessageID == "" {
+		_, err := s.ChannelMessageSend(m.ChannelID, publishedMessage(state))
+		return err
+	}
+	return nil
+}
+
+// isDraftComponent reports whether i was triggered by a preview's buttons
+func isDraftComponent(i *discordgo.InteractionCreate) bool {
+	if i.Type != discordgo.InteractionMessageComponent {
+		return false
+	}
+	customID := i.MessageComponentData().CustomID
+	return strings.HasPrefix(customID, draftPublishPrefix) ||
+		strings.HasPrefix(customID, draftEditPrefix) ||
+		strings.HasPrefix(customID, draftCancelPrefix)
+}
+
+// handleDraftInteraction answers the 公開, 修正 and キャンセル buttons of a preview
+func (h *surveyHandler) handleDraftInteraction(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	customID := i.MessageComponentData().CustomID
+	var action, authorID string
+	for _, prefix := range []string{draftPublishPrefix, draftEditPrefix, draftCancelPrefix} {
+		if rest, ok := strings.CutPrefix(customID, prefix); ok {
+			action, authorID = prefix, rest
+			break
+		}
+	}
+
+	user := interactionUser(i)
+	if authorID != "" && user.ID != authorID {
+		return respondEphemeral(s, i, "このアンケートを作成しているメンバーだけが操作できます")
+	}
+
+	key := types.DraftKey{GuildID: i.GuildID, ChannelID: i.ChannelID, AuthorID: authorID}.String()
+	state, err := h.stateManager.GetState(ctx, key)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get survey state", err)
+		return err
+	}
+	if !state.Active || state.PreviewMessageID != i.Message.ID {
+		return respondEphemeral(s, i, "このプレビューは古くなっています")
+	}
+
+	switch action {
+	case draftEditPrefix:
+		return respondEphemeral(s, i, "タイトルは "+string(types.CmdTitle)+"、回答項目は "+string(types.CmdContent)+" で入力し直してください。入力し直すと新しいプレビューが表示されます")
+
+	case draftCancelPrefix:
+		if err := h.stateManager.ClearState(ctx, key); err != nil {
+			h.logger.Error(ctx, "Failed to clear survey state", err)
+			return err
+		}
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Content:    "アンケート作成をキャンセルしました",
+				Components: []discordgo.MessageComponent{},
+			},
+		})
+	}
+
+	// Adding an emoji per option can outlast Discord's reply deadline, so the update is deferred
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	}); err != nil {
+		return err
+	}
+
+	content := publishedMessage(state)
+	components := []discordgo.MessageComponent{}
+	if err := h.publishDraft(ctx, s, key, state, user.ID); err != nil {
+		if errors.Is(err, errDraftTaken) {
+			// Whoever took the draft updates the preview
+			return nil
+		}
+		message, ok := draftErrorMessage(err)
+		if !ok {
+			return err
+		}
+		// The buttons stay, so the author can fix the draft and publish again
+		_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &message})
+		return err
+	}
+
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content:    &content,
+		Components: &components,
+	})
+	return err
+}
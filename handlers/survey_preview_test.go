@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestDraftSurvey(t *testing.T) {
+	t.Run("正常系: 下書きの内容と設定から公開するアンケートを作る", func(t *testing.T) {
+		// Arrange
+		state := &types.SurveyState{
+			Active:         true,
+			Title:          "好きな言語",
+			Options:        []string{"Go, with generics", "Rust"},
+			GuildID:        "guild-1",
+			ChannelID:      "channel-1",
+			SurveySettings: types.SurveySettings{VoteMode: types.VoteModeComponent, MaxChoices: 1},
+		}
+
+		// Act
+		survey := draftSurvey(state, "user-1")
+
+		// Assert
+		if survey.Title != state.Title || len(survey.Options) != 2 || survey.Options[0] != "Go, with generics" {
+			t.Errorf("内容が期待値と異なります: %+v", survey)
+		}
+		if survey.GuildID != "guild-1" || survey.ChannelID != "channel-1" || survey.AuthorID != "user-1" {
+			t.Errorf("投稿先が期待値と異なります: %+v", survey)
+		}
+		if survey.SurveySettings != state.SurveySettings {
+			t.Errorf("設定が期待値と異なります: got %+v, want %+v", survey.SurveySettings, state.SurveySettings)
+		}
+	})
+	t.Run("正常系: 投稿先のチャンネルが指定された下書き", func(t *testing.T) {
+		// Arrange
+		state := &types.SurveyState{Active: true, GuildID: "guild-1", ChannelID: "channel-1", TargetChannelID: "channel-2"}
+
+		// Act
+		survey := draftSurvey(state, "user-1")
+
+		// Assert
+		if survey.ChannelID != "channel-2" {
+			t.Errorf("投稿先が期待値と異なります: got %q, want %q", survey.ChannelID, "channel-2")
+		}
+	})
+}
+
+func TestPreviewComponents(t *testing.T) {
+	t.Run("正常系: プレビューのボタンは下書きの作成者を持ちsurveyハンドラーが処理する", func(t *testing.T) {
+		for _, authorID := range []string{"user-1", ""} {
+			// Act
+			row := previewComponents(authorID)[0].(discordgo.ActionsRow)
+
+			// Assert
+			if len(row.Components) != 3 {
+				t.Fatalf("ボタンの数が期待値と異なります: got %v, want %v", len(row.Components), 3)
+			}
+			for _, component := range row.Components {
+				button := component.(discordgo.Button)
+				interaction := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+					Type: discordgo.InteractionMessageComponent,
+					Data: discordgo.MessageComponentInteractionData{CustomID: button.CustomID},
+				}}
+				if !isDraftComponent(interaction) {
+					t.Errorf("プレビューのボタンとして判定されません: %v", button.CustomID)
+				}
+				if len(button.CustomID) > 100 {
+					t.Errorf("カスタムIDがDiscordの上限を超えています: %v", button.CustomID)
+				}
+			}
+		}
+	})
+
+	t.Run("正常系: 投票のボタンはプレビューのボタンではない", func(t *testing.T) {
+		// Arrange
+		interaction := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			Type: discordgo.InteractionMessageComponent,
+			Data: discordgo.MessageComponentInteractionData{CustomID: voteButtonPrefix + "0"},
+		}}
+
+		// Act & Assert
+		if isDraftComponent(interaction) {
+			t.Error("投票のボタンがプレビューのボタンとして判定されています")
+		}
+	})
+}
+
+func TestDraftErrorMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"正常系: 期限切れ", errDraftExpired, true},
+		{"正常系: 回答項目が多すぎる", &tooManyOptionsError{count: 12, max: 10}, true},
+		{"異常系: 作成者には直せないエラー", errors.New("discord unavailable"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			message, ok := draftErrorMessage(tt.err)
+
+			// Assert
+			if ok != tt.expected || (ok && message == "") {
+				t.Errorf("draftErrorMessage() = %q, %v, want ok %v", message, ok, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPublishDraft_Expired(t *testing.T) {
+	t.Run("異常系: 期限が過ぎた下書きは公開しない", func(t *testing.T) {
+		// Arrange
+		stateManager := &mockStateManager{}
+		handler := NewSurveyHandler(stateManager, &mockSurveyRegistry{}, &mockGuildSettingsStore{}, &mockTemplateStore{}, &mockScheduleStore{}, &mockScheduler{}, &mockEmojiProvider{}, &mockLogger{}).(*surveyHandler)
+		state := &types.SurveyState{
+			Active:         true,
+			Title:          "締め切り済み",
+			Options:        []string{"Go", "Rust"},
+			SurveySettings: types.SurveySettings{Deadline: time.Now().Add(-time.Minute)},
+		}
+
+		// Act
+		err := handler.publishDraft(context.Background(), nil, "guild:channel:user", state, "user")
+
+		// Assert
+		if !errors.Is(err, errDraftExpired) {
+			t.Errorf("errDraftExpiredが期待されていましたが、%vが返されました", err)
+		}
+	})
+}
+
+func TestPublishDraft_Once(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		published bool
+	}{
+		{"正常系: 2回目の公開では何も投稿しない", http.StatusOK, `{"id": "msg1", "channel_id": "channel"}`, true},
+		{"異常系: 投稿に失敗したら下書きを戻す", http.StatusInternalServerError, `{"message": "Internal Server Error"}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			key := "guild:channel:user"
+			state := &types.SurveyState{
+				Active:         true,
+				Title:          "ランチ",
+				Options:        []string{"和食", "洋食"},
+				GuildID:        "guild",
+				ChannelID:      "channel",
+				AuthorID:       "user",
+				SurveySettings: types.SurveySettings{VoteMode: types.VoteModeComponent},
+			}
+			stateManager := &mockStateManager{}
+			stateManager.SetState(context.Background(), key, state)
+			registry := &mockSurveyRegistry{}
+			handler := NewSurveyHandler(stateManager, registry, &mockGuildSettingsStore{}, &mockTemplateStore{}, &mockScheduleStore{}, &mockScheduler{}, &mockEmojiProvider{}, &mockLogger{}).(*surveyHandler)
+
+			s := newRESTSession(tt.status, tt.body)
+			transport := s.Client.Transport
+			requests := 0
+			s.Client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				requests++
+				return transport.RoundTrip(r)
+			})
+
+			// Act
+			first := handler.publishDraft(context.Background(), s, key, state, "user")
+			sent := requests
+			second := handler.publishDraft(context.Background(), s, key, state, "user")
+
+			// Assert
+			if tt.published {
+				if first != nil {
+					t.Fatalf("1回目の公開でエラーが発生: %v", first)
+				}
+				if !errors.Is(second, errDraftTaken) {
+					t.Errorf("2回目の公開でerrDraftTakenが期待されていましたが、%vが返されました", second)
+				}
+				if requests != sent {
+					t.Errorf("2回目の公開で%d件のリクエストが送られました", requests-sent)
+				}
+				if len(registry.surveys) != 1 {
+					t.Errorf("登録されたアンケート数が期待値と異なります: got %d, want 1", len(registry.surveys))
+				}
+				if restored, _ := stateManager.GetState(context.Background(), key); restored.Active {
+					t.Error("公開した下書きが残っています")
+				}
+				return
+			}
+
+			if first == nil || second == nil {
+				t.Fatalf("投稿の失敗が返されていません: %v, %v", first, second)
+			}
+			if errors.Is(second, errDraftTaken) {
+				t.Error("戻した下書きを公開し直せません")
+			}
+			if restored, _ := stateManager.GetState(context.Background(), key); !restored.Active {
+				t.Error("下書きが戻されていません")
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/bwmarrin/discordgo"
+)
+
+// earlyCloseNotice is posted with the result of a survey closed before its deadline
+const earlyCloseNotice = "投票数から結果が確定したため、アンケートを締め切りました"
+
+// eligibleCacheTTL is how long the members allowed to vote on an auto-closing survey are
+// reused before the guild's members are listed again
+const eligibleCacheTTL = 5 * time.Minute
+
+// eligibleCache remembers the eligible voters of each auto-closing survey, so that a vote
+// does not page through the guild's members every time. Votes arriving while the members
+// are listed wait for that listing instead of starting their own.
+type eligibleCache struct {
+	mu      sync.Mutex
+	entries map[string]*eligibleEntry
+}
+
+type eligibleEntry struct {
+	ready  chan struct{} // closed once voters and err are set
+	voters map[string]bool
+	err    error
+	listed time.Time
+}
+
+// get returns the eligible voters of the survey posted as messageID, calling list when
+// they are not remembered or were listed longer than eligibleCacheTTL before now
+func (c *eligibleCache) get(messageID string, now time.Time, list func() (map[string]bool, error)) (map[string]bool, error) {
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = make(map[string]*eligibleEntry)
+	}
+	entry, exists := c.entries[messageID]
+	if exists && now.Sub(entry.listed) <= eligibleCacheTTL {
+		c.mu.Unlock()
+		<-entry.ready
+		return entry.voters, entry.err
+	}
+
+	entry = &eligibleEntry{ready: make(chan struct{}), listed: now}
+	c.entries[messageID] = entry
+	c.mu.Unlock()
+
+	entry.voters, entry.err = list()
+	close(entry.ready)
+	if entry.err != nil {
+		// A failed listing is retried by the next vote
+		c.forget(messageID, entry)
+	}
+	return entry.voters, entry.err
+}
+
+// forget drops the voters remembered for messageID, or only entry when it is not nil
+func (c *eligibleCache) forget(messageID string, entry *eligibleEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry == nil || c.entries[messageID] == entry {
+		delete(c.entries, messageID)
+	}
+}
+
+// parseQuorum reads a --quorum value: a number of voters such as "10", or a percentage
+// of the eligible members such as "60%"
+func parseQuorum(value string) (int, int, error) {
+	if percent, ok := strings.CutSuffix(value, "%"); ok {
+		n, err := strconv.Atoi(percent)
+		if err != nil || n < 1 || n > 100 {
+			return 0, 0, fmt.Errorf("%w: %q", errInvalidQuorum, value)
+		}
+		return 0, n, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, 0, fmt.Errorf("%w: %q", errInvalidQuorum, value)
+	}
+	return n, 0, nil
+}
+
+// hasQuorum reports whether the survey sets a quorum
+func hasQuorum(survey *types.Survey) bool {
+	return survey.Quorum > 0 || survey.QuorumPercent > 0
+}
+
+// quorumText describes the survey's quorum for its footer; it is empty without one
+func quorumText(survey *types.Survey) string {
+	switch {
+	case survey.Quorum > 0:
+		return fmt.Sprintf("定足数 %d人", survey.Quorum)
+	case survey.QuorumPercent > 0:
+		return fmt.Sprintf("定足数 %d%%", survey.QuorumPercent)
+	}
+	return ""
+}
+
+// surveyQuorum compares voters with the survey's quorum. A percentage needs the eligible
+// members counted, so it is nil when turnout is, as it is without a quorum.
+func surveyQuorum(survey *types.Survey, voters int, turnout *types.Turnout) *types.QuorumResult {
+	required := survey.Quorum
+	if survey.QuorumPercent > 0 {
+		if turnout == nil {
+			return nil
+		}
+		required = (turnout.Members*survey.QuorumPercent + 99) / 100
+	}
+	if required <= 0 {
+		return nil
+	}
+	return &types.QuorumResult{Required: required, Voters: voters, Met: voters >= required}
+}
+
+// quorumField shows whether enough members voted for the result to stand, or nil without a quorum
+func quorumField(quorum *types.QuorumResult) *discordgo.MessageEmbedField {
+	if quorum == nil {
+		return nil
+	}
+
+	value := fmt.Sprintf("達成（%d / %d人）", quorum.Voters, quorum.Required)
+	if !quorum.Met {
+		value = fmt.Sprintf("未達（%d / %d人）\nこの結果は成立していません", quorum.Voters, quorum.Required)
+	}
+	return &discordgo.MessageEmbedField{Name: "定足数", Value: value, Inline: false}
+}
+
+// resultDecided reports whether the votes cast settle the winner, however the members
+// who have not voted yet vote. Votes already cast are taken as final.
+//
+// A ranked survey is settled once an option is the first choice of a majority of the
+// members, which wins the instant runoff in its first round. Otherwise the leader must
+// stay ahead of every other option even if all the remaining votes went to it.
+func resultDecided(survey *types.Survey, votes []types.Vote, members int) bool {
+	if len(survey.Options) == 0 || len(votes) == 0 {
+		return false
+	}
+
+	counts := make([]int, len(survey.Options))
+	for _, vote := range votes {
+		if survey.VoteMode == types.VoteModeRanked {
+			if len(vote.Choices) > 0 && validChoice(survey, vote.Choices[0]) {
+				counts[vote.Choices[0]]++
+			}
+			continue
+		}
+		for _, choice := range vote.Choices {
+			if validChoice(survey, choice) {
+				counts[choice]++
+			}
+		}
+	}
+
+	leader := 0
+	for i, count := range counts {
+		if count > counts[leader] {
+			leader = i
+		}
+	}
+
+	if survey.VoteMode == types.VoteModeRanked {
+		return counts[leader]*2 > members
+	}
+
+	remaining := max(members-len(votes), 0)
+	for option, count := range counts {
+		if option == leader {
+			continue
+		}
+		// Voters below their limit may still add the option to their choices
+		reach := count + remaining
+		for _, vote := range votes {
+			if !hasChoice(vote.Choices, option) && (survey.MaxChoices <= 0 || len(vote.Choices) < survey.MaxChoices) {
+				reach++
+			}
+		}
+		if reach >= counts[leader] {
+			return false
+		}
+	}
+	return true
+}
+
+func validChoice(survey *types.Survey, choice int) bool {
+	return choice >= 0 && choice < len(survey.Options)
+}
+
+func hasChoice(choices []int, choice int) bool {
+	for _, c := range choices {
+		if c == choice {
+			return true
+		}
+	}
+	return false
+}
+
+// closeIfDecided closes an auto-closing survey as soon as its winner is settled and its
+// quorum met. It runs after each vote, so failures are only logged.
+func (h *surveyHandler) closeIfDecided(ctx context.Context, s *discordgo.Session, messageID string) {
+	survey, err := h.registry.GetSurvey(ctx, messageID)
+	if err != nil {
+		if !errors.Is(err, types.ErrSurveyNotFound) {
+			h.logger.Error(ctx, "Failed to find survey", err, types.Field{Key: "message_id", Value: messageID})
+		}
+		return
+	}
+	// Without a guild there are no members to count the remaining votes of
+	if !survey.AutoClose || survey.Closed || survey.GuildID == "" {
+		return
+	}
+
+	eligible, err := h.eligible.get(messageID, time.Now(), func() (map[string]bool, error) {
+		return eligibleVoters(s, survey)
+	})
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list eligible members", err, types.Field{Key: "message_id", Value: messageID})
+		return
+	}
+	votes, turnout := eligibleVotes(survey, survey.Votes, eligible)
+
+	if quorum := surveyQuorum(survey, len(votes), turnout); quorum != nil && !quorum.Met {
+		return
+	}
+	if !resultDecided(survey, votes, turnout.Members) {
+		return
+	}
+
+	h.closeAutomatically(ctx, s, messageID, earlyCloseNotice, 0)
+	h.eligible.forget(messageID, nil)
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Logta/SurveyBot/pkg/scheduler"
+	"github.com/Logta/SurveyBot/pkg/state"
+	"github.com/Logta/SurveyBot/types"
+)
+
+func TestSurveyQuorum(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings types.SurveySettings
+		voters   int
+		turnout  *types.Turnout
+		expected *types.QuorumResult
+	}{
+		{"正常系: 定足数なし", types.SurveySettings{}, 3, nil, nil},
+		{"正常系: 人数の定足数に達した", types.SurveySettings{Quorum: 3}, 3, nil, &types.QuorumResult{Required: 3, Voters: 3, Met: true}},
+		{"正常系: 割合の定足数は切り上げる", types.SurveySettings{QuorumPercent: 60}, 5, &types.Turnout{Members: 9}, &types.QuorumResult{Required: 6, Voters: 5, Met: false}},
+		{"異常系: メンバー数が分からない割合の定足数", types.SurveySettings{QuorumPercent: 60}, 5, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			survey := &types.Survey{SurveySettings: tt.settings}
+
+			// Act
+			result := surveyQuorum(survey, tt.voters, tt.turnout)
+
+			// Assert
+			if (result == nil) != (tt.expected == nil) || (result != nil && *result != *tt.expected) {
+				t.Errorf("surveyQuorum() = %+v, want %+v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestQuorumField(t *testing.T) {
+	t.Run("正常系: 未達なら結果が成立していないと表示する", func(t *testing.T) {
+		// Act
+		field := quorumField(&types.QuorumResult{Required: 6, Voters: 5})
+
+		// Assert
+		if !strings.Contains(field.Value, "未達（5 / 6人）") || !strings.Contains(field.Value, "成立していません") {
+			t.Errorf("定足数の表示が期待値と異なります: %q", field.Value)
+		}
+	})
+
+	t.Run("正常系: 定足数なしでは表示しない", func(t *testing.T) {
+		// Act
+		field := quorumField(nil)
+
+		// Assert
+		if field != nil {
+			t.Errorf("nilが期待されていましたが、%+vが返されました", field)
+		}
+	})
+}
+
+func TestResultDecided(t *testing.T) {
+	options := []string{"A", "B", "C"}
+
+	tests := []struct {
+		name     string
+		settings types.SurveySettings
+		votes    [][]int
+		members  int
+		expected bool
+	}{
+		{"正常系: 残りの全員が2位に投票しても逆転しない", types.SurveySettings{MaxChoices: 1}, [][]int{{0}, {0}, {0}, {1}}, 5, true},
+		{"正常系: 残りの票で並ばれる", types.SurveySettings{MaxChoices: 1}, [][]int{{0}, {0}, {1}}, 4, false},
+		{"正常系: 複数選択では投票済みのメンバーも選択を追加できる", types.SurveySettings{}, [][]int{{0}, {0}, {0}, {1}}, 5, false},
+		{"正常系: 複数選択で全員が上限まで選んだ", types.SurveySettings{MaxChoices: 2}, [][]int{{0, 1}, {0, 2}, {0, 1}}, 3, true},
+		{"正常系: 順位付けで第1希望が過半数", types.SurveySettings{VoteMode: types.VoteModeRanked}, [][]int{{0, 1}, {0, 2}, {1, 0}}, 3, true},
+		{"正常系: 順位付けで第1希望がちょうど半数", types.SurveySettings{VoteMode: types.VoteModeRanked}, [][]int{{0, 1}, {0, 2}, {1, 0}}, 4, false},
+		{"異常系: 投票なし", types.SurveySettings{MaxChoices: 1}, nil, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			survey := &types.Survey{Options: options, SurveySettings: tt.settings}
+			votes := make([]types.Vote, len(tt.votes))
+			for i, choices := range tt.votes {
+				votes[i] = types.Vote{UserID: string(rune('a' + i)), Choices: choices}
+			}
+
+			// Act
+			result := resultDecided(survey, votes, tt.members)
+
+			// Assert
+			if result != tt.expected {
+				t.Errorf("resultDecided() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEligibleCache(t *testing.T) {
+	listedAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		forget    bool
+		failFirst bool
+		after     time.Duration
+		lists     int
+	}{
+		{"正常系: 期限内は一覧を取り直さない", false, false, eligibleCacheTTL, 1},
+		{"正常系: 期限を過ぎたら取り直す", false, false, eligibleCacheTTL + time.Second, 2},
+		{"正常系: 締め切ったアンケートは忘れる", true, false, time.Second, 2},
+		{"異常系: 取得に失敗したら次の投票で取り直す", false, true, time.Second, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var cache eligibleCache
+			lists := 0
+			list := func() (map[string]bool, error) {
+				lists++
+				if tt.failFirst && lists == 1 {
+					return nil, errors.New("list failed")
+				}
+				return map[string]bool{"user1": true}, nil
+			}
+			cache.get("msg1", listedAt, list)
+			if tt.forget {
+				cache.forget("msg1", nil)
+			}
+
+			// Act
+			voters, err := cache.get("msg1", listedAt.Add(tt.after), list)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("get() error = %v", err)
+			}
+			if !voters["user1"] {
+				t.Errorf("get() = %v, want user1", voters)
+			}
+			if lists != tt.lists {
+				t.Errorf("listed %d times, want %d", lists, tt.lists)
+			}
+		})
+	}
+}
+
+func TestSurveyHandler_CloseIfDecided_Concurrent(t *testing.T) {
+	t.Run("正常系: 同時に結果を確定させる投票が来ても結果は1回だけ投稿する", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		registry := state.NewMemorySurveyRegistry()
+		registry.SaveSurvey(ctx, &types.Survey{
+			MessageID: "message-1",
+			GuildID:   "guild-1",
+			ChannelID: "channel-1",
+			Title:     "ランチ",
+			Options:   []string{"和食", "洋食"},
+			Emojis:    []string{"1️⃣", "2️⃣"},
+			Votes: []types.Vote{
+				{UserID: "user1", Choices: []int{0}},
+				{UserID: "user2", Choices: []int{0}},
+				{UserID: "user3", Choices: []int{0}},
+			},
+			SurveySettings: types.SurveySettings{VoteMode: types.VoteModeReaction, MaxChoices: 1, AutoClose: true},
+		})
+		jobs := scheduler.New()
+		defer jobs.Stop()
+		handler := NewSurveyHandler(&mockStateManager{}, registry, &mockGuildSettingsStore{}, &mockTemplateStore{}, &mockScheduleStore{}, jobs, &mockEmojiProvider{}, &mockLogger{}).(*surveyHandler)
+
+		var posts atomic.Int32
+		s := newRESTSession(http.StatusOK, "")
+		s.Client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			body := `{"id": "result-1", "channel_id": "channel-1"}`
+			switch {
+			case strings.HasSuffix(r.URL.Path, "/members"):
+				body = `[{"user": {"id": "user1"}}, {"user": {"id": "user2"}}, {"user": {"id": "user3"}}, {"user": {"id": "user4"}}]`
+			case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/messages/message-1"):
+				// Tallying is held back so that every vote is closing the survey at once
+				time.Sleep(20 * time.Millisecond)
+				body = `{"id": "message-1", "channel_id": "channel-1", "reactions": [{"count": 4, "me": true, "emoji": {"name": "1️⃣"}}]}`
+			case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/messages"):
+				posts.Add(1)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Request:    r,
+			}, nil
+		})
+
+		// Act
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				handler.closeIfDecided(ctx, s, "message-1")
+			}()
+		}
+		wg.Wait()
+
+		// Assert
+		if got := posts.Load(); got != 1 {
+			t.Errorf("結果の投稿数が期待値と異なります: got %d, want 1", got)
+		}
+		survey, _ := registry.GetSurvey(ctx, "message-1")
+		if !survey.Closed {
+			t.Error("アンケートが締め切られていません")
+		}
+	})
+}
@@ -0,0 +1,485 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/Logta/SurveyBot/utils"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	rankStartID      = "survey_rank_start"
+	rankSelectPrefix = "survey_rank_select:"
+	rankSubmitPrefix = "survey_rank_submit:"
+
+	// rankDigits encodes one option index per character, so that the ranking built so far
+	// fits in a custom ID within Discord's 100 character limit
+	rankDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+)
+
+// rankedSurveyComponents returns the button that opens the ranking flow of a ranked survey
+func rankedSurveyComponents(survey *types.Survey) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    "順位を付けて投票",
+				Style:    discordgo.PrimaryButton,
+				CustomID: rankStartID,
+				Disabled: survey.Closed,
+			},
+		}},
+	}
+}
+
+// isRankComponent reports whether i was triggered by a ranked survey's components
+func isRankComponent(i *discordgo.InteractionCreate) bool {
+	if i.Type != discordgo.InteractionMessageComponent {
+		return false
+	}
+	customID := i.MessageComponentData().CustomID
+	return customID == rankStartID ||
+		strings.HasPrefix(customID, rankSelectPrefix) ||
+		strings.HasPrefix(customID, rankSubmitPrefix)
+}
+
+// encodeRanking packs option indices into a custom ID segment
+func encodeRanking(ranking []int) string {
+	var b strings.Builder
+	for _, choice := range ranking {
+		b.WriteByte(rankDigits[choice])
+	}
+	return b.String()
+}
+
+// decodeRanking unpacks a custom ID segment, rejecting unknown or repeated options
+func decodeRanking(encoded string, optionCount int) ([]int, error) {
+	ranking := make([]int, 0, len(encoded))
+	seen := make(map[int]bool, len(encoded))
+	for _, r := range encoded {
+		choice := strings.IndexRune(rankDigits, r)
+		if choice < 0 || choice >= optionCount || seen[choice] {
+			return nil, fmt.Errorf("invalid ranking %q", encoded)
+		}
+		seen[choice] = true
+		ranking = append(ranking, choice)
+	}
+	return ranking, nil
+}
+
+// rankCustomID returns the custom ID of a ranking step for the survey posted as messageID
+func rankCustomID(prefix, messageID string, ranking []int) string {
+	return prefix + messageID + ":" + encodeRanking(ranking)
+}
+
+// rankLength returns how many options a member ranks, at most MaxChoices when it is set
+func rankLength(survey *types.Survey) int {
+	if survey.MaxChoices > 0 && survey.MaxChoices < len(survey.Options) {
+		return survey.MaxChoices
+	}
+	return len(survey.Options)
+}
+
+func (h *surveyHandler) handleRankInteraction(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	data := i.MessageComponentData()
+
+	messageID := i.Message.ID
+	encoded := ""
+	prefix := ""
+	switch {
+	case strings.HasPrefix(data.CustomID, rankSelectPrefix):
+		prefix = rankSelectPrefix
+	case strings.HasPrefix(data.CustomID, rankSubmitPrefix):
+		prefix = rankSubmitPrefix
+	}
+	if prefix != "" {
+		var ok bool
+		messageID, encoded, ok = strings.Cut(strings.TrimPrefix(data.CustomID, prefix), ":")
+		if !ok {
+			return respondEphemeral(s, i, "無効な回答です")
+		}
+	}
+
+	survey, err := h.registry.GetSurvey(ctx, messageID)
+	if errors.Is(err, types.ErrSurveyNotFound) {
+		return respondEphemeral(s, i, "このアンケートは見つかりませんでした")
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to find survey", err)
+		return err
+	}
+
+	if survey.Closed {
+		return respondEphemeral(s, i, closedVoteMessage)
+	}
+
+	if !isEligible(survey, i.Member) {
+		return respondEphemeral(s, i, ineligibleMessage(survey))
+	}
+
+	// The start button opens a new ranking only the member can see; later steps update it
+	if prefix == "" {
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: rankingPrompt(survey, nil),
+		})
+	}
+
+	ranking, err := decodeRanking(encoded, len(survey.Options))
+	if err != nil {
+		return respondEphemeral(s, i, "無効な回答です")
+	}
+
+	if prefix == rankSelectPrefix {
+		ranking, err = appendRankChoice(survey, ranking, data.Values)
+		if err != nil {
+			return respondEphemeral(s, i, "無効な回答です")
+		}
+		if len(ranking) < rankLength(survey) {
+			return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseUpdateMessage,
+				Data: rankingPrompt(survey, ranking),
+			})
+		}
+	}
+
+	if len(ranking) == 0 {
+		return respondEphemeral(s, i, "無効な回答です")
+	}
+
+	if err := h.recordRanking(ctx, survey, interactionUser(i).ID, ranking); err != nil {
+		if errors.Is(err, types.ErrSurveyClosed) {
+			return respondEphemeral(s, i, closedVoteMessage)
+		}
+		return err
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    rankingConfirmation(survey, ranking),
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	h.closeIfDecided(ctx, s, survey.MessageID)
+	return nil
+}
+
+// appendRankChoice adds the option picked in the select menu to ranking. Once a single
+// option is left to rank, it is added as well.
+func appendRankChoice(survey *types.Survey, ranking []int, values []string) ([]int, error) {
+	if len(values) != 1 {
+		return nil, errors.New("expected one select value")
+	}
+	choice, err := strconv.Atoi(values[0])
+	if err != nil || choice < 0 || choice >= len(survey.Options) {
+		return nil, errors.New("invalid select value")
+	}
+	for _, ranked := range ranking {
+		if ranked == choice {
+			return nil, errors.New("option already ranked")
+		}
+	}
+
+	ranking = append(ranking, choice)
+	if rankLength(survey) == len(survey.Options) && len(ranking) == len(survey.Options)-1 {
+		ranking = append(ranking, unrankedOptions(survey, ranking)...)
+	}
+	return ranking, nil
+}
+
+// unrankedOptions returns the options not in ranking, in survey order
+func unrankedOptions(survey *types.Survey, ranking []int) []int {
+	ranked := make(map[int]bool, len(ranking))
+	for _, choice := range ranking {
+		ranked[choice] = true
+	}
+
+	var options []int
+	for i := range survey.Options {
+		if !ranked[i] {
+			options = append(options, i)
+		}
+	}
+	return options
+}
+
+// rankingPrompt asks the member for their next preference, showing the ranking so far
+func rankingPrompt(survey *types.Survey, ranking []int) *discordgo.InteractionResponseData {
+	content := fmt.Sprintf("「%s」の第%d希望を選んでください", survey.Title, len(ranking)+1)
+	if len(ranking) > 0 {
+		content += "\n" + rankingText(survey, ranking)
+	}
+
+	remaining := unrankedOptions(survey, ranking)
+	selectOptions := make([]discordgo.SelectMenuOption, len(remaining))
+	for n, choice := range remaining {
+		selectOptions[n] = discordgo.SelectMenuOption{
+			Label: truncate(survey.Options[choice], 100),
+			Value: strconv.Itoa(choice),
+		}
+		if survey.Emojis[choice] != "" {
+			selectOptions[n].Emoji = componentEmoji(survey.Emojis[choice])
+		}
+	}
+
+	return &discordgo.InteractionResponseData{
+		Content: content,
+		Flags:   discordgo.MessageFlagsEphemeral,
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					MenuType:    discordgo.StringSelectMenu,
+					CustomID:    rankCustomID(rankSelectPrefix, survey.MessageID, ranking),
+					Placeholder: fmt.Sprintf("第%d希望", len(ranking)+1),
+					MaxValues:   1,
+					Options:     selectOptions,
+				},
+			}},
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "この順位で投票する",
+					Style:    discordgo.SuccessButton,
+					CustomID: rankCustomID(rankSubmitPrefix, survey.MessageID, ranking),
+					Disabled: len(ranking) == 0,
+				},
+			}},
+		},
+	}
+}
+
+// rankingText lists the ranked options, one preference per line
+func rankingText(survey *types.Survey, ranking []int) string {
+	lines := make([]string, len(ranking))
+	for n, choice := range ranking {
+		lines[n] = fmt.Sprintf("%d. %s %s", n+1, optionMarker(survey, choice), survey.Options[choice])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// rankingConfirmation tells the member which ranking was recorded
+func rankingConfirmation(survey *types.Survey, ranking []int) string {
+	if survey.Anonymous {
+		return "匿名で順位を付けて投票しました\n" + rankingText(survey, ranking)
+	}
+	return "順位を付けて投票しました\n" + rankingText(survey, ranking)
+}
+
+func (h *surveyHandler) recordRanking(ctx context.Context, survey *types.Survey, userID string, ranking []int) error {
+	vote := types.Vote{UserID: voterID(survey, userID), Choices: ranking}
+	if !survey.Anonymous {
+		vote.VotedAt = time.Now()
+	}
+	if _, err := h.registry.RecordVote(ctx, survey.MessageID, vote); err != nil {
+		if !errors.Is(err, types.ErrSurveyClosed) {
+			h.logger.Error(ctx, "Failed to record ranking", err, types.Field{Key: "message_id", Value: survey.MessageID})
+		}
+		return err
+	}
+
+	h.logger.Debug(ctx, "Ranking recorded",
+		types.Field{Key: "message_id", Value: survey.MessageID},
+		types.Field{Key: "ranking", Value: ranking},
+	)
+	return nil
+}
+
+// parseRanking reads the option numbers given to !rank, most preferred first
+func parseRanking(args []string, survey *types.Survey) ([]int, error) {
+	var ranking []int
+	seen := make(map[int]bool, len(args))
+	for _, arg := range args {
+		if arg == "" {
+			continue
+		}
+		number, err := strconv.Atoi(arg)
+		if err != nil || number < 1 || number > len(survey.Options) || seen[number-1] {
+			return nil, fmt.Errorf("invalid option number %q", arg)
+		}
+		seen[number-1] = true
+		ranking = append(ranking, number-1)
+	}
+
+	if len(ranking) == 0 || len(ranking) > rankLength(survey) {
+		return nil, fmt.Errorf("ranking must have 1 to %d options", rankLength(survey))
+	}
+	return ranking, nil
+}
+
+// handleRank records a ranking given as option numbers, e.g. "!rank 3 1 2", on the
+// replied-to survey or the latest survey in the channel
+func (h *surveyHandler) handleRank(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
+	var survey *types.Survey
+	var err error
+	if m.MessageReference != nil {
+		survey, err = h.registry.GetSurvey(ctx, m.MessageReference.MessageID)
+	} else {
+		survey, err = h.registry.GetLatestSurvey(ctx, m.ChannelID)
+	}
+	if err != nil && !errors.Is(err, types.ErrSurveyNotFound) {
+		h.logger.Error(ctx, "Failed to find survey", err)
+		return err
+	}
+
+	if err != nil || survey.VoteMode != types.VoteModeRanked {
+		_, err := s.ChannelMessageSend(m.ChannelID, "順位付けのアンケートが見つかりませんでした。アンケートに返信して "+string(types.CmdRank)+" を実行してください")
+		return err
+	}
+
+	if survey.Closed {
+		_, err := s.ChannelMessageSend(m.ChannelID, closedVoteMessage)
+		return err
+	}
+
+	// A ranking typed into the channel would show everyone who voted for what
+	if survey.Anonymous {
+		_, err := s.ChannelMessageSend(m.ChannelID, "匿名アンケートには「順位を付けて投票」ボタンから投票してください")
+		return err
+	}
+
+	if !isEligible(survey, m.Member) {
+		_, err := s.ChannelMessageSend(m.ChannelID, ineligibleMessage(survey))
+		return err
+	}
+
+	args, err := utils.ParseList(utils.CommandText(m.Content))
+	var ranking []int
+	if err == nil {
+		ranking, err = parseRanking(args, survey)
+	}
+	if err != nil {
+		_, err := s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("%s の後に 1〜%d の番号を希望順に並べてください（例: %s 2 1 3）", types.CmdRank, len(survey.Options), types.CmdRank))
+		return err
+	}
+
+	if err := h.recordRanking(ctx, survey, m.Author.ID, ranking); err != nil {
+		if errors.Is(err, types.ErrSurveyClosed) {
+			_, err := s.ChannelMessageSend(m.ChannelID, closedVoteMessage)
+			return err
+		}
+		return err
+	}
+
+	if _, err := s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("<@%s> の順位を記録しました\n%s", m.Author.ID, rankingText(survey, ranking))); err != nil {
+		return err
+	}
+
+	h.closeIfDecided(ctx, s, survey.MessageID)
+	return nil
+}
+
+// tallyRanked runs the instant-runoff and Borda counts of a ranked survey
+func (h *surveyHandler) tallyRanked(ctx context.Context, s *discordgo.Session, survey *types.Survey) *types.RankedResult {
+	votes := survey.Votes
+	var turnout *types.Turnout
+	var quorum *types.QuorumResult
+	if survey.EligibleRoleID != "" || hasQuorum(survey) {
+		votes, turnout, quorum = h.countVoters(ctx, s, survey, votes)
+	}
+
+	result := utils.TallyRanked(survey.Title, surveyOptionResults(survey), votes)
+	result.Turnout = turnout
+	result.Quorum = quorum
+
+	h.logger.Debug(ctx, "Ranked survey tallied",
+		types.Field{Key: "message_id", Value: survey.MessageID},
+		types.Field{Key: "total_votes", Value: result.TotalVotes},
+		types.Field{Key: "rounds", Value: len(result.Rounds)},
+	)
+
+	return result
+}
+
+func createRankedResultEmbed(result *types.RankedResult) *discordgo.MessageEmbed {
+	// The heading leaves the chart less room than a plain result embed
+	heading := "第1希望の票数\n"
+	description := heading + utils.RenderBarChart(result.Options, utils.EmbedDescriptionLimit-len([]rune(heading)))
+
+	scores := make([]string, len(result.Options))
+	for i, option := range result.Options {
+		scores[i] = fmt.Sprintf("%s : %s  %d点", option.Emoji, option.Label, result.BordaScores[i])
+	}
+
+	rounds := "投票がありませんでした"
+	if len(result.Winners) > 0 {
+		rounds = truncate(rankedRoundsText(result), 1024)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       result.Title + " の集計結果",
+		Description: description,
+		Color:       0x141DB8,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "集計ラウンド", Value: rounds, Inline: false},
+			{Name: "ボルダ得点", Value: truncate(strings.Join(scores, "\n"), 1024), Inline: false},
+			{Name: "当選（即時決選投票）", Value: optionLabels(result.Options, result.Winners), Inline: true},
+			{Name: "最高得点（ボルダ得点）", Value: optionLabels(result.Options, result.BordaWinners), Inline: true},
+			{Name: "総投票数", Value: fmt.Sprintf("%d票", result.TotalVotes), Inline: true},
+		},
+	}
+	if field := turnoutField(result.Turnout); field != nil {
+		embed.Fields = append(embed.Fields, field)
+	}
+	if field := quorumField(result.Quorum); field != nil {
+		embed.Fields = append(embed.Fields, field)
+	}
+	return embed
+}
+
+// rankedRoundsText describes each instant-runoff round: the votes of the remaining
+// options and which options were eliminated or won
+func rankedRoundsText(result *types.RankedResult) string {
+	lines := make([]string, 0, len(result.Rounds))
+	for n, round := range result.Rounds {
+		counts := make([]string, 0, len(round.Remaining)+1)
+		for _, option := range round.Remaining {
+			counts = append(counts, fmt.Sprintf("%s %d票", result.Options[option].Emoji, round.Counts[option]))
+		}
+		if round.Exhausted > 0 {
+			counts = append(counts, fmt.Sprintf("無効 %d票", round.Exhausted))
+		}
+
+		var outcome string
+		switch {
+		case len(round.Eliminated) > 0:
+			outcome = optionEmojis(result.Options, round.Eliminated) + " を除外"
+		case len(result.Winners) == 1:
+			outcome = optionEmojis(result.Options, result.Winners) + " が過半数を獲得"
+		default:
+			outcome = optionEmojis(result.Options, result.Winners) + " が同数で並びました"
+		}
+
+		lines = append(lines, fmt.Sprintf("第%d回: %s → %s", n+1, strings.Join(counts, " / "), outcome))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// optionLabels lists the given options one per line, or notes that nobody voted
+func optionLabels(options []types.OptionResult, indices []int) string {
+	if len(indices) == 0 {
+		return "投票がありませんでした"
+	}
+	labels := make([]string, len(indices))
+	for n, idx := range indices {
+		labels[n] = fmt.Sprintf("%s : %s", options[idx].Emoji, options[idx].Label)
+	}
+	return strings.Join(labels, "\n")
+}
+
+// optionEmojis joins the markers of the given options
+func optionEmojis(options []types.OptionResult, indices []int) string {
+	emojis := make([]string, len(indices))
+	for n, idx := range indices {
+		emojis[n] = options[idx].Emoji
+	}
+	return strings.Join(emojis, " ")
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/bwmarrin/discordgo"
+)
+
+func newRankedSurvey(optionCount int) *types.Survey {
+	survey := newComponentSurvey(optionCount)
+	survey.VoteMode = types.VoteModeRanked
+	return survey
+}
+
+func TestEncodeRanking(t *testing.T) {
+	t.Run("正常系: 符号化した順位を復元できる", func(t *testing.T) {
+		// Arrange
+		ranking := []int{24, 0, 10, 3}
+
+		// Act
+		encoded := encodeRanking(ranking)
+		decoded, err := decodeRanking(encoded, maxSelectOptions)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if encoded != "o0a3" {
+			t.Errorf("符号化結果が期待値と異なります: got %v, want %v", encoded, "o0a3")
+		}
+		if !reflect.DeepEqual(decoded, ranking) {
+			t.Errorf("復元結果が期待値と異なります: got %v, want %v", decoded, ranking)
+		}
+	})
+
+	t.Run("正常系: 25項目すべての順位がカスタムIDの上限に収まる", func(t *testing.T) {
+		// Arrange
+		ranking := make([]int, maxSelectOptions)
+		for i := range ranking {
+			ranking[i] = i
+		}
+
+		// Act
+		customID := rankCustomID(rankSelectPrefix, "12345678901234567890", ranking)
+
+		// Assert
+		if len(customID) > 100 {
+			t.Errorf("カスタムIDが長すぎます: got %v", len(customID))
+		}
+	})
+
+	t.Run("異常系: 範囲外や重複した項目", func(t *testing.T) {
+		testCases := []string{"3", "00", "-"}
+
+		for _, encoded := range testCases {
+			t.Run(encoded, func(t *testing.T) {
+				// Act
+				_, err := decodeRanking(encoded, 3)
+
+				// Assert
+				if err == nil {
+					t.Errorf("エラーが期待されていましたが、nilが返されました")
+				}
+			})
+		}
+	})
+}
+
+func TestAppendRankChoice(t *testing.T) {
+	t.Run("正常系: 選んだ項目を次の順位に加える", func(t *testing.T) {
+		// Arrange
+		survey := newRankedSurvey(4)
+
+		// Act
+		ranking, err := appendRankChoice(survey, []int{2}, []string{"0"})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if !reflect.DeepEqual(ranking, []int{2, 0}) {
+			t.Errorf("順位が期待値と異なります: got %v, want %v", ranking, []int{2, 0})
+		}
+	})
+
+	t.Run("正常系: 残りが1つになると最下位に自動で加える", func(t *testing.T) {
+		// Arrange
+		survey := newRankedSurvey(3)
+
+		// Act
+		ranking, err := appendRankChoice(survey, []int{2}, []string{"0"})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if !reflect.DeepEqual(ranking, []int{2, 0, 1}) {
+			t.Errorf("順位が期待値と異なります: got %v, want %v", ranking, []int{2, 0, 1})
+		}
+	})
+
+	t.Run("正常系: 希望数の上限がある場合は自動で加えない", func(t *testing.T) {
+		// Arrange
+		survey := newRankedSurvey(3)
+		survey.MaxChoices = 2
+
+		// Act
+		ranking, err := appendRankChoice(survey, []int{2}, []string{"0"})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if !reflect.DeepEqual(ranking, []int{2, 0}) {
+			t.Errorf("順位が期待値と異なります: got %v, want %v", ranking, []int{2, 0})
+		}
+	})
+
+	t.Run("異常系: 順位付け済みの項目", func(t *testing.T) {
+		// Arrange
+		survey := newRankedSurvey(4)
+
+		// Act
+		_, err := appendRankChoice(survey, []int{2}, []string{"2"})
+
+		// Assert
+		if err == nil {
+			t.Errorf("エラーが期待されていましたが、nilが返されました")
+		}
+	})
+}
+
+func TestRankingPrompt(t *testing.T) {
+	t.Run("正常系: 未選択の項目だけを選択肢にする", func(t *testing.T) {
+		// Arrange
+		survey := newRankedSurvey(4)
+
+		// Act
+		data := rankingPrompt(survey, []int{1, 3})
+
+		// Assert
+		if !strings.Contains(data.Content, "第3希望") {
+			t.Errorf("案内が期待値と異なります: got %v", data.Content)
+		}
+		menu := data.Components[0].(discordgo.ActionsRow).Components[0].(discordgo.SelectMenu)
+		if menu.CustomID != rankSelectPrefix+"message-1:13" {
+			t.Errorf("カスタムIDが期待値と異なります: got %v", menu.CustomID)
+		}
+		var values []string
+		for _, option := range menu.Options {
+			values = append(values, option.Value)
+		}
+		if !reflect.DeepEqual(values, []string{"0", "2"}) {
+			t.Errorf("選択肢が期待値と異なります: got %v, want %v", values, []string{"0", "2"})
+		}
+	})
+}
+
+func TestParseRanking(t *testing.T) {
+	t.Run("正常系: 番号を希望順に読み取る", func(t *testing.T) {
+		// Arrange
+		survey := newRankedSurvey(3)
+
+		// Act
+		ranking, err := parseRanking([]string{"3", "", "1"}, survey)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if !reflect.DeepEqual(ranking, []int{2, 0}) {
+			t.Errorf("順位が期待値と異なります: got %v, want %v", ranking, []int{2, 0})
+		}
+	})
+
+	t.Run("異常系: 不正な番号", func(t *testing.T) {
+		survey := newRankedSurvey(3)
+		survey.MaxChoices = 2
+
+		testCases := []struct {
+			name string
+			args []string
+		}{
+			{"番号なし", nil},
+			{"範囲外", []string{"4"}},
+			{"数値以外", []string{"A"}},
+			{"重複", []string{"1", "1"}},
+			{"上限超過", []string{"1", "2", "3"}},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				// Act
+				_, err := parseRanking(tc.args, survey)
+
+				// Assert
+				if err == nil {
+					t.Errorf("エラーが期待されていましたが、nilが返されました")
+				}
+			})
+		}
+	})
+}
+
+func TestRankedRoundsText(t *testing.T) {
+	t.Run("正常系: 除外と当選をラウンドごとに表示する", func(t *testing.T) {
+		// Arrange
+		result := &types.RankedResult{
+			Options: []types.OptionResult{{Emoji: "🇦"}, {Emoji: "🇧"}, {Emoji: "🇨"}},
+			Rounds: []types.RankedRound{
+				{Counts: []int{4, 3, 2}, Remaining: []int{0, 1, 2}, Eliminated: []int{2}},
+				{Counts: []int{4, 5, 0}, Remaining: []int{0, 1}},
+			},
+			Winners: []int{1},
+		}
+
+		// Act
+		text := rankedRoundsText(result)
+
+		// Assert
+		expected := "第1回: 🇦 4票 / 🇧 3票 / 🇨 2票 → 🇨 を除外\n第2回: 🇦 4票 / 🇧 5票 → 🇧 が過半数を獲得"
+		if text != expected {
+			t.Errorf("ラウンド表示が期待値と異なります: got %q, want %q", text, expected)
+		}
+	})
+
+	t.Run("正常系: 無効票と同数での当選を表示する", func(t *testing.T) {
+		// Arrange
+		result := &types.RankedResult{
+			Options: []types.OptionResult{{Emoji: "🇦"}, {Emoji: "🇧"}},
+			Rounds: []types.RankedRound{
+				{Counts: []int{2, 2}, Remaining: []int{0, 1}, Exhausted: 1},
+			},
+			Winners: []int{0, 1},
+		}
+
+		// Act
+		text := rankedRoundsText(result)
+
+		// Assert
+		expected := "第1回: 🇦 2票 / 🇧 2票 / 無効 1票 → 🇦 🇧 が同数で並びました"
+		if text != expected {
+			t.Errorf("ラウンド表示が期待値と異なります: got %q, want %q", text, expected)
+		}
+	})
+}
+
+func TestSurveyComponents_Ranked(t *testing.T) {
+	t.Run("正常系: 順位付けのアンケートは投票開始ボタンになる", func(t *testing.T) {
+		// Arrange
+		survey := newRankedSurvey(3)
+		survey.Closed = true
+
+		// Act
+		components := surveyComponents(survey)
+
+		// Assert
+		button := components[0].(discordgo.ActionsRow).Components[0].(discordgo.Button)
+		if button.CustomID != rankStartID || !button.Disabled {
+			t.Errorf("ボタンが期待値と異なります: got %+v", button)
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/bwmarrin/discordgo"
+)
+
+// HandleReactionAdd records a reaction vote and, when the survey limits how many options a
+// member may choose, removes the member's oldest reactions beyond that limit. An
+// auto-closing survey is closed once the vote settles its result.
+func (h *surveyHandler) HandleReactionAdd(ctx context.Context, s *discordgo.Session, r *discordgo.MessageReactionAdd) error {
+	recorded, err := h.addReactionVote(ctx, s, r)
+	if err != nil || !recorded {
+		return err
+	}
+
+	// Outside the lock, since settling the result may list the guild's members
+	h.closeIfDecided(ctx, s, r.MessageID)
+	return nil
+}
+
+// addReactionVote records the vote of a reaction one event at a time and reports whether
+// it was a vote
+func (h *surveyHandler) addReactionVote(ctx context.Context, s *discordgo.Session, r *discordgo.MessageReactionAdd) (bool, error) {
+	h.reactionMu.Lock()
+	defer h.reactionMu.Unlock()
+
+	survey, choice, err := h.reactionSurvey(ctx, r.MessageID, &r.Emoji)
+	if err != nil || survey == nil {
+		return false, err
+	}
+
+	// Reactions cannot be refused, so those of members without the role are taken back
+	if !isEligible(survey, r.Member) {
+		if err := s.MessageReactionRemove(r.ChannelID, r.MessageID, reactionAPIName(survey.Emojis[choice]), r.UserID); err != nil {
+			h.logger.Error(ctx, "Failed to remove ineligible reaction", err,
+				types.Field{Key: "message_id", Value: r.MessageID},
+				types.Field{Key: "user_id", Value: r.UserID},
+			)
+		}
+		return false, nil
+	}
+
+	choices, dropped := addChoice(currentChoices(survey, r.UserID), choice, survey.MaxChoices)
+	if err := h.recordReactionVote(ctx, survey, r.UserID, choices); err != nil {
+		return false, err
+	}
+
+	for _, old := range dropped {
+		if err := s.MessageReactionRemove(r.ChannelID, r.MessageID, reactionAPIName(survey.Emojis[old]), r.UserID); err != nil {
+			h.logger.Error(ctx, "Failed to remove reaction", err,
+				types.Field{Key: "message_id", Value: r.MessageID},
+				types.Field{Key: "user_id", Value: r.UserID},
+			)
+		}
+	}
+	return true, nil
+}
+
+// HandleReactionRemove withdraws the option from the member's recorded choices
+func (h *surveyHandler) HandleReactionRemove(ctx context.Context, s *discordgo.Session, r *discordgo.MessageReactionRemove) error {
+	h.reactionMu.Lock()
+	defer h.reactionMu.Unlock()
+
+	survey, choice, err := h.reactionSurvey(ctx, r.MessageID, &r.Emoji)
+	if err != nil || survey == nil {
+		return err
+	}
+
+	choices := removeChoice(currentChoices(survey, r.UserID), choice)
+	return h.recordReactionVote(ctx, survey, r.UserID, choices)
+}
+
+// reactionSurvey returns the open reaction survey posted as messageID and the option
+// emoji stands for. The survey is nil when the reaction is not a vote.
+func (h *surveyHandler) reactionSurvey(ctx context.Context, messageID string, emoji *discordgo.Emoji) (*types.Survey, int, error) {
+	survey, err := h.registry.GetSurvey(ctx, messageID)
+	if errors.Is(err, types.ErrSurveyNotFound) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to find survey", err, types.Field{Key: "message_id", Value: messageID})
+		return nil, 0, err
+	}
+
+	if survey.Closed || survey.VoteMode != types.VoteModeReaction {
+		return nil, 0, nil
+	}
+
+	choice := reactionChoice(survey, emoji)
+	if choice < 0 {
+		return nil, 0, nil
+	}
+
+	return survey, choice, nil
+}
+
+func (h *surveyHandler) recordReactionVote(ctx context.Context, survey *types.Survey, userID string, choices []int) error {
+	_, err := h.registry.RecordVote(ctx, survey.MessageID, types.Vote{
+		UserID:  userID,
+		Choices: choices,
+		VotedAt: time.Now(),
+	})
+	if errors.Is(err, types.ErrSurveyClosed) {
+		// The survey was closed after the reaction was read, so the vote came too late
+		return nil
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to record vote", err, types.Field{Key: "message_id", Value: survey.MessageID})
+		return err
+	}
+
+	h.logger.Debug(ctx, "Reaction vote recorded",
+		types.Field{Key: "message_id", Value: survey.MessageID},
+		types.Field{Key: "choices", Value: choices},
+	)
+	return nil
+}
+
+// reactionChoice returns the index of the option marked by emoji, or -1 if none is
+func reactionChoice(survey *types.Survey, emoji *discordgo.Emoji) int {
+	for i, optionEmoji := range survey.Emojis {
+		if matchesEmoji(optionEmoji, emoji) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestReactionChoice(t *testing.T) {
+	t.Run("正常系: リアクションの絵文字から選択肢を特定する", func(t *testing.T) {
+		// Arrange
+		survey := &types.Survey{
+			Options: []string{"A", "B", "C"},
+			Emojis:  []string{"1️⃣", "<:cat:111>", ""},
+		}
+
+		testCases := []struct {
+			name     string
+			emoji    *discordgo.Emoji
+			expected int
+		}{
+			{"選択肢の絵文字", &discordgo.Emoji{Name: "1️⃣"}, 0},
+			{"選択肢のカスタム絵文字", &discordgo.Emoji{Name: "cat", ID: "111"}, 1},
+			{"選択肢ではない絵文字", &discordgo.Emoji{Name: "👍"}, -1},
+			{"空の絵文字", &discordgo.Emoji{}, -1},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				// Act
+				result := reactionChoice(survey, tc.emoji)
+
+				// Assert
+				if result != tc.expected {
+					t.Errorf("選択肢が期待値と異なります: got %v, want %v", result, tc.expected)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/Logta/SurveyBot/utils"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// dmFlag makes !remind send direct messages instead of mentioning
+	dmFlag = "--dm"
+	// guildMembersPageSize is the most members Discord returns per request
+	guildMembersPageSize = 1000
+	// reactionUsersPageSize is the most users Discord returns per reaction request
+	reactionUsersPageSize = 100
+	// minAnonymousReminderMembers is the smallest group reminded about an anonymous survey.
+	// Reminding fewer members would tell who among them has voted.
+	minAnonymousReminderMembers = 5
+	// reminderTitleLimit shortens the survey title in reminders, keeping the link to the
+	// survey within the message limit
+	reminderTitleLimit = 100
+)
+
+// errReminderGroupTooSmall is returned when reminding about an anonymous survey would
+// single out who has voted
+var errReminderGroupTooSmall = errors.New("too few members to remind anonymously")
+
+// parseReminderBefore reads how long before the deadline to remind, e.g. "90m", "2h" or "1d"
+func parseReminderBefore(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	if before, err := time.ParseDuration(value); err == nil && before > 0 {
+		return before, nil
+	}
+	return 0, fmt.Errorf("%w: %q", errInvalidReminder, value)
+}
+
+// parseRoleID accepts a role mention such as "<@&123>" or a bare role ID
+func parseRoleID(value string) (string, bool) {
+	id := strings.TrimSuffix(strings.TrimPrefix(value, "<@&"), ">")
+	if id == "" {
+		return "", false
+	}
+	for _, r := range id {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+	}
+	return id, true
+}
+
+// reminderJobID keys a survey's automatic reminder in the scheduler, apart from its
+// deadline which is keyed by the message ID alone
+func reminderJobID(messageID string) string {
+	return "remind:" + messageID
+}
+
+// handleRemind reminds the members of a role who have not voted on a survey, e.g.
+// "!remind @出席者", "!remind 123456789 @出席者 --dm". The survey is chosen like !edit
+// and without a role the members who may vote are reminded.
+func (h *surveyHandler) handleRemind(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if m.GuildID == "" {
+		_, err := s.ChannelMessageSend(m.ChannelID, "リマインドはサーバー内で使用してください")
+		return err
+	}
+
+	messageID := ""
+	roleID := ""
+	dm := false
+	for _, arg := range strings.Fields(m.Content)[1:] {
+		switch {
+		case arg == dmFlag:
+			dm = true
+		case strings.HasPrefix(arg, "<@&"):
+			roleID, _ = parseRoleID(arg)
+		default:
+			messageID = arg
+		}
+	}
+
+	survey, err := h.targetSurvey(ctx, m, messageID)
+	if errors.Is(err, types.ErrSurveyNotFound) {
+		_, err := s.ChannelMessageSend(m.ChannelID, "リマインドできるアンケートが見つかりませんでした")
+		return err
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to find survey", err)
+		return err
+	}
+
+	if survey.Closed {
+		_, err := s.ChannelMessageSend(m.ChannelID, "締め切られたアンケートはリマインドできません")
+		return err
+	}
+
+	allowed, err := h.canManageSurvey(s, m, survey)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get member permissions", err)
+		return err
+	}
+	if !allowed {
+		_, err := s.ChannelMessageSend(m.ChannelID, "リマインドできるのは作成者か「メッセージの管理」権限を持つメンバーだけです")
+		return err
+	}
+
+	if roleID == "" {
+		roleID = survey.ReminderRoleID
+	}
+	reminded, err := h.sendReminder(ctx, s, survey, roleID, dm || survey.ReminderDM)
+	if errors.Is(err, errReminderGroupTooSmall) {
+		_, err := s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("匿名アンケートは、誰が回答したかわからないように%d人以上のロールにだけリマインドできます", minAnonymousReminderMembers))
+		return err
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to send reminder", err, types.Field{Key: "message_id", Value: survey.MessageID})
+		_, err := s.ChannelMessageSend(m.ChannelID, "リマインドに失敗しました。Bot に「サーバーメンバー」インテントが有効になっているか確認してください")
+		return err
+	}
+
+	message := fmt.Sprintf("%d人にリマインドしました", reminded)
+	switch {
+	case survey.Anonymous:
+		// Counts would tell how many of the role have voted between two reminders
+		message = "未回答のメンバーにダイレクトメッセージでリマインドしました"
+	case reminded == 0:
+		message = "対象のメンバーは全員回答済みです"
+	}
+	_, err = s.ChannelMessageSend(m.ChannelID, message)
+	return err
+}
+
+// scheduleReminder arranges the survey's automatic reminder, if it has one still to send
+func (h *surveyHandler) scheduleReminder(s *discordgo.Session, survey *types.Survey) {
+	if survey.ReminderBefore <= 0 || survey.Deadline.IsZero() || survey.Reminded || survey.Closed {
+		return
+	}
+
+	messageID := survey.MessageID
+	h.scheduler.Schedule(reminderJobID(messageID), survey.Deadline.Add(-survey.ReminderBefore), func() {
+		h.remindBeforeDeadline(context.Background(), s, messageID)
+	})
+}
+
+func (h *surveyHandler) remindBeforeDeadline(ctx context.Context, s *discordgo.Session, messageID string) {
+	survey, err := h.registry.GetSurvey(ctx, messageID)
+	if err != nil {
+		if !errors.Is(err, types.ErrSurveyNotFound) {
+			h.logger.Error(ctx, "Failed to find survey", err, types.Field{Key: "message_id", Value: messageID})
+		}
+		return
+	}
+	if survey.Closed || survey.Reminded {
+		return
+	}
+
+	reminded, err := h.sendReminder(ctx, s, survey, survey.ReminderRoleID, survey.ReminderDM)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to send reminder", err, types.Field{Key: "message_id", Value: messageID})
+	}
+
+	// Only remind once, even when it failed, so a restart does not repeat it
+	_, err = h.registry.UpdateSurvey(ctx, messageID, func(survey *types.Survey) error {
+		survey.Reminded = true
+		return nil
+	})
+	if err != nil {
+		h.logger.Error(ctx, "Failed to save survey", err, types.Field{Key: "message_id", Value: messageID})
+	}
+
+	h.logger.Info(ctx, "Survey reminder sent",
+		types.Field{Key: "message_id", Value: messageID},
+		types.Field{Key: "count", Value: reminded},
+	)
+}
+
+// sendReminder mentions, or messages directly, the members of roleID who have not voted
+// and returns how many were reminded. Anonymous surveys are always reminded by direct
+// message, since mentions would show who has voted, and only for roles of at least
+// minAnonymousReminderMembers. An empty roleID falls back to the role allowed to vote, or
+// else everyone.
+func (h *surveyHandler) sendReminder(ctx context.Context, s *discordgo.Session, survey *types.Survey, roleID string, dm bool) (int, error) {
+	members, err := guildMembers(s, survey.GuildID)
+	if err != nil {
+		return 0, err
+	}
+	voted, err := surveyVoters(s, survey)
+	if err != nil {
+		return 0, err
+	}
+
+	if roleID == "" {
+		roleID = survey.EligibleRoleID
+	}
+	if roleID == "" {
+		roleID = survey.GuildID
+	}
+	if survey.Anonymous && roleMemberCount(members, survey, roleID) < minAnonymousReminderMembers {
+		return 0, errReminderGroupTooSmall
+	}
+	pending := pendingMembers(members, survey, roleID, voted)
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	link := fmt.Sprintf("https://discord.com/channels/%s/%s/%s", survey.GuildID, survey.ChannelID, survey.MessageID)
+	header := fmt.Sprintf("アンケート「%s」にまだ回答していません", utils.TruncateRunes(survey.Title, reminderTitleLimit))
+	if !survey.Deadline.IsZero() {
+		header += fmt.Sprintf("（締め切り <t:%d:R>）", survey.Deadline.Unix())
+	}
+
+	if dm || survey.Anonymous {
+		return h.remindByDM(ctx, s, pending, header+"\n"+link), nil
+	}
+
+	for _, chunk := range utils.ChunkMentions(header+"\n"+link, pending, utils.MessageContentLimit, utils.MaxMentionsPerMessage) {
+		_, err := s.ChannelMessageSendComplex(survey.ChannelID, &discordgo.MessageSend{
+			Content:         chunk.Content,
+			AllowedMentions: &discordgo.MessageAllowedMentions{Users: chunk.UserIDs},
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(pending), nil
+}
+
+// remindByDM messages each member directly and returns how many messages were delivered.
+// Members who do not accept direct messages are skipped.
+func (h *surveyHandler) remindByDM(ctx context.Context, s *discordgo.Session, userIDs []string, content string) int {
+	delivered := 0
+	for _, id := range userIDs {
+		channel, err := s.UserChannelCreate(id)
+		if err == nil {
+			_, err = s.ChannelMessageSend(channel.ID, content)
+		}
+		if err != nil {
+			h.logger.Debug(ctx, "Failed to send reminder DM", types.Field{Key: "user_id", Value: id})
+			continue
+		}
+		delivered++
+	}
+	return delivered
+}
+
+// guildMembers lists every member of the guild, which needs the Server Members intent
+func guildMembers(s *discordgo.Session, guildID string) ([]*discordgo.Member, error) {
+	var members []*discordgo.Member
+	after := ""
+	for {
+		page, err := s.GuildMembers(guildID, after, guildMembersPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list guild members: %w", err)
+		}
+		members = append(members, page...)
+		if len(page) < guildMembersPageSize {
+			return members, nil
+		}
+		after = page[len(page)-1].User.ID
+	}
+}
+
+// surveyVoters returns the IDs that have voted: the members who reacted with an option
+// emoji on reaction surveys, and the recorded voters, hashed on anonymous surveys, otherwise
+func surveyVoters(s *discordgo.Session, survey *types.Survey) (map[string]bool, error) {
+	votes := survey.Votes
+	if survey.VoteMode == types.VoteModeReaction {
+		var err error
+		votes, err = reactionVotes(s, survey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	voted := make(map[string]bool, len(votes))
+	for _, vote := range votes {
+		if len(vote.Choices) > 0 {
+			voted[vote.UserID] = true
+		}
+	}
+	return voted, nil
+}
+
+// roleMemberCount counts the members with roleID other than bots. The guild ID as roleID
+// stands for @everyone.
+func roleMemberCount(members []*discordgo.Member, survey *types.Survey, roleID string) int {
+	count := 0
+	for _, member := range members {
+		if member.User == nil || member.User.Bot {
+			continue
+		}
+		if roleID == survey.GuildID || hasRole(member, roleID) {
+			count++
+		}
+	}
+	return count
+}
+
+// pendingMembers returns the IDs of the members with roleID, other than bots, who have
+// not voted. The guild ID as roleID stands for @everyone.
+func pendingMembers(members []*discordgo.Member, survey *types.Survey, roleID string, voted map[string]bool) []string {
+	var pending []string
+	for _, member := range members {
+		if member.User == nil || member.User.Bot {
+			continue
+		}
+		if roleID != survey.GuildID && !hasRole(member, roleID) {
+			continue
+		}
+
+		voterID := member.User.ID
+		if survey.Anonymous {
+			voterID = utils.HashVoter(survey.VoterSalt, member.User.ID)
+		}
+		if !voted[voterID] {
+			pending = append(pending, member.User.ID)
+		}
+	}
+	return pending
+}
+
+func hasRole(member *discordgo.Member, roleID string) bool {
+	for _, id := range member.Roles {
+		if id == roleID {
+			return true
+		}
+	}
+	return false
+}
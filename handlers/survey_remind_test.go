@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/Logta/SurveyBot/utils"
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestParseSurveyFlags_Reminder(t *testing.T) {
+	now := time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	t.Run("正常系: 自動リマインドの設定を読み取る", func(t *testing.T) {
+		// Act
+		_, settings, err := parseSurveyFlags([]string{"期限:", "1d", "--remind", "2h", "--remind-role", "<@&42>", "--remind-dm"}, now)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if settings.ReminderBefore != 2*time.Hour || settings.ReminderRoleID != "42" || !settings.ReminderDM {
+			t.Errorf("設定が期待値と異なります: got %+v", settings)
+		}
+	})
+
+	t.Run("異常系: 不正なリマインドの指定", func(t *testing.T) {
+		testCases := []struct {
+			name string
+			args []string
+		}{
+			{"期限なし", []string{"--remind", "2h"}},
+			{"期限より長い", []string{"期限:", "1h", "--remind", "2h"}},
+			{"期間でない", []string{"期限:", "1d", "--remind", "soon"}},
+			{"ロールでない", []string{"--remind-role", "@出席者"}},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				// Act
+				_, _, err := parseSurveyFlags(tc.args, now)
+
+				// Assert
+				if !errors.Is(err, errInvalidReminder) {
+					t.Errorf("errInvalidReminderが期待されていましたが、%vが返されました", err)
+				}
+			})
+		}
+	})
+}
+
+func TestPendingMembers(t *testing.T) {
+	members := []*discordgo.Member{
+		{User: &discordgo.User{ID: "1"}, Roles: []string{"role-1"}},
+		{User: &discordgo.User{ID: "2"}, Roles: []string{"role-1"}},
+		{User: &discordgo.User{ID: "3"}},
+		{User: &discordgo.User{ID: "bot", Bot: true}, Roles: []string{"role-1"}},
+	}
+
+	t.Run("正常系: ロールのメンバーのうち未回答の人", func(t *testing.T) {
+		// Arrange
+		survey := &types.Survey{GuildID: "guild-1"}
+
+		// Act
+		result := pendingMembers(members, survey, "role-1", map[string]bool{"1": true})
+
+		// Assert
+		if !reflect.DeepEqual(result, []string{"2"}) {
+			t.Errorf("未回答のメンバーが期待値と異なります: got %v", result)
+		}
+	})
+
+	t.Run("正常系: ギルドIDは全員を対象にする", func(t *testing.T) {
+		// Arrange
+		survey := &types.Survey{GuildID: "guild-1"}
+
+		// Act
+		result := pendingMembers(members, survey, "guild-1", map[string]bool{"1": true})
+
+		// Assert
+		if !reflect.DeepEqual(result, []string{"2", "3"}) {
+			t.Errorf("未回答のメンバーが期待値と異なります: got %v", result)
+		}
+	})
+
+	t.Run("正常系: 匿名アンケートはハッシュで照合する", func(t *testing.T) {
+		// Arrange
+		survey := &types.Survey{GuildID: "guild-1", VoterSalt: "salt", SurveySettings: types.SurveySettings{Anonymous: true}}
+		voted := map[string]bool{utils.HashVoter("salt", "2"): true}
+
+		// Act
+		result := pendingMembers(members, survey, "role-1", voted)
+
+		// Assert
+		if !reflect.DeepEqual(result, []string{"1"}) {
+			t.Errorf("未回答のメンバーが期待値と異なります: got %v", result)
+		}
+	})
+}
+
+func TestRoleMemberCount(t *testing.T) {
+	members := []*discordgo.Member{
+		{User: &discordgo.User{ID: "1"}, Roles: []string{"role-1"}},
+		{User: &discordgo.User{ID: "2"}},
+		{User: &discordgo.User{ID: "bot", Bot: true}, Roles: []string{"role-1"}},
+	}
+	survey := &types.Survey{GuildID: "guild-1"}
+
+	t.Run("正常系: Botを除いたロールのメンバー数", func(t *testing.T) {
+		// Act
+		count := roleMemberCount(members, survey, "role-1")
+
+		// Assert
+		if count != 1 {
+			t.Errorf("メンバー数が期待値と異なります: got %v, want %v", count, 1)
+		}
+	})
+
+	t.Run("正常系: ギルドIDは全員を数える", func(t *testing.T) {
+		// Act
+		count := roleMemberCount(members, survey, "guild-1")
+
+		// Assert
+		if count != 2 {
+			t.Errorf("メンバー数が期待値と異なります: got %v, want %v", count, 2)
+		}
+	})
+}
+
+func TestSurveyHandler_SendReminder(t *testing.T) {
+	t.Run("異常系: 匿名アンケートは少人数のロールにリマインドしない", func(t *testing.T) {
+		// Arrange
+		members := `[
+			{"user": {"id": "1"}, "roles": ["role-1"]},
+			{"user": {"id": "2"}, "roles": ["role-1"]},
+			{"user": {"id": "3"}}
+		]`
+		survey := &types.Survey{
+			MessageID:      "message-1",
+			GuildID:        "guild-1",
+			ChannelID:      "channel-1",
+			Options:        []string{"A", "B"},
+			VoterSalt:      "salt",
+			SurveySettings: types.SurveySettings{VoteMode: types.VoteModeComponent, Anonymous: true},
+		}
+		handler := &surveyHandler{logger: &mockLogger{}}
+
+		// Act
+		reminded, err := handler.sendReminder(context.Background(), newRESTSession(http.StatusOK, members), survey, "role-1", true)
+
+		// Assert
+		if !errors.Is(err, errReminderGroupTooSmall) {
+			t.Errorf("errReminderGroupTooSmallが期待されていましたが、%vが返されました", err)
+		}
+		if reminded != 0 {
+			t.Errorf("リマインドした人数が期待値と異なります: got %v, want %v", reminded, 0)
+		}
+	})
+}
@@ -0,0 +1,433 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/Logta/SurveyBot/utils"
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxSchedules is the most recurring surveys a guild can have
+const maxSchedules = 25
+
+// errSchedulePaused stops a run from updating a schedule that was paused while it posted
+var errSchedulePaused = errors.New("schedule is paused")
+
+const scheduleUsage = "!schedule add <名前> <曜日> <時刻> [タイムゾーン] [#チャンネル] の後に改行を挟んでタイトルと回答項目を記入してください\n" +
+	"例: !schedule add standup mon 10:00 Asia/Tokyo #general 期限: 2h\n" +
+	"曜日は daily・weekdays・weekends・mon,thu（毎日・平日・週末・月,木）、回答項目の代わりに from <テンプレート名> も指定できます\n" +
+	"!schedule list で一覧、!schedule pause・resume・remove <名前> で停止・再開・削除できます"
+
+// scheduleJobID keys a schedule's next run in the scheduler, apart from survey deadlines
+// which are keyed by message ID
+func scheduleJobID(guildID, name string) string {
+	return "schedule:" + guildID + ":" + strings.ToLower(name)
+}
+
+// handleSchedule manages the guild's recurring surveys:
+//
+//	!schedule add standup mon 10:00 Asia/Tokyo #general 期限: 2h
+//	今週の出席
+//	出席
+//	欠席
+func (h *surveyHandler) handleSchedule(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if m.GuildID == "" {
+		_, err := s.ChannelMessageSend(m.ChannelID, "定期アンケートはサーバー内で使用してください")
+		return err
+	}
+
+	header, body, _ := strings.Cut(strings.ReplaceAll(m.Content, "\r\n", "\n"), "\n")
+	args := strings.Fields(header)[1:]
+	if len(args) == 1 && args[0] == "list" {
+		return h.listSchedules(ctx, s, m)
+	}
+	if len(args) < 2 || (args[0] != "add" && args[0] != "pause" && args[0] != "resume" && args[0] != "remove") {
+		_, err := s.ChannelMessageSend(m.ChannelID, scheduleUsage)
+		return err
+	}
+
+	allowed, err := h.canManageSchedules(s, m)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get member permissions", err)
+		return err
+	}
+	if !allowed {
+		_, err := s.ChannelMessageSend(m.ChannelID, "定期アンケートの設定には「サーバー管理」権限が必要です")
+		return err
+	}
+
+	switch args[0] {
+	case "add":
+		return h.addSchedule(ctx, s, m, args[1], args[2:], body)
+	case "remove":
+		return h.removeSchedule(ctx, s, m, args[1])
+	default:
+		return h.pauseSchedule(ctx, s, m, args[1], args[0] == "pause")
+	}
+}
+
+// canManageSchedules reports whether the author of m may change the guild's recurring
+// surveys, which post in the guild's name and so need the Manage Server permission
+func (h *surveyHandler) canManageSchedules(s *discordgo.Session, m *discordgo.MessageCreate) (bool, error) {
+	permissions, err := s.UserChannelPermissions(m.Author.ID, m.ChannelID)
+	if err != nil {
+		return false, err
+	}
+	return permissions&discordgo.PermissionManageGuild != 0, nil
+}
+
+// scheduleOptions are the words after the time of !schedule add
+type scheduleOptions struct {
+	channelID string
+	template  string
+	flags     []string
+}
+
+// parseScheduleOptions picks out a channel mention and "from <template>", leaving the
+// rest to parseSurveyFlags
+func parseScheduleOptions(args []string) scheduleOptions {
+	var options scheduleOptions
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case strings.HasPrefix(arg, "<#") && strings.HasSuffix(arg, ">"):
+			options.channelID = strings.TrimSuffix(strings.TrimPrefix(arg, "<#"), ">")
+		case arg == templateSource && i+1 < len(args):
+			options.template = args[i+1]
+			i++
+		default:
+			options.flags = append(options.flags, arg)
+		}
+	}
+	return options
+}
+
+func (h *surveyHandler) addSchedule(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, name string, args []string, body string) error {
+	if !templateNamePattern.MatchString(name) {
+		_, err := s.ChannelMessageSend(m.ChannelID, "定期アンケートの名前は32文字以内の英数字・かな漢字・-・_ で指定してください")
+		return err
+	}
+
+	recurrence, consumed, err := utils.ParseRecurrence(args, time.Local)
+	if err != nil {
+		_, err := s.ChannelMessageSend(m.ChannelID, scheduleUsage)
+		return err
+	}
+
+	options := parseScheduleOptions(args[consumed:])
+	now := time.Now()
+	_, settings, err := parseSurveyFlags(options.flags, now)
+	if err != nil {
+		_, sendErr := s.ChannelMessageSend(m.ChannelID, surveyFlagsErrorMessage(err))
+		return sendErr
+	}
+
+	schedule := &types.SurveySchedule{
+		Name:           name,
+		GuildID:        m.GuildID,
+		ChannelID:      m.ChannelID,
+		AuthorID:       m.Author.ID,
+		Recurrence:     recurrence,
+		Template:       options.template,
+		SurveySettings: settings,
+	}
+	// Each run closes the same while after it is posted
+	if !settings.Deadline.IsZero() {
+		schedule.Duration = settings.Deadline.Sub(now).Round(time.Minute)
+		schedule.Deadline = time.Time{}
+	}
+
+	// As when publishing a draft elsewhere, the author and the bot must both be able to
+	// post to the target channel
+	if options.channelID != "" {
+		target := &types.Survey{ChannelID: options.channelID, GuildID: m.GuildID, SurveySettings: settings}
+		if err := h.checkTargetChannel(s, target, m.Author.ID); err != nil {
+			if message, ok := surveyErrorMessage(err); ok {
+				_, err := s.ChannelMessageSend(m.ChannelID, message)
+				return err
+			}
+			h.logger.Error(ctx, "Failed to check target channel", err)
+			return err
+		}
+		schedule.ChannelID = options.channelID
+	}
+
+	if schedule.Template != "" {
+		if _, err := h.templates.GetTemplate(ctx, m.GuildID, schedule.Template); err != nil {
+			if errors.Is(err, types.ErrTemplateNotFound) {
+				_, err := s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("テンプレート「%s」は見つかりませんでした", schedule.Template))
+				return err
+			}
+			h.logger.Error(ctx, "Failed to get template", err)
+			return err
+		}
+	} else {
+		title, surveyOptions, err := h.surveyContents(ctx, m, body)
+		if errors.Is(err, types.ErrSurveyNotFound) {
+			_, err := s.ChannelMessageSend(m.ChannelID, "返信先のアンケートが見つかりませんでした")
+			return err
+		}
+		if errors.Is(err, utils.ErrUnclosedQuote) {
+			_, err := s.ChannelMessageSend(m.ChannelID, unclosedQuoteMessage)
+			return err
+		}
+		if err != nil {
+			h.logger.Error(ctx, "Failed to find survey", err)
+			return err
+		}
+		if title == "" || len(surveyOptions) == 0 {
+			_, err := s.ChannelMessageSend(m.ChannelID, scheduleUsage)
+			return err
+		}
+		schedule.Title, schedule.Options = title, surveyOptions
+	}
+
+	existing, err := h.schedules.ListSchedules(ctx, m.GuildID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list schedules", err)
+		return err
+	}
+	replaced := false
+	for _, other := range existing {
+		replaced = replaced || strings.EqualFold(other.Name, name)
+	}
+	if !replaced && len(existing) >= maxSchedules {
+		_, err := s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("定期アンケートは1つのサーバーに%d個まで設定できます", maxSchedules))
+		return err
+	}
+
+	next, err := utils.NextOccurrence(schedule.Recurrence, now)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to compute next run", err, types.Field{Key: "schedule", Value: name})
+		return err
+	}
+	if err := h.schedules.SaveSchedule(ctx, schedule); err != nil {
+		h.logger.Error(ctx, "Failed to save schedule", err)
+		return err
+	}
+	h.scheduleRun(s, schedule, next)
+
+	_, err = s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("定期アンケート「%s」を設定しました\n%s に <#%s> へ投稿します（次回 <t:%d:f>）",
+		name, utils.FormatRecurrence(schedule.Recurrence), schedule.ChannelID, next.Unix()))
+	return err
+}
+
+func (h *surveyHandler) listSchedules(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
+	schedules, err := h.schedules.ListSchedules(ctx, m.GuildID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list schedules", err)
+		return err
+	}
+
+	if len(schedules) == 0 {
+		_, err := s.ChannelMessageSend(m.ChannelID, "定期アンケートはありません")
+		return err
+	}
+
+	now := time.Now()
+	description := ""
+	for _, schedule := range schedules {
+		title := schedule.Title
+		if schedule.Template != "" {
+			title = "テンプレート「" + schedule.Template + "」"
+		}
+		description += fmt.Sprintf("`%s` : %s <#%s> %s\n", schedule.Name, utils.FormatRecurrence(schedule.Recurrence), schedule.ChannelID, title)
+		if schedule.Paused {
+			description += "　停止中\n"
+		} else if next, err := utils.NextOccurrence(schedule.Recurrence, now); err == nil {
+			description += fmt.Sprintf("　次回 <t:%d:f>\n", next.Unix())
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "定期アンケート",
+		Description: description,
+		Color:       0x141DB8,
+	}
+
+	_, err = s.ChannelMessageSendEmbed(m.ChannelID, embed)
+	return err
+}
+
+func (h *surveyHandler) pauseSchedule(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, name string, paused bool) error {
+	schedule, err := h.schedules.GetSchedule(ctx, m.GuildID, name)
+	if errors.Is(err, types.ErrScheduleNotFound) {
+		_, err := s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("定期アンケート「%s」は見つかりませんでした", name))
+		return err
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get schedule", err)
+		return err
+	}
+
+	schedule.Paused = paused
+	if err := h.schedules.SaveSchedule(ctx, schedule); err != nil {
+		h.logger.Error(ctx, "Failed to save schedule", err)
+		return err
+	}
+
+	if paused {
+		h.scheduler.Cancel(scheduleJobID(schedule.GuildID, schedule.Name))
+		_, err := s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("定期アンケート「%s」を停止しました", schedule.Name))
+		return err
+	}
+
+	next, err := utils.NextOccurrence(schedule.Recurrence, time.Now())
+	if err != nil {
+		h.logger.Error(ctx, "Failed to compute next run", err, types.Field{Key: "schedule", Value: schedule.Name})
+		return err
+	}
+	h.scheduleRun(s, schedule, next)
+
+	_, err = s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("定期アンケート「%s」を再開しました（次回 <t:%d:f>）", schedule.Name, next.Unix()))
+	return err
+}
+
+func (h *surveyHandler) removeSchedule(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, name string) error {
+	err := h.schedules.DeleteSchedule(ctx, m.GuildID, name)
+	if errors.Is(err, types.ErrScheduleNotFound) {
+		_, err := s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("定期アンケート「%s」は見つかりませんでした", name))
+		return err
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to delete schedule", err)
+		return err
+	}
+
+	h.scheduler.Cancel(scheduleJobID(m.GuildID, name))
+
+	_, err = s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("定期アンケート「%s」を削除しました", name))
+	return err
+}
+
+// resumeSchedules arranges the next run of every active schedule. Runs missed while the
+// bot was offline are skipped rather than posted late.
+func (h *surveyHandler) resumeSchedules(ctx context.Context, s *discordgo.Session) error {
+	schedules, err := h.schedules.ListSchedules(ctx, "")
+	if err != nil {
+		return fmt.Errorf("failed to list schedules: %w", err)
+	}
+
+	now := time.Now()
+	scheduled := 0
+	for _, schedule := range schedules {
+		if schedule.Paused {
+			continue
+		}
+		next, err := utils.NextOccurrence(schedule.Recurrence, now)
+		if err != nil {
+			h.logger.Error(ctx, "Failed to compute next run", err, types.Field{Key: "schedule", Value: schedule.Name})
+			continue
+		}
+		h.scheduleRun(s, schedule, next)
+		scheduled++
+	}
+
+	h.logger.Info(ctx, "Survey schedules resumed", types.Field{Key: "count", Value: scheduled})
+	return nil
+}
+
+// scheduleRun arranges for schedule to post its survey at the given time
+func (h *surveyHandler) scheduleRun(s *discordgo.Session, schedule *types.SurveySchedule, at time.Time) {
+	guildID, name := schedule.GuildID, schedule.Name
+	h.scheduler.Schedule(scheduleJobID(guildID, name), at, func() {
+		h.runSchedule(context.Background(), s, guildID, name)
+	})
+}
+
+// runSchedule posts the schedule's survey and arranges its next run. The schedule is read
+// again so edits, pauses and removals since it was arranged take effect.
+func (h *surveyHandler) runSchedule(ctx context.Context, s *discordgo.Session, guildID, name string) {
+	schedule, err := h.schedules.GetSchedule(ctx, guildID, name)
+	if err != nil {
+		if !errors.Is(err, types.ErrScheduleNotFound) {
+			h.logger.Error(ctx, "Failed to get schedule", err, types.Field{Key: "schedule", Value: name})
+		}
+		return
+	}
+	if schedule.Paused {
+		return
+	}
+
+	now := time.Now()
+	if err := h.postScheduledSurvey(ctx, s, schedule, now); err != nil {
+		h.logger.Error(ctx, "Failed to post scheduled survey", err, types.Field{Key: "schedule", Value: name})
+	} else {
+		_, err := h.schedules.UpdateSchedule(ctx, guildID, name, func(schedule *types.SurveySchedule) error {
+			if schedule.Paused {
+				return errSchedulePaused
+			}
+			schedule.LastRun = now
+			return nil
+		})
+		if err != nil && !errors.Is(err, types.ErrScheduleNotFound) && !errors.Is(err, errSchedulePaused) {
+			h.logger.Error(ctx, "Failed to save schedule", err, types.Field{Key: "schedule", Value: name})
+		}
+	}
+
+	// Posting takes a while, so a removal or pause made meanwhile stops the next run
+	schedule, err = h.schedules.GetSchedule(ctx, guildID, name)
+	if err != nil {
+		if !errors.Is(err, types.ErrScheduleNotFound) {
+			h.logger.Error(ctx, "Failed to get schedule", err, types.Field{Key: "schedule", Value: name})
+		}
+		return
+	}
+	if schedule.Paused {
+		return
+	}
+
+	next, err := utils.NextOccurrence(schedule.Recurrence, now)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to compute next run", err, types.Field{Key: "schedule", Value: name})
+		return
+	}
+	h.scheduleRun(s, schedule, next)
+}
+
+// postScheduledSurvey publishes one run of schedule, telling its channel when the survey
+// cannot be posted as configured
+func (h *surveyHandler) postScheduledSurvey(ctx context.Context, s *discordgo.Session, schedule *types.SurveySchedule, now time.Time) error {
+	survey := &types.Survey{
+		ChannelID:      schedule.ChannelID,
+		GuildID:        schedule.GuildID,
+		AuthorID:       schedule.AuthorID,
+		Title:          schedule.Title,
+		Options:        schedule.Options,
+		SurveySettings: schedule.SurveySettings,
+	}
+	if schedule.Duration > 0 {
+		survey.Deadline = now.Add(schedule.Duration)
+	}
+
+	if schedule.Template != "" {
+		template, err := h.templates.GetTemplate(ctx, schedule.GuildID, schedule.Template)
+		if errors.Is(err, types.ErrTemplateNotFound) {
+			_, sendErr := s.ChannelMessageSend(schedule.ChannelID, fmt.Sprintf("定期アンケート「%s」のテンプレート「%s」が見つからないため投稿できませんでした", schedule.Name, schedule.Template))
+			return errors.Join(err, sendErr)
+		}
+		if err != nil {
+			return err
+		}
+		survey.Title, survey.Options = template.Title, template.Options
+	}
+
+	if err := h.createSurveyEmbed(ctx, s, survey); err != nil {
+		if message, ok := surveyErrorMessage(err); ok {
+			_, sendErr := s.ChannelMessageSend(schedule.ChannelID, fmt.Sprintf("定期アンケート「%s」を投稿できませんでした: %s", schedule.Name, message))
+			return errors.Join(err, sendErr)
+		}
+		return err
+	}
+
+	h.logger.Info(ctx, "Scheduled survey posted",
+		types.Field{Key: "schedule", Value: schedule.Name},
+		types.Field{Key: "message_id", Value: survey.MessageID},
+	)
+	return nil
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestParseScheduleOptions(t *testing.T) {
+	t.Run("正常系: チャンネルとテンプレートとフラグを分ける", func(t *testing.T) {
+		// Act
+		result := parseScheduleOptions([]string{"<#123456>", "from", "standup", "--single", "期限:", "2h"})
+
+		// Assert
+		expected := scheduleOptions{channelID: "123456", template: "standup", flags: []string{"--single", "期限:", "2h"}}
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("解析結果が期待値と異なります: got %+v, want %+v", result, expected)
+		}
+	})
+
+	t.Run("正常系: 指定がなければ空", func(t *testing.T) {
+		// Act
+		result := parseScheduleOptions(nil)
+
+		// Assert
+		if !reflect.DeepEqual(result, scheduleOptions{}) {
+			t.Errorf("解析結果が期待値と異なります: got %+v", result)
+		}
+	})
+}
+
+func TestSurveyHandler_OnStart_Schedules(t *testing.T) {
+	t.Run("正常系: 停止していない定期アンケートの次回を登録する", func(t *testing.T) {
+		// Arrange
+		recurrence := types.Recurrence{Weekdays: []time.Weekday{time.Monday}, Hour: 10, Location: "UTC"}
+		schedules := &mockScheduleStore{schedules: map[string]*types.SurveySchedule{
+			"guild-1:standup": {Name: "standup", GuildID: "guild-1", Recurrence: recurrence},
+			"guild-1:retro":   {Name: "retro", GuildID: "guild-1", Recurrence: recurrence, Paused: true},
+		}}
+		scheduler := &mockScheduler{}
+		handler := NewSurveyHandler(&mockStateManager{}, &mockSurveyRegistry{}, &mockGuildSettingsStore{}, &mockTemplateStore{}, schedules, scheduler, &mockEmojiProvider{}, &mockLogger{}).(types.StartupHandler)
+
+		// Act
+		err := handler.OnStart(context.Background(), nil)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if len(scheduler.jobs) != 1 {
+			t.Fatalf("登録されたジョブ数が期待値と異なります: got %v, want %v", len(scheduler.jobs), 1)
+		}
+		at, exists := scheduler.jobs[scheduleJobID("guild-1", "standup")]
+		if !exists || at.Weekday() != time.Monday || at.Hour() != 10 || !at.After(time.Now()) {
+			t.Errorf("ジョブの実行時刻が期待値と異なります: got %v", at)
+		}
+	})
+
+	t.Run("異常系: 定期アンケートの取得に失敗", func(t *testing.T) {
+		// Arrange
+		schedules := &mockScheduleStore{err: context.DeadlineExceeded}
+		handler := NewSurveyHandler(&mockStateManager{}, &mockSurveyRegistry{}, &mockGuildSettingsStore{}, &mockTemplateStore{}, schedules, &mockScheduler{}, &mockEmojiProvider{}, &mockLogger{}).(types.StartupHandler)
+
+		// Act
+		err := handler.OnStart(context.Background(), nil)
+
+		// Assert
+		if err == nil {
+			t.Error("エラーが期待されていましたが、nilが返されました")
+		}
+	})
+}
+
+func TestSurveyHandler_RunSchedule_ChangedWhilePosting(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(schedules *mockScheduleStore)
+		paused bool
+	}{
+		{"正常系: 投稿中に停止されたら停止したままにする", func(schedules *mockScheduleStore) {
+			schedules.schedules["guild-1:standup"].Paused = true
+		}, true},
+		{"正常系: 投稿中に削除されたら元に戻さない", func(schedules *mockScheduleStore) {
+			delete(schedules.schedules, "guild-1:standup")
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			recurrence := types.Recurrence{Weekdays: []time.Weekday{time.Monday}, Hour: 10, Location: "UTC"}
+			schedules := &mockScheduleStore{schedules: map[string]*types.SurveySchedule{
+				"guild-1:standup": {
+					Name: "standup", GuildID: "guild-1", ChannelID: "channel-1", Recurrence: recurrence,
+					Title: "今週の出席", Options: []string{"出席", "欠席"},
+					SurveySettings: types.SurveySettings{VoteMode: types.VoteModeComponent},
+				},
+			}}
+			scheduler := &mockScheduler{}
+			handler := NewSurveyHandler(&mockStateManager{}, &mockSurveyRegistry{}, &mockGuildSettingsStore{}, &mockTemplateStore{}, schedules, scheduler, &mockEmojiProvider{}, &mockLogger{}).(*surveyHandler)
+
+			s := newRESTSession(http.StatusOK, `{"id": "message-1", "channel_id": "channel-1"}`)
+			transport := s.Client.Transport
+			s.Client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				// The schedule is changed while its survey is being posted
+				tt.change(schedules)
+				return transport.RoundTrip(r)
+			})
+
+			// Act
+			handler.runSchedule(context.Background(), s, "guild-1", "standup")
+
+			// Assert
+			schedule, err := schedules.GetSchedule(context.Background(), "guild-1", "standup")
+			if tt.paused {
+				if err != nil || !schedule.Paused || !schedule.LastRun.IsZero() {
+					t.Errorf("停止したスケジュールが変更されました: got %+v, %v", schedule, err)
+				}
+			} else if !errors.Is(err, types.ErrScheduleNotFound) {
+				t.Errorf("削除したスケジュールが戻されました: got %+v", schedule)
+			}
+			if _, exists := scheduler.jobs[scheduleJobID("guild-1", "standup")]; exists {
+				t.Error("次回の投稿が登録されました")
+			}
+		})
+	}
+}
+
+func TestSurveyHandler_AddSchedule_TargetChannel(t *testing.T) {
+	var allowed int64 = discordgo.PermissionViewChannel | discordgo.PermissionSendMessages | discordgo.PermissionEmbedLinks |
+		discordgo.PermissionAddReactions | discordgo.PermissionReadMessageHistory
+
+	tests := []struct {
+		name        string
+		permissions int64
+		saved       bool
+	}{
+		{"正常系: 書き込めるチャンネルには登録する", allowed, true},
+		{"異常系: 書き込めないチャンネルには登録しない", discordgo.PermissionViewChannel, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			schedules := &mockScheduleStore{}
+			handler := NewSurveyHandler(&mockStateManager{}, &mockSurveyRegistry{}, &mockGuildSettingsStore{}, &mockTemplateStore{}, schedules, &mockScheduler{}, &mockEmojiProvider{}, &mockLogger{}).(*surveyHandler)
+
+			s := newRESTSession(http.StatusOK, `{"id": "reply-1", "channel_id": "channel-1"}`)
+			s.State = newPermissionSession("guild-1", "announce", map[string]int64{"author": tt.permissions, "bot": allowed}).State
+			s.State.User = &discordgo.User{ID: "bot"}
+			transport := s.Client.Transport
+			var sent []string
+			s.Client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				if r.Body != nil {
+					body, _ := io.ReadAll(r.Body)
+					sent = append(sent, string(body))
+				}
+				return transport.RoundTrip(r)
+			})
+			m := &discordgo.MessageCreate{Message: &discordgo.Message{
+				GuildID:   "guild-1",
+				ChannelID: "channel-1",
+				Author:    &discordgo.User{ID: "author"},
+			}}
+
+			// Act
+			err := handler.addSchedule(context.Background(), s, m, "standup", []string{"mon", "10:00", "<#announce>"}, "今週の出席\n出席\n欠席")
+
+			// Assert
+			if err != nil {
+				t.Fatalf("期待していないエラーが発生: %v", err)
+			}
+			schedule, getErr := schedules.GetSchedule(context.Background(), "guild-1", "standup")
+			if tt.saved {
+				if getErr != nil || schedule.ChannelID != "announce" {
+					t.Errorf("スケジュールが期待値と異なります: got %+v, %v", schedule, getErr)
+				}
+				return
+			}
+			if !errors.Is(getErr, types.ErrScheduleNotFound) {
+				t.Errorf("書き込めないチャンネルのスケジュールが登録されました: got %+v", schedule)
+			}
+			if len(sent) != 1 || !strings.Contains(sent[0], "announce") {
+				t.Errorf("権限がない旨の返信が期待されていましたが、%qが送られました", sent)
+			}
+		})
+	}
+}
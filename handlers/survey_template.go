@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/Logta/SurveyBot/utils"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// templateSource is the !survey argument that publishes a template, as in "!survey from lunch"
+	templateSource = "from"
+	// maxTemplates is the most templates a guild can save
+	maxTemplates = 50
+)
+
+// templateNamePattern keeps template names to a single short word
+var templateNamePattern = regexp.MustCompile(`^[\p{L}\p{N}_-]{1,32}$`)
+
+const templateUsage = "!template save <名前> の後に改行を挟んでタイトルと回答項目を記入するか、アンケートに返信して実行してください\n" +
+	"!template list で一覧、!template delete <名前> で削除、!survey from <名前> でテンプレートからアンケートを作成できます"
+
+// handleTemplate saves, lists and deletes the guild's survey templates:
+//
+//	!template save lunch
+//	お昼どうする？
+//	カレー
+//	ラーメン
+func (h *surveyHandler) handleTemplate(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
+	if m.GuildID == "" {
+		_, err := s.ChannelMessageSend(m.ChannelID, "テンプレートはサーバー内で使用してください")
+		return err
+	}
+
+	header, body, _ := strings.Cut(strings.ReplaceAll(m.Content, "\r\n", "\n"), "\n")
+	args := strings.Fields(header)[1:]
+	switch {
+	case len(args) == 1 && args[0] == "list":
+		return h.listTemplates(ctx, s, m)
+	case len(args) == 2 && args[0] == "save":
+		return h.saveTemplate(ctx, s, m, args[1], body)
+	case len(args) == 2 && args[0] == "delete":
+		return h.deleteTemplate(ctx, s, m, args[1])
+	}
+
+	_, err := s.ChannelMessageSend(m.ChannelID, templateUsage)
+	return err
+}
+
+// surveyContents reads a title and options from the lines after a command such as
+// !template save, or from the survey the command replies to
+func (h *surveyHandler) surveyContents(ctx context.Context, m *discordgo.MessageCreate, body string) (string, []string, error) {
+	if strings.TrimSpace(body) == "" && m.MessageReference != nil {
+		survey, err := h.registry.GetSurvey(ctx, m.MessageReference.MessageID)
+		if err != nil {
+			return "", nil, err
+		}
+		return survey.Title, survey.Options, nil
+	}
+
+	title, rest, _ := strings.Cut(strings.TrimSpace(body), "\n")
+	options, err := utils.ParseLines(rest)
+	if err != nil {
+		return "", nil, err
+	}
+	return strings.TrimSpace(title), options, nil
+}
+
+func (h *surveyHandler) saveTemplate(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, name, body string) error {
+	if !templateNamePattern.MatchString(name) {
+		_, err := s.ChannelMessageSend(m.ChannelID, "テンプレート名は32文字以内の英数字・かな漢字・-・_ で指定してください")
+		return err
+	}
+
+	title, options, err := h.surveyContents(ctx, m, body)
+	if errors.Is(err, types.ErrSurveyNotFound) {
+		_, err := s.ChannelMessageSend(m.ChannelID, "返信先のアンケートが見つかりませんでした")
+		return err
+	}
+	if errors.Is(err, utils.ErrUnclosedQuote) {
+		_, err := s.ChannelMessageSend(m.ChannelID, unclosedQuoteMessage)
+		return err
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to find survey", err)
+		return err
+	}
+	if title == "" || len(options) == 0 {
+		_, err := s.ChannelMessageSend(m.ChannelID, templateUsage)
+		return err
+	}
+
+	existing, err := h.templates.GetTemplate(ctx, m.GuildID, name)
+	switch {
+	case errors.Is(err, types.ErrTemplateNotFound):
+		templates, err := h.templates.ListTemplates(ctx, m.GuildID)
+		if err != nil {
+			h.logger.Error(ctx, "Failed to list templates", err)
+			return err
+		}
+		if len(templates) >= maxTemplates {
+			_, err := s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("テンプレートは1つのサーバーに%d個まで保存できます", maxTemplates))
+			return err
+		}
+	case err != nil:
+		h.logger.Error(ctx, "Failed to get template", err)
+		return err
+	default:
+		allowed, err := h.canManageTemplate(s, m, existing)
+		if err != nil {
+			h.logger.Error(ctx, "Failed to get member permissions", err)
+			return err
+		}
+		if !allowed {
+			_, err := s.ChannelMessageSend(m.ChannelID, "他のメンバーのテンプレートを上書きするには「サーバー管理」権限が必要です")
+			return err
+		}
+	}
+
+	template := &types.SurveyTemplate{
+		Name:      name,
+		Title:     title,
+		Options:   options,
+		AuthorID:  m.Author.ID,
+		UpdatedAt: time.Now(),
+	}
+	if err := h.templates.SaveTemplate(ctx, m.GuildID, template); err != nil {
+		h.logger.Error(ctx, "Failed to save template", err)
+		return err
+	}
+
+	_, err = s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("テンプレート「%s」を保存しました（回答項目%d個）\n%s from %s で作成できます", name, len(options), types.CmdSurvey, name))
+	return err
+}
+
+func (h *surveyHandler) listTemplates(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate) error {
+	templates, err := h.templates.ListTemplates(ctx, m.GuildID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list templates", err)
+		return err
+	}
+
+	if len(templates) == 0 {
+		_, err := s.ChannelMessageSend(m.ChannelID, "保存されたテンプレートはありません")
+		return err
+	}
+
+	description := ""
+	for _, template := range templates {
+		description += fmt.Sprintf("`%s` : %s (%d項目)\n", template.Name, template.Title, len(template.Options))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "アンケートのテンプレート",
+		Description: description,
+		Color:       0x141DB8,
+		Footer:      &discordgo.MessageEmbedFooter{Text: string(types.CmdSurvey) + " from <名前> でアンケートを作成できます"},
+	}
+
+	_, err = s.ChannelMessageSendEmbed(m.ChannelID, embed)
+	return err
+}
+
+func (h *surveyHandler) deleteTemplate(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, name string) error {
+	template, err := h.templates.GetTemplate(ctx, m.GuildID, name)
+	if errors.Is(err, types.ErrTemplateNotFound) {
+		_, err := s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("テンプレート「%s」は見つかりませんでした", name))
+		return err
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get template", err)
+		return err
+	}
+
+	allowed, err := h.canManageTemplate(s, m, template)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get member permissions", err)
+		return err
+	}
+	if !allowed {
+		_, err := s.ChannelMessageSend(m.ChannelID, "他のメンバーのテンプレートを削除するには「サーバー管理」権限が必要です")
+		return err
+	}
+
+	if err := h.templates.DeleteTemplate(ctx, m.GuildID, name); err != nil {
+		h.logger.Error(ctx, "Failed to delete template", err)
+		return err
+	}
+
+	_, err = s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("テンプレート「%s」を削除しました", template.Name))
+	return err
+}
+
+// canManageTemplate reports whether the author of m may replace or delete template: its
+// author always can, other members need the Manage Server permission
+func (h *surveyHandler) canManageTemplate(s *discordgo.Session, m *discordgo.MessageCreate, template *types.SurveyTemplate) (bool, error) {
+	if m.Author.ID == template.AuthorID {
+		return true, nil
+	}
+
+	permissions, err := s.UserChannelPermissions(m.Author.ID, m.ChannelID)
+	if err != nil {
+		return false, err
+	}
+	return permissions&discordgo.PermissionManageGuild != 0, nil
+}
+
+// handleSurveyFromTemplate publishes a saved template right away. The flags of !survey
+// apply, e.g. "!survey from lunch --single 期限: 2h".
+func (h *surveyHandler) handleSurveyFromTemplate(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, args []string) error {
+	if m.GuildID == "" {
+		_, err := s.ChannelMessageSend(m.ChannelID, "テンプレートはサーバー内で使用してください")
+		return err
+	}
+	if len(args) == 0 {
+		_, err := s.ChannelMessageSend(m.ChannelID, string(types.CmdSurvey)+" from <名前> でテンプレートを指定してください")
+		return err
+	}
+
+	_, settings, err := parseSurveyFlags(args[1:], time.Now())
+	if err != nil {
+		_, sendErr := s.ChannelMessageSend(m.ChannelID, surveyFlagsErrorMessage(err))
+		return sendErr
+	}
+
+	template, err := h.templates.GetTemplate(ctx, m.GuildID, args[0])
+	if errors.Is(err, types.ErrTemplateNotFound) {
+		_, err := s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("テンプレート「%s」は見つかりませんでした", args[0]))
+		return err
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get template", err)
+		return err
+	}
+
+	return h.publishSurvey(ctx, s, m, &types.Survey{
+		ChannelID:      m.ChannelID,
+		GuildID:        m.GuildID,
+		AuthorID:       m.Author.ID,
+		Title:          template.Title,
+		Options:        template.Options,
+		SurveySettings: settings,
+	})
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import "testing"
+
+func TestTemplateNamePattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected bool
+	}{
+		{"正常系: 英数字", "lunch_2", true},
+		{"正常系: 日本語", "お昼ごはん", true},
+		{"異常系: 空白を含む", "lunch menu", false},
+		{"異常系: 33文字", "abcdefghijklmnopqrstuvwxyz0123456", false},
+		{"異常系: 空文字", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			result := templateNamePattern.MatchString(tt.input)
+
+			// Assert
+			if result != tt.expected {
+				t.Errorf("templateNamePattern.MatchString(%q) = %v, want %v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
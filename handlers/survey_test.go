@@ -3,9 +3,14 @@ package handlers
 import (
 	"context"
 	"errors"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/Logta/SurveyBot/types"
+	"github.com/bwmarrin/discordgo"
 )
 
 // モックの実装
@@ -14,32 +19,315 @@ type mockStateManager struct {
 	err    error
 }
 
-func (m *mockStateManager) GetState(ctx context.Context, guildID string) (*types.SurveyState, error) {
+func (m *mockStateManager) GetState(ctx context.Context, key string) (*types.SurveyState, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
-	if state, exists := m.states[guildID]; exists {
-		return &types.SurveyState{Active: state.Active, Title: state.Title}, nil
+	if state, exists := m.states[key]; exists {
+		copied := *state
+		return &copied, nil
 	}
 	return &types.SurveyState{}, nil
 }
 
-func (m *mockStateManager) SetState(ctx context.Context, guildID string, state *types.SurveyState) error {
+func (m *mockStateManager) SetState(ctx context.Context, key string, state *types.SurveyState) error {
 	if m.err != nil {
 		return m.err
 	}
 	if m.states == nil {
 		m.states = make(map[string]*types.SurveyState)
 	}
-	m.states[guildID] = &types.SurveyState{Active: state.Active, Title: state.Title}
+	copied := *state
+	m.states[key] = &copied
 	return nil
 }
 
-func (m *mockStateManager) ClearState(ctx context.Context, guildID string) error {
+func (m *mockStateManager) ClearState(ctx context.Context, key string) error {
 	if m.err != nil {
 		return m.err
 	}
-	delete(m.states, guildID)
+	delete(m.states, key)
+	return nil
+}
+
+func (m *mockStateManager) CompareAndClearState(ctx context.Context, key string, expected *types.SurveyState) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	state, exists := m.states[key]
+	if !exists || !reflect.DeepEqual(state, expected) {
+		return false, nil
+	}
+	delete(m.states, key)
+	return true, nil
+}
+
+func (m *mockStateManager) ListActiveStates(ctx context.Context, guildID string) ([]*types.SurveyState, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var states []*types.SurveyState
+	for _, state := range m.states {
+		if state.Active && state.GuildID == guildID {
+			states = append(states, state)
+		}
+	}
+	return states, nil
+}
+
+type mockSurveyRegistry struct {
+	surveys map[string]*types.Survey
+	err     error
+}
+
+func (m *mockSurveyRegistry) SaveSurvey(ctx context.Context, survey *types.Survey) error {
+	if m.err != nil {
+		return m.err
+	}
+	if m.surveys == nil {
+		m.surveys = make(map[string]*types.Survey)
+	}
+	m.surveys[survey.MessageID] = survey
+	return nil
+}
+
+func (m *mockSurveyRegistry) GetSurvey(ctx context.Context, messageID string) (*types.Survey, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if survey, exists := m.surveys[messageID]; exists {
+		return survey, nil
+	}
+	return nil, types.ErrSurveyNotFound
+}
+
+func (m *mockSurveyRegistry) GetLatestSurvey(ctx context.Context, channelID string) (*types.Survey, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var latest *types.Survey
+	for _, survey := range m.surveys {
+		if survey.ChannelID == channelID && (latest == nil || survey.CreatedAt.After(latest.CreatedAt)) {
+			latest = survey
+		}
+	}
+	if latest == nil {
+		return nil, types.ErrSurveyNotFound
+	}
+	return latest, nil
+}
+
+func (m *mockSurveyRegistry) UpdateSurvey(ctx context.Context, messageID string, update func(*types.Survey) error) (*types.Survey, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	survey, exists := m.surveys[messageID]
+	if !exists {
+		return nil, types.ErrSurveyNotFound
+	}
+	updated := *survey
+	if err := update(&updated); err != nil {
+		return nil, err
+	}
+	m.surveys[messageID] = &updated
+	return &updated, nil
+}
+
+func (m *mockSurveyRegistry) RecordVote(ctx context.Context, messageID string, vote types.Vote) (*types.Survey, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	survey, exists := m.surveys[messageID]
+	if !exists {
+		return nil, types.ErrSurveyNotFound
+	}
+	if survey.Closed {
+		return nil, types.ErrSurveyClosed
+	}
+	var votes []types.Vote
+	for _, existing := range survey.Votes {
+		if existing.UserID != vote.UserID {
+			votes = append(votes, existing)
+		}
+	}
+	if len(vote.Choices) > 0 {
+		votes = append(votes, vote)
+	}
+	survey.Votes = votes
+	return survey, nil
+}
+
+func (m *mockSurveyRegistry) ListOpenSurveys(ctx context.Context) ([]*types.Survey, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var surveys []*types.Survey
+	for _, survey := range m.surveys {
+		if !survey.Closed {
+			surveys = append(surveys, survey)
+		}
+	}
+	return surveys, nil
+}
+
+type mockScheduler struct {
+	jobs map[string]time.Time
+}
+
+func (m *mockScheduler) Schedule(id string, at time.Time, job func()) {
+	if m.jobs == nil {
+		m.jobs = make(map[string]time.Time)
+	}
+	m.jobs[id] = at
+}
+
+func (m *mockScheduler) Cancel(id string) {
+	delete(m.jobs, id)
+}
+
+func (m *mockScheduler) Stop() {
+	m.jobs = nil
+}
+
+type mockTemplateStore struct {
+	templates map[string]*types.SurveyTemplate
+	err       error
+}
+
+func (m *mockTemplateStore) SaveTemplate(ctx context.Context, guildID string, template *types.SurveyTemplate) error {
+	if m.err != nil {
+		return m.err
+	}
+	if m.templates == nil {
+		m.templates = make(map[string]*types.SurveyTemplate)
+	}
+	copied := *template
+	m.templates[guildID+":"+strings.ToLower(template.Name)] = &copied
+	return nil
+}
+
+func (m *mockTemplateStore) GetTemplate(ctx context.Context, guildID, name string) (*types.SurveyTemplate, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	template, exists := m.templates[guildID+":"+strings.ToLower(name)]
+	if !exists {
+		return nil, types.ErrTemplateNotFound
+	}
+	copied := *template
+	return &copied, nil
+}
+
+func (m *mockTemplateStore) ListTemplates(ctx context.Context, guildID string) ([]*types.SurveyTemplate, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var templates []*types.SurveyTemplate
+	for key, template := range m.templates {
+		if strings.HasPrefix(key, guildID+":") {
+			templates = append(templates, template)
+		}
+	}
+	return templates, nil
+}
+
+func (m *mockTemplateStore) DeleteTemplate(ctx context.Context, guildID, name string) error {
+	if _, err := m.GetTemplate(ctx, guildID, name); err != nil {
+		return err
+	}
+	delete(m.templates, guildID+":"+strings.ToLower(name))
+	return nil
+}
+
+type mockScheduleStore struct {
+	schedules map[string]*types.SurveySchedule
+	err       error
+}
+
+func (m *mockScheduleStore) SaveSchedule(ctx context.Context, schedule *types.SurveySchedule) error {
+	if m.err != nil {
+		return m.err
+	}
+	if m.schedules == nil {
+		m.schedules = make(map[string]*types.SurveySchedule)
+	}
+	copied := *schedule
+	m.schedules[schedule.GuildID+":"+strings.ToLower(schedule.Name)] = &copied
+	return nil
+}
+
+func (m *mockScheduleStore) GetSchedule(ctx context.Context, guildID, name string) (*types.SurveySchedule, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	schedule, exists := m.schedules[guildID+":"+strings.ToLower(name)]
+	if !exists {
+		return nil, types.ErrScheduleNotFound
+	}
+	copied := *schedule
+	return &copied, nil
+}
+
+func (m *mockScheduleStore) UpdateSchedule(ctx context.Context, guildID, name string, update func(*types.SurveySchedule) error) (*types.SurveySchedule, error) {
+	updated, err := m.GetSchedule(ctx, guildID, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := update(updated); err != nil {
+		return nil, err
+	}
+	m.schedules[guildID+":"+strings.ToLower(name)] = updated
+	copied := *updated
+	return &copied, nil
+}
+
+func (m *mockScheduleStore) ListSchedules(ctx context.Context, guildID string) ([]*types.SurveySchedule, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var schedules []*types.SurveySchedule
+	for _, schedule := range m.schedules {
+		if guildID == "" || schedule.GuildID == guildID {
+			copied := *schedule
+			schedules = append(schedules, &copied)
+		}
+	}
+	return schedules, nil
+}
+
+func (m *mockScheduleStore) DeleteSchedule(ctx context.Context, guildID, name string) error {
+	if _, err := m.GetSchedule(ctx, guildID, name); err != nil {
+		return err
+	}
+	delete(m.schedules, guildID+":"+strings.ToLower(name))
+	return nil
+}
+
+type mockGuildSettingsStore struct {
+	settings map[string]*types.GuildSettings
+	err      error
+}
+
+func (m *mockGuildSettingsStore) GetGuildSettings(ctx context.Context, guildID string) (*types.GuildSettings, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if settings, exists := m.settings[guildID]; exists {
+		copied := *settings
+		return &copied, nil
+	}
+	return &types.GuildSettings{}, nil
+}
+
+func (m *mockGuildSettingsStore) SetGuildSettings(ctx context.Context, guildID string, settings *types.GuildSettings) error {
+	if m.err != nil {
+		return m.err
+	}
+	if m.settings == nil {
+		m.settings = make(map[string]*types.GuildSettings)
+	}
+	copied := *settings
+	m.settings[guildID] = &copied
 	return nil
 }
 
@@ -63,28 +351,36 @@ func (m *mockEmojiProvider) GetMaxEmojis() int {
 }
 
 type mockLogger struct {
+	mu   sync.Mutex
 	logs []string
 }
 
+func (m *mockLogger) log(line string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.logs = append(m.logs, line)
+}
+
 func (m *mockLogger) Info(ctx context.Context, msg string, fields ...types.Field) {
-	m.logs = append(m.logs, "INFO: "+msg)
+	m.log("INFO: " + msg)
 }
 
 func (m *mockLogger) Error(ctx context.Context, msg string, err error, fields ...types.Field) {
-	m.logs = append(m.logs, "ERROR: "+msg+" - "+err.Error())
+	m.log("ERROR: " + msg + " - " + err.Error())
 }
 
 func (m *mockLogger) Debug(ctx context.Context, msg string, fields ...types.Field) {
-	m.logs = append(m.logs, "DEBUG: "+msg)
+	m.log("DEBUG: " + msg)
 }
 
 func TestSurveyHandler_Name(t *testing.T) {
 	t.Run("正常系: ハンドラー名を取得", func(t *testing.T) {
 		// Arrange
 		stateManager := &mockStateManager{}
+		registry := &mockSurveyRegistry{}
 		emojiProvider := &mockEmojiProvider{}
 		logger := &mockLogger{}
-		handler := NewSurveyHandler(stateManager, emojiProvider, logger)
+		handler := NewSurveyHandler(stateManager, registry, &mockGuildSettingsStore{}, &mockTemplateStore{}, &mockScheduleStore{}, &mockScheduler{}, emojiProvider, logger)
 
 		// Act
 		name := handler.Name()
@@ -101,22 +397,57 @@ func TestSurveyHandler_CanHandle(t *testing.T) {
 	t.Run("正常系: 対応可能なコマンドの判定", func(t *testing.T) {
 		// Arrange
 		stateManager := &mockStateManager{}
+		registry := &mockSurveyRegistry{}
 		emojiProvider := &mockEmojiProvider{}
 		logger := &mockLogger{}
-		handler := NewSurveyHandler(stateManager, emojiProvider, logger)
+		handler := NewSurveyHandler(stateManager, registry, &mockGuildSettingsStore{}, &mockTemplateStore{}, &mockScheduleStore{}, &mockScheduler{}, emojiProvider, logger)
 
 		testCases := []struct {
 			command  string
 			expected bool
 		}{
 			{"!survey", true},
+			{"!survey --shared", true},
+			{"!survey --buttons", true},
+			{"!survey --single", true},
+			{"!survey --max 2", true},
+			{"!survey --anon", true},
+			{"!survey --emoji alphabet", true},
+			{"!emoji", true},
+			{"!emoji circle", true},
+			{"!emojis", false},
+			{"!rank", true},
+			{"!rank 2 1 3", true},
+			{"!ranking", false},
+			{"!edit title", true},
+			{"!edit\n新しいタイトル", true},
+			{"!editor", false},
+			{"!export", true},
+			{"!export json", true},
+			{"!template list", true},
+			{"!template save lunch\nお昼\nカレー", true},
+			{"!templates", false},
+			{"!schedule list", true},
+			{"!schedule add standup mon 10:00\n出席確認\n出席", true},
+			{"!scheduler", false},
+			{"!poll お昼は？\nカレー\nそば", true},
+			{"!poll", true},
+			{"!publish", true},
+			{"!publisher", false},
+			{"!polls", false},
+			{"!remind", true},
+			{"!remind 123456789 --dm", true},
+			{"!reminder", false},
 			{"!title", true},
 			{"!title テストタイトル", true},
 			{"!content", true},
 			{"!content 選択肢1", true},
 			{"!cancel", true},
+			{"!close", true},
+			{"!close 123456789", true},
 			{"!check state", true},
 			{"!check title", true},
+			{"!drafts", true},
 			{"!help", false},
 			{"!shuffle", false},
 			{"hello", false},
@@ -216,3 +547,264 @@ func TestSurveyHandler_StateManagement(t *testing.T) {
 		}
 	})
 }
+
+func TestDraftKey(t *testing.T) {
+	t.Run("正常系: チャンネルと作成者ごとに別の下書きになる", func(t *testing.T) {
+		// Arrange
+		message := func(channelID, authorID string) *discordgo.MessageCreate {
+			return &discordgo.MessageCreate{Message: &discordgo.Message{
+				GuildID:   "guild",
+				ChannelID: channelID,
+				Author:    &discordgo.User{ID: authorID},
+			}}
+		}
+
+		// Act
+		keys := []string{
+			draftKey(message("channel-1", "user-1"), false).String(),
+			draftKey(message("channel-2", "user-1"), false).String(),
+			draftKey(message("channel-1", "user-2"), false).String(),
+			draftKey(message("channel-1", "user-1"), true).String(),
+		}
+
+		// Assert
+		seen := make(map[string]bool)
+		for _, key := range keys {
+			if seen[key] {
+				t.Errorf("下書きのキーが重複しています: %v", key)
+			}
+			seen[key] = true
+		}
+	})
+
+	t.Run("正常系: 共有の下書きは作成者に依存しない", func(t *testing.T) {
+		// Arrange
+		first := &discordgo.MessageCreate{Message: &discordgo.Message{
+			GuildID: "guild", ChannelID: "channel", Author: &discordgo.User{ID: "user-1"},
+		}}
+		second := &discordgo.MessageCreate{Message: &discordgo.Message{
+			GuildID: "guild", ChannelID: "channel", Author: &discordgo.User{ID: "user-2"},
+		}}
+
+		// Act
+		firstKey := draftKey(first, true)
+		secondKey := draftKey(second, true)
+
+		// Assert
+		if firstKey != secondKey {
+			t.Errorf("共有の下書きのキーが一致しません: %v != %v", firstKey, secondKey)
+		}
+	})
+
+	t.Run("正常系: DMの下書きもユーザーごとに分かれる", func(t *testing.T) {
+		// Arrange
+		first := &discordgo.MessageCreate{Message: &discordgo.Message{
+			ChannelID: "dm-channel-1", Author: &discordgo.User{ID: "user-1"},
+		}}
+		second := &discordgo.MessageCreate{Message: &discordgo.Message{
+			ChannelID: "dm-channel-2", Author: &discordgo.User{ID: "user-2"},
+		}}
+
+		// Act
+		firstKey := draftKey(first, false).String()
+		secondKey := draftKey(second, false).String()
+
+		// Assert
+		if firstKey == secondKey {
+			t.Errorf("DMの下書きのキーが重複しています: %v", firstKey)
+		}
+	})
+}
+
+func TestParseSurveyFlags(t *testing.T) {
+	t.Run("正常系: フラグから下書きの設定を読み取る", func(t *testing.T) {
+		testCases := []struct {
+			name           string
+			args           []string
+			expectedShared bool
+			expected       types.SurveySettings
+		}{
+			{"フラグなし", nil, false, types.SurveySettings{VoteMode: types.VoteModeReaction}},
+			{"共有とボタン", []string{"--shared", "--buttons"}, true, types.SurveySettings{VoteMode: types.VoteModeComponent}},
+			{"単一選択", []string{"--single"}, false, types.SurveySettings{VoteMode: types.VoteModeReaction, MaxChoices: 1}},
+			{"最大選択数", []string{"--max", "3"}, false, types.SurveySettings{VoteMode: types.VoteModeReaction, MaxChoices: 3}},
+			{"イコール区切りの最大選択数", []string{"--max=2"}, false, types.SurveySettings{VoteMode: types.VoteModeReaction, MaxChoices: 2}},
+			{"匿名はボタン方式になる", []string{"--anon"}, false, types.SurveySettings{VoteMode: types.VoteModeComponent, Anonymous: true}},
+			{"順位付け", []string{"--ranked"}, false, types.SurveySettings{VoteMode: types.VoteModeRanked}},
+			{"匿名の順位付け", []string{"--anon", "--ranked"}, false, types.SurveySettings{VoteMode: types.VoteModeRanked, Anonymous: true}},
+			{"絵文字セット", []string{"--emoji", "alphabet"}, false, types.SurveySettings{VoteMode: types.VoteModeReaction, EmojiSet: types.EmojiSetAlphabet}},
+			{"イコール区切りの絵文字セット", []string{"--emoji=CIRCLE"}, false, types.SurveySettings{VoteMode: types.VoteModeReaction, EmojiSet: types.EmojiSetCircle}},
+			{"定足数", []string{"--quorum", "10"}, false, types.SurveySettings{VoteMode: types.VoteModeReaction, Quorum: 10}},
+			{"割合の定足数と自動締め切り", []string{"--quorum=60%", "--auto-close"}, false, types.SurveySettings{VoteMode: types.VoteModeReaction, QuorumPercent: 60, AutoClose: true}},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				// Act
+				shared, settings, err := parseSurveyFlags(tc.args, time.Now())
+
+				// Assert
+				if err != nil {
+					t.Fatalf("期待していないエラーが発生: %v", err)
+				}
+				if shared != tc.expectedShared {
+					t.Errorf("共有設定が期待値と異なります: got %v, want %v", shared, tc.expectedShared)
+				}
+				if settings != tc.expected {
+					t.Errorf("設定が期待値と異なります: got %+v, want %+v", settings, tc.expected)
+				}
+			})
+		}
+	})
+
+	t.Run("異常系: 不正なフラグの値", func(t *testing.T) {
+		testCases := [][]string{
+			{"--max"},
+			{"--max", "0"},
+			{"--max", "abc"},
+			{"--max=-1"},
+			{"--emoji"},
+			{"--emoji", "hearts"},
+			{"--quorum"},
+			{"--quorum", "0"},
+			{"--quorum=120%"},
+			{"--multi"},
+			{"--buttns"},
+			{"期日:2h"},
+			{"期限:2x"},
+		}
+
+		for _, args := range testCases {
+			// Act
+			_, _, err := parseSurveyFlags(args, time.Now())
+
+			// Assert
+			if err == nil {
+				t.Errorf("エラーが期待されていましたが、nilが返されました: %v", args)
+			}
+		}
+	})
+}
+
+func TestChoiceLimitText(t *testing.T) {
+	t.Run("正常系: 選択数の上限を表示する", func(t *testing.T) {
+		testCases := []struct {
+			maxChoices int
+			expected   string
+		}{
+			{0, ""},
+			{1, "1つだけ選択できます"},
+			{3, "最大3個まで選択できます"},
+		}
+
+		for _, tc := range testCases {
+			// Act
+			result := choiceLimitText(tc.maxChoices)
+
+			// Assert
+			if result != tc.expected {
+				t.Errorf("表示が期待値と異なります: got %v, want %v", result, tc.expected)
+			}
+		}
+	})
+}
+
+func TestParseSurveyFlags_Deadline(t *testing.T) {
+	now := time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	t.Run("正常系: 期限を読み取る", func(t *testing.T) {
+		testCases := []struct {
+			name     string
+			args     []string
+			expected time.Time
+		}{
+			{"区切りの後に期間", []string{"期限:", "2h"}, now.Add(2 * time.Hour)},
+			{"区切りなしで期間", []string{"期限", "30m"}, now.Add(30 * time.Minute)},
+			{"全角の区切りに続けて日数", []string{"期限：3d"}, now.AddDate(0, 0, 3)},
+			{"ISO形式の日時", []string{"期限:", "2030-01-03T09:00:00Z"}, time.Date(2030, 1, 3, 9, 0, 0, 0, time.UTC)},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				// Act
+				_, settings, err := parseSurveyFlags(append(tc.args, "--single"), now)
+
+				// Assert
+				if err != nil {
+					t.Fatalf("期待していないエラーが発生: %v", err)
+				}
+				if !settings.Deadline.Equal(tc.expected) {
+					t.Errorf("期限が期待値と異なります: got %v, want %v", settings.Deadline, tc.expected)
+				}
+				if settings.MaxChoices != 1 {
+					t.Errorf("期限の後のフラグが読み取られていません: %+v", settings)
+				}
+			})
+		}
+	})
+
+	t.Run("異常系: 不正な期限", func(t *testing.T) {
+		testCases := [][]string{
+			{"期限:"},
+			{"期限:", "明日"},
+			{"期限:", "-1h"},
+			{"期限:", "2020-01-01T00:00:00Z"},
+		}
+
+		for _, args := range testCases {
+			// Act
+			_, _, err := parseSurveyFlags(args, now)
+
+			// Assert
+			if !errors.Is(err, errInvalidDeadline) {
+				t.Errorf("errInvalidDeadlineが期待されていましたが、%vが返されました: %v", err, args)
+			}
+		}
+	})
+}
+
+func TestSurveyFooterText(t *testing.T) {
+	t.Run("正常系: 投票の受付状況を表示する", func(t *testing.T) {
+		deadline := time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC)
+		testCases := []struct {
+			name     string
+			survey   *types.Survey
+			expected string
+		}{
+			{"設定なし", &types.Survey{}, ""},
+			{"期限と選択数の上限", &types.Survey{SurveySettings: types.SurveySettings{MaxChoices: 1, Deadline: deadline}}, "1つだけ選択できます / 締め切り"},
+			{"匿名アンケート", &types.Survey{SurveySettings: types.SurveySettings{Anonymous: true, MaxChoices: 2}}, "匿名アンケート / 最大2個まで選択できます"},
+			{"締め切り済み", &types.Survey{Closed: true, SurveySettings: types.SurveySettings{MaxChoices: 1, Deadline: deadline}}, "締め切り済み"},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				// Act
+				result := surveyFooterText(tc.survey)
+
+				// Assert
+				if result != tc.expected {
+					t.Errorf("表示が期待値と異なります: got %v, want %v", result, tc.expected)
+				}
+			})
+		}
+	})
+}
+
+func TestSurveyFlagsErrorMessage(t *testing.T) {
+	t.Run("異常系: 未知のフラグには使用できるオプションを返す", func(t *testing.T) {
+		// Arrange
+		_, _, err := parseSurveyFlags([]string{"--single", "--multi"}, time.Now())
+
+		// Act
+		message := surveyFlagsErrorMessage(err)
+
+		// Assert
+		if !errors.Is(err, errUnknownFlag) {
+			t.Fatalf("errUnknownFlagが期待されていましたが、%vが返されました", err)
+		}
+		if message != surveyFlagsUsage {
+			t.Errorf("メッセージが期待値と異なります: got %q", message)
+		}
+	})
+}
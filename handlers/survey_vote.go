@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/Logta/SurveyBot/utils"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// maxButtonOptions is the most options shown as buttons; Discord allows 5 per row
+	maxButtonOptions = 5
+	// maxSelectOptions is the most options a Discord select menu can hold
+	maxSelectOptions = 25
+
+	voteButtonPrefix = "survey_vote:"
+	voteSelectID     = "survey_vote_select"
+
+	closedVoteMessage = "このアンケートは締め切られています"
+)
+
+// errInvalidVote is returned for a button or select value that is not one of the options
+var errInvalidVote = errors.New("invalid vote")
+
+// surveyComponents returns the buttons or select menu used to vote on a component survey,
+// or the button that starts ranking a ranked survey
+func surveyComponents(survey *types.Survey) []discordgo.MessageComponent {
+	switch survey.VoteMode {
+	case types.VoteModeRanked:
+		return rankedSurveyComponents(survey)
+	case types.VoteModeComponent:
+	default:
+		return nil
+	}
+
+	if len(survey.Options) <= maxButtonOptions {
+		buttons := make([]discordgo.MessageComponent, len(survey.Options))
+		for i, option := range survey.Options {
+			button := discordgo.Button{
+				Label:    truncate(option, 80),
+				Style:    discordgo.SecondaryButton,
+				CustomID: voteButtonPrefix + strconv.Itoa(i),
+				Disabled: survey.Closed,
+			}
+			if survey.Emojis[i] != "" {
+				button.Emoji = componentEmoji(survey.Emojis[i])
+			}
+			buttons[i] = button
+		}
+		return []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}}
+	}
+
+	selectOptions := make([]discordgo.SelectMenuOption, len(survey.Options))
+	for i, option := range survey.Options {
+		selectOptions[i] = discordgo.SelectMenuOption{
+			Label: truncate(option, 100),
+			Value: strconv.Itoa(i),
+		}
+		if survey.Emojis[i] != "" {
+			selectOptions[i].Emoji = componentEmoji(survey.Emojis[i])
+		}
+	}
+
+	minValues := 0
+	maxValues := len(survey.Options)
+	if survey.MaxChoices > 0 && survey.MaxChoices < maxValues {
+		maxValues = survey.MaxChoices
+	}
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{
+				MenuType:    discordgo.StringSelectMenu,
+				CustomID:    voteSelectID,
+				Placeholder: "回答を選択してください",
+				MinValues:   &minValues,
+				MaxValues:   maxValues,
+				Options:     selectOptions,
+				Disabled:    survey.Closed,
+			},
+		}},
+	}
+}
+
+// isVoteComponent reports whether i was triggered by a survey's voting components
+func isVoteComponent(i *discordgo.InteractionCreate) bool {
+	if i.Type != discordgo.InteractionMessageComponent {
+		return false
+	}
+	customID := i.MessageComponentData().CustomID
+	return strings.HasPrefix(customID, voteButtonPrefix) || customID == voteSelectID
+}
+
+func (h *surveyHandler) handleVoteInteraction(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	survey, err := h.registry.GetSurvey(ctx, i.Message.ID)
+	if errors.Is(err, types.ErrSurveyNotFound) {
+		return respondEphemeral(s, i, "このアンケートは見つかりませんでした")
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to find survey", err)
+		return err
+	}
+
+	if survey.Closed {
+		return respondEphemeral(s, i, closedVoteMessage)
+	}
+
+	if !isEligible(survey, i.Member) {
+		return respondEphemeral(s, i, ineligibleMessage(survey))
+	}
+
+	// The new choices are worked out from the stored vote while it is updated, so quick
+	// clicks each toggle the result of the one before
+	voterID := voterID(survey, interactionUser(i).ID)
+	var choices []int
+	updated, err := h.registry.UpdateSurvey(ctx, survey.MessageID, func(survey *types.Survey) error {
+		if survey.Closed {
+			return types.ErrSurveyClosed
+		}
+		var err error
+		choices, err = voteChoices(survey, voterID, i.MessageComponentData())
+		if err != nil {
+			return errInvalidVote
+		}
+
+		vote := types.Vote{UserID: voterID, Choices: choices}
+		// Vote times could be matched against who was online, so anonymous surveys drop them
+		if !survey.Anonymous {
+			vote.VotedAt = time.Now()
+		}
+		survey.Votes = utils.ReplaceVote(survey.Votes, vote)
+		return nil
+	})
+	if errors.Is(err, types.ErrSurveyClosed) {
+		return respondEphemeral(s, i, closedVoteMessage)
+	}
+	if errors.Is(err, errInvalidVote) {
+		return respondEphemeral(s, i, "無効な回答です")
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to record vote", err, types.Field{Key: "message_id", Value: survey.MessageID})
+		return err
+	}
+
+	h.logger.Debug(ctx, "Vote recorded",
+		types.Field{Key: "message_id", Value: survey.MessageID},
+		types.Field{Key: "choices", Value: choices},
+	)
+
+	if updated.Anonymous {
+		err = respondEphemeral(s, i, voteConfirmation(updated, choices))
+	} else {
+		err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Embeds:     []*discordgo.MessageEmbed{createSurveyMessageEmbed(updated)},
+				Components: surveyComponents(updated),
+			},
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	// The interaction is answered first, as closing may take longer than Discord waits
+	h.closeIfDecided(ctx, s, survey.MessageID)
+	return nil
+}
+
+// voterID returns the ID a vote by userID is recorded under
+func voterID(survey *types.Survey, userID string) string {
+	if survey.Anonymous {
+		return utils.HashVoter(survey.VoterSalt, userID)
+	}
+	return userID
+}
+
+// voteConfirmation tells an anonymous voter which options they have chosen
+func voteConfirmation(survey *types.Survey, choices []int) string {
+	if len(choices) == 0 {
+		return "回答を取り消しました"
+	}
+
+	labels := make([]string, len(choices))
+	for n, choice := range choices {
+		labels[n] = fmt.Sprintf("%s %s", optionMarker(survey, choice), survey.Options[choice])
+	}
+	return "匿名で回答しました: " + strings.Join(labels, ", ")
+}
+
+// voteChoices returns the member's new choices: a button toggles one option while the
+// select menu replaces the whole selection
+func voteChoices(survey *types.Survey, userID string, data discordgo.MessageComponentInteractionData) ([]int, error) {
+	if data.CustomID == voteSelectID {
+		if survey.MaxChoices > 0 && len(data.Values) > survey.MaxChoices {
+			return nil, errors.New("too many select values")
+		}
+		choices := make([]int, 0, len(data.Values))
+		for _, value := range data.Values {
+			choice, err := strconv.Atoi(value)
+			if err != nil || choice < 0 || choice >= len(survey.Options) {
+				return nil, errors.New("invalid select value")
+			}
+			choices = append(choices, choice)
+		}
+		return choices, nil
+	}
+
+	choice, err := strconv.Atoi(strings.TrimPrefix(data.CustomID, voteButtonPrefix))
+	if err != nil || choice < 0 || choice >= len(survey.Options) {
+		return nil, errors.New("invalid button")
+	}
+
+	current := currentChoices(survey, userID)
+	for _, existing := range current {
+		if existing == choice {
+			return removeChoice(current, choice), nil
+		}
+	}
+
+	choices, _ := addChoice(current, choice, survey.MaxChoices)
+	return choices, nil
+}
+
+// currentChoices returns the options the member has chosen so far, oldest first
+func currentChoices(survey *types.Survey, userID string) []int {
+	for _, vote := range survey.Votes {
+		if vote.UserID == userID {
+			return vote.Choices
+		}
+	}
+	return nil
+}
+
+// addChoice appends choice to current, dropping the oldest choices beyond maxChoices.
+// It returns the new choices and the dropped ones.
+func addChoice(current []int, choice, maxChoices int) ([]int, []int) {
+	choices := make([]int, 0, len(current)+1)
+	for _, existing := range current {
+		if existing != choice {
+			choices = append(choices, existing)
+		}
+	}
+	choices = append(choices, choice)
+
+	if maxChoices <= 0 || len(choices) <= maxChoices {
+		return choices, nil
+	}
+
+	excess := len(choices) - maxChoices
+	return choices[excess:], choices[:excess]
+}
+
+// removeChoice returns current without choice
+func removeChoice(current []int, choice int) []int {
+	choices := make([]int, 0, len(current))
+	for _, existing := range current {
+		if existing != choice {
+			choices = append(choices, existing)
+		}
+	}
+	return choices
+}
+
+// truncate shortens s to at most n runes, as Discord rejects over-long component labels
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
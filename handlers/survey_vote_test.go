@@ -0,0 +1,418 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Logta/SurveyBot/pkg/state"
+	"github.com/Logta/SurveyBot/types"
+	"github.com/bwmarrin/discordgo"
+)
+
+func newComponentSurvey(optionCount int) *types.Survey {
+	survey := &types.Survey{
+		MessageID:      "message-1",
+		SurveySettings: types.SurveySettings{VoteMode: types.VoteModeComponent},
+	}
+	for i := 0; i < optionCount; i++ {
+		survey.Options = append(survey.Options, string(rune('A'+i)))
+		survey.Emojis = append(survey.Emojis, "")
+	}
+	return survey
+}
+
+func TestSurveyComponents(t *testing.T) {
+	t.Run("正常系: 5個以下の選択肢はボタンになる", func(t *testing.T) {
+		// Arrange
+		survey := newComponentSurvey(5)
+
+		// Act
+		components := surveyComponents(survey)
+
+		// Assert
+		row := components[0].(discordgo.ActionsRow)
+		if len(row.Components) != 5 {
+			t.Fatalf("ボタン数が期待値と異なります: got %v, want %v", len(row.Components), 5)
+		}
+		button := row.Components[4].(discordgo.Button)
+		if button.CustomID != voteButtonPrefix+"4" || button.Label != "E" {
+			t.Errorf("ボタンが期待値と異なります: got %+v", button)
+		}
+	})
+
+	t.Run("正常系: 6個以上の選択肢はセレクトメニューになる", func(t *testing.T) {
+		// Arrange
+		survey := newComponentSurvey(maxSelectOptions)
+
+		// Act
+		components := surveyComponents(survey)
+
+		// Assert
+		row := components[0].(discordgo.ActionsRow)
+		menu := row.Components[0].(discordgo.SelectMenu)
+		if menu.CustomID != voteSelectID {
+			t.Errorf("カスタムIDが期待値と異なります: got %v, want %v", menu.CustomID, voteSelectID)
+		}
+		if len(menu.Options) != maxSelectOptions || menu.MaxValues != maxSelectOptions {
+			t.Errorf("選択肢数が期待値と異なります: got %v, max %v", len(menu.Options), menu.MaxValues)
+		}
+	})
+
+	t.Run("正常系: セレクトメニューの選択数は上限に合わせる", func(t *testing.T) {
+		// Arrange
+		survey := newComponentSurvey(6)
+		survey.MaxChoices = 2
+
+		// Act
+		components := surveyComponents(survey)
+
+		// Assert
+		menu := components[0].(discordgo.ActionsRow).Components[0].(discordgo.SelectMenu)
+		if menu.MaxValues != 2 {
+			t.Errorf("最大選択数が期待値と異なります: got %v, want %v", menu.MaxValues, 2)
+		}
+	})
+
+	t.Run("正常系: 締め切り後は無効化される", func(t *testing.T) {
+		// Arrange
+		survey := newComponentSurvey(2)
+		survey.Closed = true
+
+		// Act
+		components := surveyComponents(survey)
+
+		// Assert
+		button := components[0].(discordgo.ActionsRow).Components[0].(discordgo.Button)
+		if !button.Disabled {
+			t.Error("締め切り後のボタンが無効化されていません")
+		}
+	})
+
+	t.Run("正常系: リアクション方式ではコンポーネントを付けない", func(t *testing.T) {
+		// Arrange
+		survey := newComponentSurvey(2)
+		survey.VoteMode = types.VoteModeReaction
+
+		// Act
+		components := surveyComponents(survey)
+
+		// Assert
+		if components != nil {
+			t.Errorf("コンポーネントが付いています: %v", components)
+		}
+	})
+}
+
+func TestVoteChoices(t *testing.T) {
+	t.Run("正常系: ボタンで選択を切り替える", func(t *testing.T) {
+		// Arrange
+		survey := newComponentSurvey(3)
+		survey.Votes = []types.Vote{{UserID: "user-1", Choices: []int{0}}}
+
+		testCases := []struct {
+			name     string
+			customID string
+			expected []int
+		}{
+			{"未選択の項目を追加", voteButtonPrefix + "2", []int{0, 2}},
+			{"選択済みの項目を解除", voteButtonPrefix + "0", []int{}},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				// Act
+				choices, err := voteChoices(survey, "user-1", discordgo.MessageComponentInteractionData{CustomID: tc.customID})
+
+				// Assert
+				if err != nil {
+					t.Fatalf("期待していないエラーが発生: %v", err)
+				}
+				if !reflect.DeepEqual(choices, tc.expected) {
+					t.Errorf("選択が期待値と異なります: got %v, want %v", choices, tc.expected)
+				}
+			})
+		}
+	})
+
+	t.Run("正常系: セレクトメニューで選択を置き換える", func(t *testing.T) {
+		// Arrange
+		survey := newComponentSurvey(6)
+		survey.Votes = []types.Vote{{UserID: "user-1", Choices: []int{0}}}
+		data := discordgo.MessageComponentInteractionData{CustomID: voteSelectID, Values: []string{"3", "5"}}
+
+		// Act
+		choices, err := voteChoices(survey, "user-1", data)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if !reflect.DeepEqual(choices, []int{3, 5}) {
+			t.Errorf("選択が期待値と異なります: got %v, want %v", choices, []int{3, 5})
+		}
+	})
+
+	t.Run("正常系: 上限を超えるボタンの選択は古い選択を外す", func(t *testing.T) {
+		// Arrange
+		survey := newComponentSurvey(3)
+		survey.MaxChoices = 2
+		survey.Votes = []types.Vote{{UserID: "user-1", Choices: []int{1, 0}}}
+		data := discordgo.MessageComponentInteractionData{CustomID: voteButtonPrefix + "2"}
+
+		// Act
+		choices, err := voteChoices(survey, "user-1", data)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if !reflect.DeepEqual(choices, []int{0, 2}) {
+			t.Errorf("選択が期待値と異なります: got %v, want %v", choices, []int{0, 2})
+		}
+	})
+
+	t.Run("異常系: 上限を超えるセレクトメニューの選択", func(t *testing.T) {
+		// Arrange
+		survey := newComponentSurvey(6)
+		survey.MaxChoices = 1
+		data := discordgo.MessageComponentInteractionData{CustomID: voteSelectID, Values: []string{"0", "1"}}
+
+		// Act
+		_, err := voteChoices(survey, "user-1", data)
+
+		// Assert
+		if err == nil {
+			t.Error("エラーが期待されていましたが、nilが返されました")
+		}
+	})
+
+	t.Run("異常系: 範囲外の選択", func(t *testing.T) {
+		// Arrange
+		survey := newComponentSurvey(2)
+		testCases := []discordgo.MessageComponentInteractionData{
+			{CustomID: voteButtonPrefix + "2"},
+			{CustomID: voteButtonPrefix + "x"},
+			{CustomID: voteSelectID, Values: []string{"-1"}},
+		}
+
+		for _, data := range testCases {
+			// Act
+			_, err := voteChoices(survey, "user-1", data)
+
+			// Assert
+			if err == nil {
+				t.Errorf("エラーが期待されていましたが、nilが返されました: %+v", data)
+			}
+		}
+	})
+}
+
+func TestAddChoice(t *testing.T) {
+	t.Run("正常系: 選択の上限を適用する", func(t *testing.T) {
+		testCases := []struct {
+			name            string
+			current         []int
+			choice          int
+			maxChoices      int
+			expectedChoices []int
+			expectedDropped []int
+		}{
+			{"上限なし", []int{0, 1}, 2, 0, []int{0, 1, 2}, nil},
+			{"上限内", []int{0}, 1, 2, []int{0, 1}, nil},
+			{"単一選択では前の選択を外す", []int{0}, 2, 1, []int{2}, []int{0}},
+			{"上限を超えた古い選択から外す", []int{2, 0, 1}, 3, 2, []int{1, 3}, []int{2, 0}},
+			{"選択済みの項目は最新として扱う", []int{0, 1}, 0, 2, []int{1, 0}, nil},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				// Act
+				choices, dropped := addChoice(tc.current, tc.choice, tc.maxChoices)
+
+				// Assert
+				if !reflect.DeepEqual(choices, tc.expectedChoices) {
+					t.Errorf("選択が期待値と異なります: got %v, want %v", choices, tc.expectedChoices)
+				}
+				if !reflect.DeepEqual(dropped, tc.expectedDropped) {
+					t.Errorf("外した選択が期待値と異なります: got %v, want %v", dropped, tc.expectedDropped)
+				}
+			})
+		}
+	})
+}
+
+func TestTruncate(t *testing.T) {
+	t.Run("正常系: 上限を超える文字列を切り詰める", func(t *testing.T) {
+		// Act & Assert
+		if result := truncate("あいうえお", 3); result != "あい…" {
+			t.Errorf("切り詰め結果が期待値と異なります: got %v, want %v", result, "あい…")
+		}
+		if result := truncate("あい", 3); result != "あい" {
+			t.Errorf("上限以下の文字列が変更されています: got %v", result)
+		}
+	})
+}
+
+func TestVoterID(t *testing.T) {
+	t.Run("正常系: 匿名アンケートではユーザーIDを記録しない", func(t *testing.T) {
+		// Arrange
+		survey := newComponentSurvey(2)
+		survey.Anonymous = true
+		survey.VoterSalt = "salt-1"
+
+		// Act
+		result := voterID(survey, "user-1")
+
+		// Assert
+		if result == "user-1" || result == "" {
+			t.Errorf("ユーザーIDがそのまま記録されます: %v", result)
+		}
+		if result != voterID(survey, "user-1") {
+			t.Error("同じユーザーのIDが一致しません")
+		}
+	})
+
+	t.Run("正常系: 通常のアンケートではユーザーIDを記録する", func(t *testing.T) {
+		// Arrange
+		survey := newComponentSurvey(2)
+
+		// Act
+		result := voterID(survey, "user-1")
+
+		// Assert
+		if result != "user-1" {
+			t.Errorf("IDが期待値と異なります: got %v, want %v", result, "user-1")
+		}
+	})
+}
+
+func TestVoteConfirmation(t *testing.T) {
+	t.Run("正常系: 選択した回答項目を知らせる", func(t *testing.T) {
+		// Arrange
+		survey := newComponentSurvey(3)
+		survey.Emojis[0] = "1️⃣"
+
+		testCases := []struct {
+			name     string
+			choices  []int
+			expected string
+		}{
+			{"回答あり", []int{0, 2}, "匿名で回答しました: 1️⃣ A, 3. C"},
+			{"回答の取り消し", nil, "回答を取り消しました"},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				// Act
+				result := voteConfirmation(survey, tc.choices)
+
+				// Assert
+				if result != tc.expected {
+					t.Errorf("メッセージが期待値と異なります: got %v, want %v", result, tc.expected)
+				}
+			})
+		}
+	})
+}
+
+func TestCreateSurveyMessageEmbed_Anonymous(t *testing.T) {
+	t.Run("正常系: 匿名アンケートは締め切りまで得票数を表示しない", func(t *testing.T) {
+		// Arrange
+		survey := newComponentSurvey(2)
+		survey.Anonymous = true
+		survey.Votes = []types.Vote{{UserID: "hash-1", Choices: []int{0}}}
+
+		// Act
+		open := createSurveyMessageEmbed(survey)
+		survey.Closed = true
+		closed := createSurveyMessageEmbed(survey)
+
+		// Assert
+		if strings.Contains(open.Description, "票") {
+			t.Errorf("受付中に得票数が表示されています: %v", open.Description)
+		}
+		if !strings.Contains(closed.Description, "(1票)") {
+			t.Errorf("締め切り後に得票数が表示されていません: %v", closed.Description)
+		}
+	})
+}
+
+// racingRegistry runs afterRead right after the survey is first read, as another click
+// handled at the same time would
+type racingRegistry struct {
+	types.SurveyRegistry
+	afterRead func(registry types.SurveyRegistry)
+}
+
+func (r *racingRegistry) GetSurvey(ctx context.Context, messageID string) (*types.Survey, error) {
+	survey, err := r.SurveyRegistry.GetSurvey(ctx, messageID)
+	if err == nil && r.afterRead != nil {
+		r.afterRead(r.SurveyRegistry)
+		r.afterRead = nil
+	}
+	return survey, err
+}
+
+func TestSurveyHandler_HandleVoteInteraction_Race(t *testing.T) {
+	tests := []struct {
+		name      string
+		afterRead func(registry types.SurveyRegistry)
+		choices   []int
+		reply     string
+	}{
+		{"正常系: 直前のクリックを取りこぼさない", func(registry types.SurveyRegistry) {
+			registry.RecordVote(context.Background(), "message-1", types.Vote{UserID: "user-1", Choices: []int{0}})
+		}, []int{0, 1}, ""},
+		{"異常系: 読んだ後に締め切られたら投票しない", func(registry types.SurveyRegistry) {
+			registry.UpdateSurvey(context.Background(), "message-1", closeSurvey(time.Now()))
+		}, nil, closedVoteMessage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			stored := state.NewMemorySurveyRegistry()
+			stored.SaveSurvey(ctx, newComponentSurvey(3))
+			registry := &racingRegistry{SurveyRegistry: stored, afterRead: tt.afterRead}
+			handler := NewSurveyHandler(&mockStateManager{}, registry, &mockGuildSettingsStore{}, &mockTemplateStore{}, &mockScheduleStore{}, &mockScheduler{}, &mockEmojiProvider{}, &mockLogger{}).(*surveyHandler)
+
+			s := newRESTSession(http.StatusNoContent, "")
+			transport := s.Client.Transport
+			var sent []string
+			s.Client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				body, _ := io.ReadAll(r.Body)
+				sent = append(sent, string(body))
+				return transport.RoundTrip(r)
+			})
+			i := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+				ID:      "interaction-1",
+				Token:   "token",
+				Type:    discordgo.InteractionMessageComponent,
+				Message: &discordgo.Message{ID: "message-1"},
+				Member:  &discordgo.Member{User: &discordgo.User{ID: "user-1"}},
+				Data:    discordgo.MessageComponentInteractionData{CustomID: voteButtonPrefix + "1"},
+			}}
+
+			// Act
+			err := handler.handleVoteInteraction(ctx, s, i)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("期待していないエラーが発生: %v", err)
+			}
+			survey, _ := stored.GetSurvey(ctx, "message-1")
+			if got := currentChoices(survey, "user-1"); !reflect.DeepEqual(got, tt.choices) {
+				t.Errorf("投票が期待値と異なります: got %v, want %v", got, tt.choices)
+			}
+			if tt.reply != "" && (len(sent) != 1 || !strings.Contains(sent[0], tt.reply)) {
+				t.Errorf("返信が期待値と異なります: got %q, want %q", sent, tt.reply)
+			}
+		})
+	}
+}
@@ -3,11 +3,13 @@ package main
 import (
 	"context"
 	"log"
+	_ "time/tzdata" // schedules name IANA time zones, which slim images lack
 
 	"github.com/Logta/SurveyBot/handlers"
 	"github.com/Logta/SurveyBot/pkg/bot"
 	"github.com/Logta/SurveyBot/pkg/config"
 	"github.com/Logta/SurveyBot/pkg/logger"
+	"github.com/Logta/SurveyBot/pkg/scheduler"
 	"github.com/Logta/SurveyBot/pkg/state"
 	"github.com/Logta/SurveyBot/utils"
 )
@@ -25,7 +27,33 @@ func main() {
 	logger := logger.New()
 
 	// Initialize dependencies
-	stateManager := state.NewMemoryStateManager()
+	stateManager, err := state.NewStateManager(cfg)
+	if err != nil {
+		logger.Error(ctx, "Failed to create state manager", err)
+		log.Fatalf("Failed to create state manager: %v", err)
+	}
+	surveyRegistry, err := state.NewSurveyRegistry(cfg)
+	if err != nil {
+		logger.Error(ctx, "Failed to create survey registry", err)
+		log.Fatalf("Failed to create survey registry: %v", err)
+	}
+	guildSettings, err := state.NewGuildSettingsStore(cfg)
+	if err != nil {
+		logger.Error(ctx, "Failed to create guild settings store", err)
+		log.Fatalf("Failed to create guild settings store: %v", err)
+	}
+	templates, err := state.NewTemplateStore(cfg)
+	if err != nil {
+		logger.Error(ctx, "Failed to create template store", err)
+		log.Fatalf("Failed to create template store: %v", err)
+	}
+	schedules, err := state.NewScheduleStore(cfg)
+	if err != nil {
+		logger.Error(ctx, "Failed to create schedule store", err)
+		log.Fatalf("Failed to create schedule store: %v", err)
+	}
+	surveyScheduler := scheduler.New()
+	defer surveyScheduler.Stop()
 	emojiProvider := utils.NewEmojiProvider()
 	shuffler := utils.NewShuffler()
 	coupler := utils.NewCoupler()
@@ -38,7 +66,7 @@ func main() {
 	}
 
 	// Register handlers
-	b.RegisterHandler(handlers.NewSurveyHandler(stateManager, emojiProvider, logger))
+	b.RegisterHandler(handlers.NewSurveyHandler(stateManager, surveyRegistry, guildSettings, templates, schedules, surveyScheduler, emojiProvider, logger))
 	b.RegisterHandler(handlers.NewShuffleHandler(shuffler, emojiProvider, logger))
 	b.RegisterHandler(handlers.NewCouplingHandler(coupler, emojiProvider, logger))
 	b.RegisterHandler(handlers.NewHelpHandler(logger))
@@ -12,10 +12,13 @@ import (
 )
 
 type bot struct {
-	session  *discordgo.Session
-	handlers []types.Handler
-	logger   types.Logger
-	config   *types.Config
+	session             *discordgo.Session
+	handlers            []types.Handler
+	interactionHandlers []types.InteractionHandler
+	reactionHandlers    []types.ReactionHandler
+	startupHandlers     []types.StartupHandler
+	logger              types.Logger
+	config              *types.Config
 }
 
 // New creates a new bot instance
@@ -34,15 +37,40 @@ func New(config *types.Config, logger types.Logger) (types.Bot, error) {
 
 func (b *bot) RegisterHandler(handler types.Handler) {
 	b.handlers = append(b.handlers, handler)
+
+	if interactionHandler, ok := handler.(types.InteractionHandler); ok {
+		b.interactionHandlers = append(b.interactionHandlers, interactionHandler)
+	}
+
+	if reactionHandler, ok := handler.(types.ReactionHandler); ok {
+		b.reactionHandlers = append(b.reactionHandlers, reactionHandler)
+	}
+
+	if startupHandler, ok := handler.(types.StartupHandler); ok {
+		b.startupHandlers = append(b.startupHandlers, startupHandler)
+	}
 }
 
 func (b *bot) Start(ctx context.Context) error {
 	b.session.AddHandler(b.messageCreateHandler)
+	b.session.AddHandler(b.interactionCreateHandler)
+	b.session.AddHandler(b.messageReactionAddHandler)
+	b.session.AddHandler(b.messageReactionRemoveHandler)
 
 	if err := b.session.Open(); err != nil {
 		return fmt.Errorf("failed to open Discord session: %w", err)
 	}
 
+	if err := b.registerApplicationCommands(ctx); err != nil {
+		b.logger.Error(ctx, "Failed to register application commands", err)
+	}
+
+	for _, handler := range b.startupHandlers {
+		if err := handler.OnStart(ctx, b.session); err != nil {
+			b.logger.Error(ctx, "Startup handler failed", err)
+		}
+	}
+
 	b.logger.Info(ctx, "Bot started successfully")
 
 	// Wait for interrupt signal
@@ -97,3 +125,76 @@ func (b *bot) messageCreateHandler(s *discordgo.Session, m *discordgo.MessageCre
 		}
 	}
 }
+
+func (b *bot) registerApplicationCommands(ctx context.Context) error {
+	var commands []*discordgo.ApplicationCommand
+	for _, handler := range b.interactionHandlers {
+		commands = append(commands, handler.ApplicationCommands()...)
+	}
+
+	registered, err := b.session.ApplicationCommandBulkOverwrite(b.session.State.User.ID, "", commands)
+	if err != nil {
+		return fmt.Errorf("failed to overwrite application commands: %w", err)
+	}
+
+	b.logger.Info(ctx, "Application commands registered", types.Field{Key: "count", Value: len(registered)})
+	return nil
+}
+
+func (b *bot) interactionCreateHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	ctx := context.Background()
+
+	b.logger.Debug(ctx, "Received interaction",
+		types.Field{Key: "type", Value: i.Type.String()},
+		types.Field{Key: "guild", Value: i.GuildID},
+	)
+
+	for _, handler := range b.interactionHandlers {
+		if handler.CanHandleInteraction(i) {
+			if err := handler.HandleInteraction(ctx, s, i); err != nil {
+				b.logger.Error(ctx, "Interaction handler failed",
+					err,
+					types.Field{Key: "type", Value: i.Type.String()},
+				)
+			}
+			return
+		}
+	}
+}
+
+func (b *bot) messageReactionAddHandler(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+	ctx := context.Background()
+
+	// Ignore reactions seeded by the bot itself
+	if r.UserID == s.State.User.ID {
+		return
+	}
+
+	for _, handler := range b.reactionHandlers {
+		if err := handler.HandleReactionAdd(ctx, s, r); err != nil {
+			b.logger.Error(ctx, "Reaction handler failed",
+				err,
+				types.Field{Key: "message", Value: r.MessageID},
+				types.Field{Key: "emoji", Value: r.Emoji.Name},
+			)
+		}
+	}
+}
+
+func (b *bot) messageReactionRemoveHandler(s *discordgo.Session, r *discordgo.MessageReactionRemove) {
+	ctx := context.Background()
+
+	if r.UserID == s.State.User.ID {
+		return
+	}
+
+	for _, handler := range b.reactionHandlers {
+		if err := handler.HandleReactionRemove(ctx, s, r); err != nil {
+			b.logger.Error(ctx, "Reaction handler failed",
+				err,
+				types.Field{Key: "message", Value: r.MessageID},
+				types.Field{Key: "emoji", Value: r.Emoji.Name},
+			)
+		}
+	}
+}
@@ -17,6 +17,8 @@ func Load() (*types.Config, error) {
 	config := &types.Config{
 		DiscordToken: getEnv("DISCORD_TOKEN", ""),
 		GoEnv:        getEnv("GO_ENV", "development"),
+		StateBackend: getEnv("STATE_BACKEND", "memory"),
+		StateDir:     getEnv("STATE_DIR", "./data"),
 	}
 
 	if config.DiscordToken == "" {
@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+type timerScheduler struct {
+	mu      sync.Mutex
+	timers  map[string]*time.Timer
+	stopped bool
+}
+
+// New creates a scheduler that runs each job on its own goroutine when its time comes.
+// Jobs scheduled in the past run immediately.
+func New() types.Scheduler {
+	return &timerScheduler{
+		timers: make(map[string]*time.Timer),
+	}
+}
+
+func (s *timerScheduler) Schedule(id string, at time.Time, job func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return
+	}
+
+	if timer, exists := s.timers[id]; exists {
+		timer.Stop()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Until(at), func() {
+		s.mu.Lock()
+		// The job was cancelled or replaced after the timer had already fired
+		if s.timers[id] != timer {
+			s.mu.Unlock()
+			return
+		}
+		delete(s.timers, id)
+		s.mu.Unlock()
+
+		job()
+	})
+	s.timers[id] = timer
+}
+
+func (s *timerScheduler) Cancel(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if timer, exists := s.timers[id]; exists {
+		timer.Stop()
+		delete(s.timers, id)
+	}
+}
+
+func (s *timerScheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, timer := range s.timers {
+		timer.Stop()
+		delete(s.timers, id)
+	}
+	s.stopped = true
+}
@@ -0,0 +1,120 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+// waitTimeout bounds how long a test waits for a job that is expected to run
+const waitTimeout = time.Second
+
+func TestScheduler_Schedule(t *testing.T) {
+	t.Run("正常系: 指定時刻にジョブを実行", func(t *testing.T) {
+		// Arrange
+		scheduler := New()
+		defer scheduler.Stop()
+		done := make(chan struct{})
+
+		// Act
+		scheduler.Schedule("job-1", time.Now().Add(10*time.Millisecond), func() { close(done) })
+
+		// Assert
+		select {
+		case <-done:
+		case <-time.After(waitTimeout):
+			t.Fatal("ジョブが実行されませんでした")
+		}
+	})
+
+	t.Run("正常系: 過去の時刻のジョブはすぐに実行", func(t *testing.T) {
+		// Arrange
+		scheduler := New()
+		defer scheduler.Stop()
+		done := make(chan struct{})
+
+		// Act
+		scheduler.Schedule("job-1", time.Now().Add(-time.Hour), func() { close(done) })
+
+		// Assert
+		select {
+		case <-done:
+		case <-time.After(waitTimeout):
+			t.Fatal("ジョブが実行されませんでした")
+		}
+	})
+
+	t.Run("正常系: 同じIDで登録し直すと前のジョブは実行されない", func(t *testing.T) {
+		// Arrange
+		scheduler := New()
+		defer scheduler.Stop()
+		ran := make(chan string, 2)
+		scheduler.Schedule("job-1", time.Now().Add(20*time.Millisecond), func() { ran <- "old" })
+
+		// Act
+		scheduler.Schedule("job-1", time.Now().Add(40*time.Millisecond), func() { ran <- "new" })
+
+		// Assert
+		select {
+		case result := <-ran:
+			if result != "new" {
+				t.Errorf("実行されたジョブが期待値と異なります: got %v, want %v", result, "new")
+			}
+		case <-time.After(waitTimeout):
+			t.Fatal("ジョブが実行されませんでした")
+		}
+		select {
+		case result := <-ran:
+			t.Errorf("置き換えたジョブも実行されました: %v", result)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
+
+func TestScheduler_Cancel(t *testing.T) {
+	t.Run("正常系: キャンセルしたジョブは実行されない", func(t *testing.T) {
+		// Arrange
+		scheduler := New()
+		defer scheduler.Stop()
+		ran := make(chan struct{}, 1)
+		scheduler.Schedule("job-1", time.Now().Add(20*time.Millisecond), func() { ran <- struct{}{} })
+
+		// Act
+		scheduler.Cancel("job-1")
+
+		// Assert
+		select {
+		case <-ran:
+			t.Error("キャンセルしたジョブが実行されました")
+		case <-time.After(60 * time.Millisecond):
+		}
+	})
+
+	t.Run("正常系: 登録されていないIDのキャンセル", func(t *testing.T) {
+		// Arrange
+		scheduler := New()
+		defer scheduler.Stop()
+
+		// Act & Assert (パニックしないこと)
+		scheduler.Cancel("unknown")
+	})
+}
+
+func TestScheduler_Stop(t *testing.T) {
+	t.Run("正常系: 停止後はジョブを実行しない", func(t *testing.T) {
+		// Arrange
+		scheduler := New()
+		ran := make(chan struct{}, 2)
+		scheduler.Schedule("job-1", time.Now().Add(20*time.Millisecond), func() { ran <- struct{}{} })
+
+		// Act
+		scheduler.Stop()
+		scheduler.Schedule("job-2", time.Now(), func() { ran <- struct{}{} })
+
+		// Assert
+		select {
+		case <-ran:
+			t.Error("停止後にジョブが実行されました")
+		case <-time.After(60 * time.Millisecond):
+		}
+	})
+}
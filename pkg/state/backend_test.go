@@ -0,0 +1,70 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+// forEachBackend runs fn against every StateManager implementation
+func forEachBackend(t *testing.T, fn func(t *testing.T, newManager func() types.StateManager)) {
+	backends := []struct {
+		name       string
+		newManager func(t *testing.T) types.StateManager
+	}{
+		{
+			name: BackendMemory,
+			newManager: func(t *testing.T) types.StateManager {
+				return NewMemoryStateManager()
+			},
+		},
+		{
+			name: BackendFile,
+			newManager: func(t *testing.T) types.StateManager {
+				manager, err := NewFileStateManager(filepath.Join(t.TempDir(), "states.json"))
+				if err != nil {
+					t.Fatalf("ファイルバックエンドの作成に失敗: %v", err)
+				}
+				return manager
+			},
+		},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			fn(t, func() types.StateManager { return backend.newManager(t) })
+		})
+	}
+}
+
+// forEachRegistryBackend runs fn against every SurveyRegistry implementation
+func forEachRegistryBackend(t *testing.T, fn func(t *testing.T, newRegistry func() types.SurveyRegistry)) {
+	backends := []struct {
+		name        string
+		newRegistry func(t *testing.T) types.SurveyRegistry
+	}{
+		{
+			name: BackendMemory,
+			newRegistry: func(t *testing.T) types.SurveyRegistry {
+				return NewMemorySurveyRegistry()
+			},
+		},
+		{
+			name: BackendFile,
+			newRegistry: func(t *testing.T) types.SurveyRegistry {
+				registry, err := NewFileSurveyRegistry(filepath.Join(t.TempDir(), "surveys"))
+				if err != nil {
+					t.Fatalf("ファイルバックエンドの作成に失敗: %v", err)
+				}
+				return registry
+			},
+		},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			fn(t, func() types.SurveyRegistry { return backend.newRegistry(t) })
+		})
+	}
+}
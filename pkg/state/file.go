@@ -0,0 +1,108 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+type fileStateManager struct {
+	mu     sync.RWMutex
+	path   string
+	states map[string]*types.SurveyState
+}
+
+// NewFileStateManager creates a state manager that persists states as JSON at path
+func NewFileStateManager(path string) (types.StateManager, error) {
+	m := &fileStateManager{
+		path:   path,
+		states: make(map[string]*types.SurveyState),
+	}
+
+	if err := readJSONFile(path, &m.states); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (m *fileStateManager) GetState(ctx context.Context, key string) (*types.SurveyState, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if state, exists := m.states[key]; exists {
+		// Return a copy to avoid race conditions
+		return copyState(state), nil
+	}
+
+	return &types.SurveyState{}, nil
+}
+
+func (m *fileStateManager) SetState(ctx context.Context, key string, state *types.SurveyState) error {
+	if state == nil {
+		return fmt.Errorf("state cannot be nil")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous, existed := m.states[key]
+	m.states[key] = copyState(state)
+
+	if err := writeJSONFile(m.path, m.states); err != nil {
+		// Keep memory consistent with what is on disk
+		if existed {
+			m.states[key] = previous
+		} else {
+			delete(m.states, key)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (m *fileStateManager) ClearState(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous, existed := m.states[key]
+	if !existed {
+		return nil
+	}
+
+	delete(m.states, key)
+	if err := writeJSONFile(m.path, m.states); err != nil {
+		m.states[key] = previous
+		return err
+	}
+
+	return nil
+}
+
+func (m *fileStateManager) CompareAndClearState(ctx context.Context, key string, expected *types.SurveyState) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous, existed := m.states[key]
+	if !existed || !sameState(previous, expected) {
+		return false, nil
+	}
+
+	delete(m.states, key)
+	if err := writeJSONFile(m.path, m.states); err != nil {
+		m.states[key] = previous
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (m *fileStateManager) ListActiveStates(ctx context.Context, guildID string) ([]*types.SurveyState, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return activeStates(m.states, guildID), nil
+}
@@ -0,0 +1,121 @@
+package state
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+func TestFileStateManager_Persistence(t *testing.T) {
+	t.Run("正常系: 再起動後も状態が復元される", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		path := filepath.Join(t.TempDir(), "states.json")
+		manager, err := NewFileStateManager(path)
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		manager.SetState(ctx, "guild-keep", &types.SurveyState{Active: true, Title: "保存されるアンケート"})
+		manager.SetState(ctx, "guild-clear", &types.SurveyState{Active: true, Title: "削除されるアンケート"})
+		manager.ClearState(ctx, "guild-clear")
+
+		// Act
+		reopened, err := NewFileStateManager(path)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		kept, _ := reopened.GetState(ctx, "guild-keep")
+		if !kept.Active || kept.Title != "保存されるアンケート" {
+			t.Errorf("復元された状態が期待値と異なります: got %+v", kept)
+		}
+		cleared, _ := reopened.GetState(ctx, "guild-clear")
+		if cleared.Active || cleared.Title != "" {
+			t.Errorf("クリアした状態が復元されています: got %+v", cleared)
+		}
+	})
+
+	t.Run("正常系: 存在しないディレクトリを作成する", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		path := filepath.Join(t.TempDir(), "nested", "dir", "states.json")
+		manager, err := NewFileStateManager(path)
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+
+		// Act
+		err = manager.SetState(ctx, "guild", &types.SurveyState{Active: true})
+
+		// Assert
+		if err != nil {
+			t.Errorf("期待していないエラーが発生: %v", err)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("状態ファイルが作成されていません: %v", err)
+		}
+	})
+
+	t.Run("異常系: 壊れた状態ファイル", func(t *testing.T) {
+		// Arrange
+		path := filepath.Join(t.TempDir(), "states.json")
+		if err := os.WriteFile(path, []byte("{broken"), 0o644); err != nil {
+			t.Fatalf("事前準備でエラーが発生: %v", err)
+		}
+
+		// Act
+		_, err := NewFileStateManager(path)
+
+		// Assert
+		if err == nil {
+			t.Error("エラーが期待されていましたが、nilが返されました")
+		}
+	})
+}
+
+func TestNewStateManager(t *testing.T) {
+	t.Run("正常系: 設定に応じたバックエンドを作成", func(t *testing.T) {
+		// Arrange
+		testCases := []struct {
+			backend string
+		}{
+			{""},
+			{BackendMemory},
+			{BackendFile},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.backend, func(t *testing.T) {
+				cfg := &types.Config{StateBackend: tc.backend, StateDir: t.TempDir()}
+
+				// Act
+				manager, err := NewStateManager(cfg)
+
+				// Assert
+				if err != nil {
+					t.Errorf("期待していないエラーが発生: %v", err)
+				}
+				if manager == nil {
+					t.Error("StateManagerがnilです")
+				}
+			})
+		}
+	})
+
+	t.Run("異常系: 未知のバックエンド", func(t *testing.T) {
+		// Arrange
+		cfg := &types.Config{StateBackend: "redis"}
+
+		// Act
+		_, err := NewStateManager(cfg)
+
+		// Assert
+		if err == nil {
+			t.Error("エラーが期待されていましたが、nilが返されました")
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+type memoryGuildSettingsStore struct {
+	mu       sync.RWMutex
+	settings map[string]*types.GuildSettings
+}
+
+// NewMemoryGuildSettingsStore creates a new in-memory guild settings store
+func NewMemoryGuildSettingsStore() types.GuildSettingsStore {
+	return &memoryGuildSettingsStore{
+		settings: make(map[string]*types.GuildSettings),
+	}
+}
+
+func (m *memoryGuildSettingsStore) GetGuildSettings(ctx context.Context, guildID string) (*types.GuildSettings, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if settings, exists := m.settings[guildID]; exists {
+		copied := *settings
+		return &copied, nil
+	}
+
+	return &types.GuildSettings{}, nil
+}
+
+func (m *memoryGuildSettingsStore) SetGuildSettings(ctx context.Context, guildID string, settings *types.GuildSettings) error {
+	if settings == nil {
+		return fmt.Errorf("guild settings cannot be nil")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	copied := *settings
+	m.settings[guildID] = &copied
+	return nil
+}
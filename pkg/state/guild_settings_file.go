@@ -0,0 +1,66 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+type fileGuildSettingsStore struct {
+	mu       sync.RWMutex
+	path     string
+	settings map[string]*types.GuildSettings
+}
+
+// NewFileGuildSettingsStore creates a guild settings store that persists settings as JSON at path
+func NewFileGuildSettingsStore(path string) (types.GuildSettingsStore, error) {
+	m := &fileGuildSettingsStore{
+		path:     path,
+		settings: make(map[string]*types.GuildSettings),
+	}
+
+	if err := readJSONFile(path, &m.settings); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (m *fileGuildSettingsStore) GetGuildSettings(ctx context.Context, guildID string) (*types.GuildSettings, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if settings, exists := m.settings[guildID]; exists {
+		copied := *settings
+		return &copied, nil
+	}
+
+	return &types.GuildSettings{}, nil
+}
+
+func (m *fileGuildSettingsStore) SetGuildSettings(ctx context.Context, guildID string, settings *types.GuildSettings) error {
+	if settings == nil {
+		return fmt.Errorf("guild settings cannot be nil")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous, existed := m.settings[guildID]
+	copied := *settings
+	m.settings[guildID] = &copied
+
+	if err := writeJSONFile(m.path, m.settings); err != nil {
+		// Keep memory consistent with what is on disk
+		if existed {
+			m.settings[guildID] = previous
+		} else {
+			delete(m.settings, guildID)
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package state
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+func TestGuildSettingsStore(t *testing.T) {
+	backends := []struct {
+		name     string
+		newStore func(t *testing.T) types.GuildSettingsStore
+	}{
+		{
+			name: BackendMemory,
+			newStore: func(t *testing.T) types.GuildSettingsStore {
+				return NewMemoryGuildSettingsStore()
+			},
+		},
+		{
+			name: BackendFile,
+			newStore: func(t *testing.T) types.GuildSettingsStore {
+				store, err := NewFileGuildSettingsStore(filepath.Join(t.TempDir(), "guilds.json"))
+				if err != nil {
+					t.Fatalf("ファイルバックエンドの作成に失敗: %v", err)
+				}
+				return store
+			},
+		},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			t.Run("正常系: 設定を保存して取得", func(t *testing.T) {
+				// Arrange
+				store := backend.newStore(t)
+				ctx := context.Background()
+
+				// Act
+				err := store.SetGuildSettings(ctx, "guild-1", &types.GuildSettings{EmojiSet: types.EmojiSetAlphabet})
+
+				// Assert
+				if err != nil {
+					t.Fatalf("期待していないエラーが発生: %v", err)
+				}
+				result, err := store.GetGuildSettings(ctx, "guild-1")
+				if err != nil {
+					t.Fatalf("期待していないエラーが発生: %v", err)
+				}
+				if result.EmojiSet != types.EmojiSetAlphabet {
+					t.Errorf("絵文字セットが期待値と異なります: got %v, want %v", result.EmojiSet, types.EmojiSetAlphabet)
+				}
+			})
+
+			t.Run("正常系: 未設定のギルドは空の設定", func(t *testing.T) {
+				// Arrange
+				store := backend.newStore(t)
+
+				// Act
+				result, err := store.GetGuildSettings(context.Background(), "unknown")
+
+				// Assert
+				if err != nil {
+					t.Fatalf("期待していないエラーが発生: %v", err)
+				}
+				if result.EmojiSet != "" {
+					t.Errorf("空の設定が期待されていましたが、%+vが返されました", result)
+				}
+			})
+
+			t.Run("異常系: nilの設定を保存", func(t *testing.T) {
+				// Arrange
+				store := backend.newStore(t)
+
+				// Act
+				err := store.SetGuildSettings(context.Background(), "guild-1", nil)
+
+				// Assert
+				if err == nil {
+					t.Error("エラーが期待されていましたが、nilが返されました")
+				}
+			})
+		})
+	}
+}
+
+func TestFileGuildSettingsStore_Persistence(t *testing.T) {
+	t.Run("正常系: 再起動後も設定が復元される", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		path := filepath.Join(t.TempDir(), "guilds.json")
+		store, err := NewFileGuildSettingsStore(path)
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		store.SetGuildSettings(ctx, "guild-1", &types.GuildSettings{EmojiSet: types.EmojiSetCircle})
+
+		// Act
+		reopened, err := NewFileGuildSettingsStore(path)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		result, _ := reopened.GetGuildSettings(ctx, "guild-1")
+		if result.EmojiSet != types.EmojiSetCircle {
+			t.Errorf("復元された設定が期待値と異なります: got %v, want %v", result.EmojiSet, types.EmojiSetCircle)
+		}
+	})
+}
@@ -3,6 +3,8 @@ package state
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"sort"
 	"sync"
 
 	"github.com/Logta/SurveyBot/types"
@@ -20,22 +22,19 @@ func NewMemoryStateManager() types.StateManager {
 	}
 }
 
-func (m *memoryStateManager) GetState(ctx context.Context, guildID string) (*types.SurveyState, error) {
+func (m *memoryStateManager) GetState(ctx context.Context, key string) (*types.SurveyState, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	if state, exists := m.states[guildID]; exists {
+	if state, exists := m.states[key]; exists {
 		// Return a copy to avoid race conditions
-		return &types.SurveyState{
-			Active: state.Active,
-			Title:  state.Title,
-		}, nil
+		return copyState(state), nil
 	}
 
 	return &types.SurveyState{}, nil
 }
 
-func (m *memoryStateManager) SetState(ctx context.Context, guildID string, state *types.SurveyState) error {
+func (m *memoryStateManager) SetState(ctx context.Context, key string, state *types.SurveyState) error {
 	if state == nil {
 		return fmt.Errorf("state cannot be nil")
 	}
@@ -43,18 +42,70 @@ func (m *memoryStateManager) SetState(ctx context.Context, guildID string, state
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.states[guildID] = &types.SurveyState{
-		Active: state.Active,
-		Title:  state.Title,
-	}
+	m.states[key] = copyState(state)
 
 	return nil
 }
 
-func (m *memoryStateManager) ClearState(ctx context.Context, guildID string) error {
+func (m *memoryStateManager) ClearState(ctx context.Context, key string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	delete(m.states, guildID)
+	delete(m.states, key)
 	return nil
 }
+
+func (m *memoryStateManager) CompareAndClearState(ctx context.Context, key string, expected *types.SurveyState) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !sameState(m.states[key], expected) {
+		return false, nil
+	}
+
+	delete(m.states, key)
+	return true, nil
+}
+
+func (m *memoryStateManager) ListActiveStates(ctx context.Context, guildID string) ([]*types.SurveyState, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return activeStates(m.states, guildID), nil
+}
+
+func copyState(state *types.SurveyState) *types.SurveyState {
+	copied := *state
+	// The options are copied too, so callers cannot change a stored draft through them
+	if state.Options != nil {
+		copied.Options = append([]string(nil), state.Options...)
+	}
+	return &copied
+}
+
+// sameState reports whether the stored state equals expected; nothing stored equals nothing
+func sameState(stored, expected *types.SurveyState) bool {
+	if stored == nil || expected == nil {
+		return stored == nil && expected == nil
+	}
+	return reflect.DeepEqual(stored, expected)
+}
+
+// activeStates returns copies of the active drafts in a guild ordered by channel and author
+func activeStates(states map[string]*types.SurveyState, guildID string) []*types.SurveyState {
+	result := []*types.SurveyState{}
+	for _, state := range states {
+		if state.Active && state.GuildID == guildID {
+			result = append(result, copyState(state))
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ChannelID != result[j].ChannelID {
+			return result[i].ChannelID < result[j].ChannelID
+		}
+		return result[i].AuthorID < result[j].AuthorID
+	})
+
+	return result
+}
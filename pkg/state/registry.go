@@ -0,0 +1,196 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/Logta/SurveyBot/utils"
+)
+
+// closedSurveyRetention is how long a closed survey stays registered for !close and
+// !export before it is dropped
+const closedSurveyRetention = 90 * 24 * time.Hour
+
+type memorySurveyRegistry struct {
+	mu      sync.RWMutex
+	surveys map[string]*types.Survey
+}
+
+// NewMemorySurveyRegistry creates a new in-memory survey registry
+func NewMemorySurveyRegistry() types.SurveyRegistry {
+	return &memorySurveyRegistry{
+		surveys: make(map[string]*types.Survey),
+	}
+}
+
+func (r *memorySurveyRegistry) SaveSurvey(ctx context.Context, survey *types.Survey) error {
+	if err := validateSurvey(survey); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.surveys[survey.MessageID] = copySurvey(survey)
+	for _, messageID := range expiredSurveys(r.surveys, time.Now()) {
+		delete(r.surveys, messageID)
+	}
+	return nil
+}
+
+func (r *memorySurveyRegistry) GetSurvey(ctx context.Context, messageID string) (*types.Survey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	survey, exists := r.surveys[messageID]
+	if !exists {
+		return nil, types.ErrSurveyNotFound
+	}
+
+	return copySurvey(survey), nil
+}
+
+func (r *memorySurveyRegistry) GetLatestSurvey(ctx context.Context, channelID string) (*types.Survey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return latestSurvey(r.surveys, channelID)
+}
+
+func (r *memorySurveyRegistry) UpdateSurvey(ctx context.Context, messageID string, update func(*types.Survey) error) (*types.Survey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	survey, exists := r.surveys[messageID]
+	if !exists {
+		return nil, types.ErrSurveyNotFound
+	}
+
+	updated, err := applyUpdate(survey, update)
+	if err != nil {
+		return nil, err
+	}
+	r.surveys[messageID] = updated
+	return copySurvey(updated), nil
+}
+
+func (r *memorySurveyRegistry) RecordVote(ctx context.Context, messageID string, vote types.Vote) (*types.Survey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	survey, exists := r.surveys[messageID]
+	if !exists {
+		return nil, types.ErrSurveyNotFound
+	}
+
+	if survey.Closed {
+		return nil, types.ErrSurveyClosed
+	}
+
+	survey.Votes = utils.ReplaceVote(survey.Votes, vote)
+	return copySurvey(survey), nil
+}
+
+func (r *memorySurveyRegistry) ListOpenSurveys(ctx context.Context) ([]*types.Survey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return openSurveys(r.surveys), nil
+}
+
+// validateSurvey rejects surveys that cannot be stored
+func validateSurvey(survey *types.Survey) error {
+	if survey == nil {
+		return fmt.Errorf("survey cannot be nil")
+	}
+	if survey.MessageID == "" {
+		return fmt.Errorf("survey message ID cannot be empty")
+	}
+	return nil
+}
+
+// applyUpdate runs update on a copy of survey, so a failed update leaves it untouched
+func applyUpdate(survey *types.Survey, update func(*types.Survey) error) (*types.Survey, error) {
+	updated := copySurvey(survey)
+	if err := update(updated); err != nil {
+		return nil, err
+	}
+	if updated.MessageID != survey.MessageID {
+		return nil, fmt.Errorf("survey message ID cannot be changed")
+	}
+	return updated, nil
+}
+
+// latestSurvey returns a copy of the most recently created survey in the channel
+func latestSurvey(surveys map[string]*types.Survey, channelID string) (*types.Survey, error) {
+	var latest *types.Survey
+	for _, survey := range surveys {
+		if survey.ChannelID != channelID {
+			continue
+		}
+		if latest == nil || survey.CreatedAt.After(latest.CreatedAt) {
+			latest = survey
+		}
+	}
+
+	if latest == nil {
+		return nil, types.ErrSurveyNotFound
+	}
+
+	return copySurvey(latest), nil
+}
+
+// openSurveys returns copies of the surveys that have not been closed, oldest first
+func openSurveys(surveys map[string]*types.Survey) []*types.Survey {
+	result := make([]*types.Survey, 0, len(surveys))
+	for _, survey := range surveys {
+		if !survey.Closed {
+			result = append(result, copySurvey(survey))
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.Before(result[j].CreatedAt)
+		}
+		return result[i].MessageID < result[j].MessageID
+	})
+
+	return result
+}
+
+// expiredSurveys returns the IDs of the surveys closed longer than closedSurveyRetention
+// before now. Surveys closed before ClosedAt was recorded count from their creation.
+func expiredSurveys(surveys map[string]*types.Survey, now time.Time) []string {
+	var expired []string
+	for messageID, survey := range surveys {
+		if !survey.Closed {
+			continue
+		}
+		closedAt := survey.ClosedAt
+		if closedAt.IsZero() {
+			closedAt = survey.CreatedAt
+		}
+		if now.Sub(closedAt) > closedSurveyRetention {
+			expired = append(expired, messageID)
+		}
+	}
+	return expired
+}
+
+// copySurvey returns a deep copy so callers cannot mutate stored surveys
+func copySurvey(survey *types.Survey) *types.Survey {
+	copied := *survey
+	copied.Options = append([]string(nil), survey.Options...)
+	copied.Emojis = append([]string(nil), survey.Emojis...)
+	copied.Votes = make([]types.Vote, len(survey.Votes))
+	for i, vote := range survey.Votes {
+		copied.Votes[i] = vote
+		copied.Votes[i].Choices = append([]int(nil), vote.Choices...)
+	}
+	return &copied
+}
@@ -0,0 +1,208 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+	"github.com/Logta/SurveyBot/utils"
+)
+
+// surveyFileExt ends the name of each survey's file in the registry directory
+const surveyFileExt = ".json"
+
+type fileSurveyRegistry struct {
+	mu      sync.RWMutex
+	dir     string
+	surveys map[string]*types.Survey
+}
+
+// NewFileSurveyRegistry creates a survey registry that persists each survey as a JSON file
+// in dir, so that a vote rewrites only the survey it is cast on. Surveys kept in a single
+// dir+".json" file by earlier versions are moved into dir.
+func NewFileSurveyRegistry(dir string) (types.SurveyRegistry, error) {
+	r := &fileSurveyRegistry{
+		dir:     dir,
+		surveys: make(map[string]*types.Survey),
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), surveyFileExt) {
+			continue
+		}
+		var survey types.Survey
+		if err := readJSONFile(filepath.Join(dir, entry.Name()), &survey); err != nil {
+			return nil, err
+		}
+		r.surveys[survey.MessageID] = &survey
+	}
+
+	if err := r.migrate(dir + surveyFileExt); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.prune(time.Now()); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// migrate moves the surveys of a single-file registry at legacyPath into their own files
+func (r *fileSurveyRegistry) migrate(legacyPath string) error {
+	legacy := make(map[string]*types.Survey)
+	if err := readJSONFile(legacyPath, &legacy); err != nil {
+		return err
+	}
+	if len(legacy) == 0 {
+		return nil
+	}
+
+	for messageID, survey := range legacy {
+		if _, exists := r.surveys[messageID]; exists {
+			continue
+		}
+		if err := r.replace(messageID, survey); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(legacyPath, legacyPath+".migrated"); err != nil {
+		return fmt.Errorf("failed to retire %s: %w", legacyPath, err)
+	}
+	return nil
+}
+
+func (r *fileSurveyRegistry) SaveSurvey(ctx context.Context, survey *types.Survey) error {
+	if err := validateSurvey(survey); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.replace(survey.MessageID, copySurvey(survey)); err != nil {
+		return err
+	}
+	return r.prune(time.Now())
+}
+
+func (r *fileSurveyRegistry) GetSurvey(ctx context.Context, messageID string) (*types.Survey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	survey, exists := r.surveys[messageID]
+	if !exists {
+		return nil, types.ErrSurveyNotFound
+	}
+
+	return copySurvey(survey), nil
+}
+
+func (r *fileSurveyRegistry) GetLatestSurvey(ctx context.Context, channelID string) (*types.Survey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return latestSurvey(r.surveys, channelID)
+}
+
+func (r *fileSurveyRegistry) UpdateSurvey(ctx context.Context, messageID string, update func(*types.Survey) error) (*types.Survey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	survey, exists := r.surveys[messageID]
+	if !exists {
+		return nil, types.ErrSurveyNotFound
+	}
+
+	updated, err := applyUpdate(survey, update)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.replace(messageID, updated); err != nil {
+		return nil, err
+	}
+
+	return copySurvey(updated), nil
+}
+
+func (r *fileSurveyRegistry) RecordVote(ctx context.Context, messageID string, vote types.Vote) (*types.Survey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	survey, exists := r.surveys[messageID]
+	if !exists {
+		return nil, types.ErrSurveyNotFound
+	}
+
+	if survey.Closed {
+		return nil, types.ErrSurveyClosed
+	}
+
+	updated := copySurvey(survey)
+	updated.Votes = utils.ReplaceVote(updated.Votes, vote)
+	if err := r.replace(messageID, updated); err != nil {
+		return nil, err
+	}
+
+	return copySurvey(updated), nil
+}
+
+func (r *fileSurveyRegistry) ListOpenSurveys(ctx context.Context) ([]*types.Survey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return openSurveys(r.surveys), nil
+}
+
+// replace stores survey under messageID and writes its file, keeping the previous entry
+// if the write fails. Callers must hold r.mu.
+func (r *fileSurveyRegistry) replace(messageID string, survey *types.Survey) error {
+	path, err := r.surveyPath(messageID)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONFile(path, survey); err != nil {
+		return err
+	}
+
+	r.surveys[messageID] = survey
+	return nil
+}
+
+// prune deletes the surveys closed longer than closedSurveyRetention before now. Callers
+// must hold r.mu.
+func (r *fileSurveyRegistry) prune(now time.Time) error {
+	for _, messageID := range expiredSurveys(r.surveys, now) {
+		path, err := r.surveyPath(messageID)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		delete(r.surveys, messageID)
+	}
+	return nil
+}
+
+// surveyPath returns the file of the survey posted as messageID. Message IDs are Discord
+// snowflakes, so anything that could leave the directory is refused.
+func (r *fileSurveyRegistry) surveyPath(messageID string) (string, error) {
+	if messageID == "" || strings.HasPrefix(messageID, ".") || filepath.Base(messageID) != messageID {
+		return "", fmt.Errorf("invalid survey message ID %q", messageID)
+	}
+	return filepath.Join(r.dir, messageID+surveyFileExt), nil
+}
@@ -0,0 +1,205 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+func TestFileSurveyRegistry_Persistence(t *testing.T) {
+	t.Run("正常系: 再起動後もアンケートと投票が復元される", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		path := filepath.Join(t.TempDir(), "surveys")
+		registry, err := NewFileSurveyRegistry(path)
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		deadline := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+		registry.SaveSurvey(ctx, &types.Survey{
+			MessageID:      "message-1",
+			ChannelID:      "channel-1",
+			Title:          "期限付きアンケート",
+			Options:        []string{"A", "B"},
+			Emojis:         []string{"1️⃣", "2️⃣"},
+			SurveySettings: types.SurveySettings{VoteMode: types.VoteModeComponent, Deadline: deadline},
+		})
+		registry.RecordVote(ctx, "message-1", types.Vote{UserID: "user-1", Choices: []int{1}})
+
+		// Act
+		reopened, err := NewFileSurveyRegistry(path)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		survey, err := reopened.GetSurvey(ctx, "message-1")
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if survey.Title != "期限付きアンケート" || !survey.Deadline.Equal(deadline) {
+			t.Errorf("復元されたアンケートが期待値と異なります: got %+v", survey)
+		}
+		if len(survey.Votes) != 1 || survey.Votes[0].Choices[0] != 1 {
+			t.Errorf("復元された投票が期待値と異なります: got %+v", survey.Votes)
+		}
+	})
+}
+
+func TestNewSurveyRegistry(t *testing.T) {
+	t.Run("正常系: 設定に応じたバックエンドを作成", func(t *testing.T) {
+		// Arrange
+		testCases := []struct {
+			backend string
+		}{
+			{""},
+			{BackendMemory},
+			{BackendFile},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.backend, func(t *testing.T) {
+				cfg := &types.Config{StateBackend: tc.backend, StateDir: t.TempDir()}
+
+				// Act
+				registry, err := NewSurveyRegistry(cfg)
+
+				// Assert
+				if err != nil {
+					t.Errorf("期待していないエラーが発生: %v", err)
+				}
+				if registry == nil {
+					t.Error("SurveyRegistryがnilです")
+				}
+			})
+		}
+	})
+
+	t.Run("異常系: 未知のバックエンド", func(t *testing.T) {
+		// Arrange
+		cfg := &types.Config{StateBackend: "redis"}
+
+		// Act
+		_, err := NewSurveyRegistry(cfg)
+
+		// Assert
+		if err == nil {
+			t.Error("エラーが期待されていましたが、nilが返されました")
+		}
+	})
+}
+
+func TestFileSurveyRegistry_FilePerSurvey(t *testing.T) {
+	t.Run("正常系: 投票は対象のアンケートのファイルだけを書き換える", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		dir := filepath.Join(t.TempDir(), "surveys")
+		registry, err := NewFileSurveyRegistry(dir)
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		registry.SaveSurvey(ctx, &types.Survey{MessageID: "message-1", Options: []string{"A", "B"}})
+		registry.SaveSurvey(ctx, &types.Survey{MessageID: "message-2", Options: []string{"A", "B"}})
+		other := filepath.Join(dir, "message-2.json")
+		before, err := os.ReadFile(other)
+		if err != nil {
+			t.Fatalf("アンケートのファイルがありません: %v", err)
+		}
+		os.Remove(other)
+
+		// Act
+		_, err = registry.RecordVote(ctx, "message-1", types.Vote{UserID: "user-1", Choices: []int{0}})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if _, err := os.Stat(other); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("投票していないアンケートのファイルが書き換えられました: %s", before)
+		}
+	})
+
+	t.Run("正常系: 1つのファイルに保存されたアンケートを移行する", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		dir := filepath.Join(t.TempDir(), "surveys")
+		legacy := map[string]*types.Survey{
+			"message-1": {MessageID: "message-1", Title: "以前のアンケート", Options: []string{"A"}},
+		}
+		data, _ := json.Marshal(legacy)
+		if err := os.WriteFile(dir+".json", data, 0o644); err != nil {
+			t.Fatalf("ファイルの作成に失敗: %v", err)
+		}
+
+		// Act
+		registry, err := NewFileSurveyRegistry(dir)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		survey, err := registry.GetSurvey(ctx, "message-1")
+		if err != nil || survey.Title != "以前のアンケート" {
+			t.Errorf("移行したアンケートが期待値と異なります: got %+v, %v", survey, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "message-1.json")); err != nil {
+			t.Errorf("アンケートのファイルが作成されていません: %v", err)
+		}
+		if _, err := os.Stat(dir + ".json"); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("移行元のファイルが残っています: %v", err)
+		}
+	})
+
+	t.Run("異常系: ディレクトリの外を指すメッセージID", func(t *testing.T) {
+		// Arrange
+		registry, err := NewFileSurveyRegistry(filepath.Join(t.TempDir(), "surveys"))
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+
+		// Act
+		err = registry.SaveSurvey(context.Background(), &types.Survey{MessageID: "../message-1"})
+
+		// Assert
+		if err == nil {
+			t.Error("エラーが期待されていましたが、nilが返されました")
+		}
+	})
+}
+
+func TestFileSurveyRegistry_Prune(t *testing.T) {
+	t.Run("正常系: 保存期間を過ぎた締め切り済みのアンケートを起動時に削除する", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		dir := filepath.Join(t.TempDir(), "surveys")
+		registry, err := NewFileSurveyRegistry(dir)
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		registry.SaveSurvey(ctx, &types.Survey{MessageID: "message-old", Closed: true, ClosedAt: time.Now().Add(-closedSurveyRetention - time.Hour)})
+		registry.SaveSurvey(ctx, &types.Survey{MessageID: "message-open", CreatedAt: time.Now().Add(-closedSurveyRetention - time.Hour)})
+
+		// Act
+		reopened, err := NewFileSurveyRegistry(dir)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if _, err := reopened.GetSurvey(ctx, "message-old"); !errors.Is(err, types.ErrSurveyNotFound) {
+			t.Errorf("保存期間を過ぎたアンケートが残っています: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "message-old.json")); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("保存期間を過ぎたアンケートのファイルが残っています: %v", err)
+		}
+		if _, err := reopened.GetSurvey(ctx, "message-open"); err != nil {
+			t.Errorf("受付中のアンケートが削除されました: %v", err)
+		}
+	})
+}
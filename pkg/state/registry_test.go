@@ -0,0 +1,401 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+func TestSurveyRegistry_SaveSurvey(t *testing.T) {
+	forEachRegistryBackend(t, func(t *testing.T, newRegistry func() types.SurveyRegistry) {
+		t.Run("正常系: アンケートを登録してIDで取得", func(t *testing.T) {
+			// Arrange
+			registry := newRegistry()
+			ctx := context.Background()
+			survey := &types.Survey{
+				MessageID: "message-1",
+				ChannelID: "channel-1",
+				GuildID:   "guild-1",
+				AuthorID:  "author-1",
+				Title:     "好きな言語",
+				Options:   []string{"Go", "Rust"},
+				Emojis:    []string{"1️⃣", "2️⃣"},
+				CreatedAt: time.Now(),
+			}
+
+			// Act
+			err := registry.SaveSurvey(ctx, survey)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("期待していないエラーが発生: %v", err)
+			}
+			result, err := registry.GetSurvey(ctx, "message-1")
+			if err != nil {
+				t.Fatalf("期待していないエラーが発生: %v", err)
+			}
+			if result.Title != survey.Title || result.GuildID != survey.GuildID || result.AuthorID != survey.AuthorID {
+				t.Errorf("アンケートが期待値と異なります: got %+v, want %+v", result, survey)
+			}
+			if len(result.Options) != 2 || result.Emojis[1] != "2️⃣" {
+				t.Errorf("選択肢と絵文字の対応が期待値と異なります: got %v %v", result.Options, result.Emojis)
+			}
+		})
+
+		t.Run("異常系: nilのアンケートを登録", func(t *testing.T) {
+			// Arrange
+			registry := newRegistry()
+
+			// Act
+			err := registry.SaveSurvey(context.Background(), nil)
+
+			// Assert
+			if err == nil {
+				t.Error("エラーが期待されていましたが、nilが返されました")
+			}
+		})
+
+		t.Run("異常系: メッセージIDが空のアンケートを登録", func(t *testing.T) {
+			// Arrange
+			registry := newRegistry()
+
+			// Act
+			err := registry.SaveSurvey(context.Background(), &types.Survey{Title: "IDなし"})
+
+			// Assert
+			if err == nil {
+				t.Error("エラーが期待されていましたが、nilが返されました")
+			}
+		})
+
+		t.Run("正常系: 登録後に元のアンケートを変更しても影響しない", func(t *testing.T) {
+			// Arrange
+			registry := newRegistry()
+			ctx := context.Background()
+			survey := &types.Survey{MessageID: "message-copy", Options: []string{"A"}}
+			registry.SaveSurvey(ctx, survey)
+
+			// Act
+			survey.Options[0] = "変更後"
+			result, _ := registry.GetSurvey(ctx, "message-copy")
+
+			// Assert
+			if result.Options[0] != "A" {
+				t.Error("アンケートのコピーが正しく保存されていません")
+			}
+		})
+	})
+}
+
+func TestSurveyRegistry_GetSurvey(t *testing.T) {
+	forEachRegistryBackend(t, func(t *testing.T, newRegistry func() types.SurveyRegistry) {
+		t.Run("異常系: 登録されていないアンケート", func(t *testing.T) {
+			// Arrange
+			registry := newRegistry()
+
+			// Act
+			result, err := registry.GetSurvey(context.Background(), "unknown")
+
+			// Assert
+			if !errors.Is(err, types.ErrSurveyNotFound) {
+				t.Errorf("ErrSurveyNotFoundが期待されていましたが、%vが返されました", err)
+			}
+			if result != nil {
+				t.Errorf("nilが期待されていましたが、%vが返されました", result)
+			}
+		})
+	})
+}
+
+func TestSurveyRegistry_GetLatestSurvey(t *testing.T) {
+	forEachRegistryBackend(t, func(t *testing.T, newRegistry func() types.SurveyRegistry) {
+		t.Run("正常系: チャンネル内の最新のアンケートを取得", func(t *testing.T) {
+			// Arrange
+			registry := newRegistry()
+			ctx := context.Background()
+			now := time.Now()
+			registry.SaveSurvey(ctx, &types.Survey{MessageID: "old", ChannelID: "channel-1", CreatedAt: now.Add(-time.Hour)})
+			registry.SaveSurvey(ctx, &types.Survey{MessageID: "new", ChannelID: "channel-1", CreatedAt: now})
+			registry.SaveSurvey(ctx, &types.Survey{MessageID: "other", ChannelID: "channel-2", CreatedAt: now.Add(time.Hour)})
+
+			// Act
+			result, err := registry.GetLatestSurvey(ctx, "channel-1")
+
+			// Assert
+			if err != nil {
+				t.Fatalf("期待していないエラーが発生: %v", err)
+			}
+			if result.MessageID != "new" {
+				t.Errorf("最新のアンケートが期待値と異なります: got %v, want %v", result.MessageID, "new")
+			}
+		})
+
+		t.Run("異常系: チャンネルにアンケートがない", func(t *testing.T) {
+			// Arrange
+			registry := newRegistry()
+			ctx := context.Background()
+			registry.SaveSurvey(ctx, &types.Survey{MessageID: "other", ChannelID: "channel-2"})
+
+			// Act
+			_, err := registry.GetLatestSurvey(ctx, "channel-1")
+
+			// Assert
+			if !errors.Is(err, types.ErrSurveyNotFound) {
+				t.Errorf("ErrSurveyNotFoundが期待されていましたが、%vが返されました", err)
+			}
+		})
+	})
+}
+
+func TestSurveyRegistry_RecordVote(t *testing.T) {
+	forEachRegistryBackend(t, func(t *testing.T, newRegistry func() types.SurveyRegistry) {
+		t.Run("正常系: 同じユーザーの投票は置き換えられる", func(t *testing.T) {
+			// Arrange
+			registry := newRegistry()
+			ctx := context.Background()
+			registry.SaveSurvey(ctx, &types.Survey{MessageID: "message-1", Options: []string{"A", "B"}})
+			registry.RecordVote(ctx, "message-1", types.Vote{UserID: "user-1", Choices: []int{0}})
+			registry.RecordVote(ctx, "message-1", types.Vote{UserID: "user-2", Choices: []int{0}})
+
+			// Act
+			result, err := registry.RecordVote(ctx, "message-1", types.Vote{UserID: "user-1", Choices: []int{1}})
+
+			// Assert
+			if err != nil {
+				t.Fatalf("期待していないエラーが発生: %v", err)
+			}
+			if len(result.Votes) != 2 {
+				t.Fatalf("投票数が期待値と異なります: got %v, want %v", len(result.Votes), 2)
+			}
+			stored, _ := registry.GetSurvey(ctx, "message-1")
+			for _, vote := range stored.Votes {
+				if vote.UserID == "user-1" && (len(vote.Choices) != 1 || vote.Choices[0] != 1) {
+					t.Errorf("置き換えた投票が期待値と異なります: got %v, want %v", vote.Choices, []int{1})
+				}
+			}
+		})
+
+		t.Run("正常系: 選択のない投票で投票を取り消す", func(t *testing.T) {
+			// Arrange
+			registry := newRegistry()
+			ctx := context.Background()
+			registry.SaveSurvey(ctx, &types.Survey{MessageID: "message-1", Options: []string{"A"}})
+			registry.RecordVote(ctx, "message-1", types.Vote{UserID: "user-1", Choices: []int{0}})
+
+			// Act
+			result, err := registry.RecordVote(ctx, "message-1", types.Vote{UserID: "user-1"})
+
+			// Assert
+			if err != nil {
+				t.Fatalf("期待していないエラーが発生: %v", err)
+			}
+			if len(result.Votes) != 0 {
+				t.Errorf("投票が取り消されていません: %v", result.Votes)
+			}
+		})
+
+		t.Run("正常系: 返された投票を変更しても保存内容に影響しない", func(t *testing.T) {
+			// Arrange
+			registry := newRegistry()
+			ctx := context.Background()
+			registry.SaveSurvey(ctx, &types.Survey{MessageID: "message-1", Options: []string{"A", "B"}})
+			result, _ := registry.RecordVote(ctx, "message-1", types.Vote{UserID: "user-1", Choices: []int{0}})
+
+			// Act
+			result.Votes[0].Choices[0] = 1
+
+			// Assert
+			stored, _ := registry.GetSurvey(ctx, "message-1")
+			if stored.Votes[0].Choices[0] != 0 {
+				t.Error("投票のコピーが正しく返されていません")
+			}
+		})
+
+		t.Run("異常系: 締め切られたアンケートへの投票", func(t *testing.T) {
+			// Arrange
+			registry := newRegistry()
+			ctx := context.Background()
+			registry.SaveSurvey(ctx, &types.Survey{MessageID: "message-1", Options: []string{"A"}, Closed: true, ClosedAt: time.Now()})
+
+			// Act
+			_, err := registry.RecordVote(ctx, "message-1", types.Vote{UserID: "user-1", Choices: []int{0}})
+
+			// Assert
+			if !errors.Is(err, types.ErrSurveyClosed) {
+				t.Errorf("ErrSurveyClosedが期待されていましたが、%vが返されました", err)
+			}
+			stored, _ := registry.GetSurvey(ctx, "message-1")
+			if len(stored.Votes) != 0 {
+				t.Errorf("締め切り後の投票が保存されています: %v", stored.Votes)
+			}
+		})
+
+		t.Run("異常系: 登録されていないアンケートへの投票", func(t *testing.T) {
+			// Arrange
+			registry := newRegistry()
+
+			// Act
+			_, err := registry.RecordVote(context.Background(), "unknown", types.Vote{UserID: "user-1", Choices: []int{0}})
+
+			// Assert
+			if !errors.Is(err, types.ErrSurveyNotFound) {
+				t.Errorf("ErrSurveyNotFoundが期待されていましたが、%vが返されました", err)
+			}
+		})
+	})
+}
+
+func TestSurveyRegistry_ListOpenSurveys(t *testing.T) {
+	forEachRegistryBackend(t, func(t *testing.T, newRegistry func() types.SurveyRegistry) {
+		t.Run("正常系: 締め切られていないアンケートを古い順に取得", func(t *testing.T) {
+			// Arrange
+			registry := newRegistry()
+			ctx := context.Background()
+			now := time.Now()
+			registry.SaveSurvey(ctx, &types.Survey{MessageID: "new", CreatedAt: now})
+			registry.SaveSurvey(ctx, &types.Survey{MessageID: "closed", CreatedAt: now.Add(-2 * time.Hour), Closed: true})
+			registry.SaveSurvey(ctx, &types.Survey{MessageID: "old", CreatedAt: now.Add(-time.Hour)})
+
+			// Act
+			result, err := registry.ListOpenSurveys(ctx)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("期待していないエラーが発生: %v", err)
+			}
+			if len(result) != 2 || result[0].MessageID != "old" || result[1].MessageID != "new" {
+				t.Errorf("アンケートの一覧が期待値と異なります: got %v", result)
+			}
+		})
+
+		t.Run("正常系: アンケートがない場合", func(t *testing.T) {
+			// Arrange
+			registry := newRegistry()
+
+			// Act
+			result, err := registry.ListOpenSurveys(context.Background())
+
+			// Assert
+			if err != nil {
+				t.Fatalf("期待していないエラーが発生: %v", err)
+			}
+			if len(result) != 0 {
+				t.Errorf("空の一覧が期待されていましたが、%vが返されました", result)
+			}
+		})
+	})
+}
+
+func TestSurveyRegistry_Retention(t *testing.T) {
+	forEachRegistryBackend(t, func(t *testing.T, newRegistry func() types.SurveyRegistry) {
+		t.Run("正常系: 保存期間を過ぎた締め切り済みのアンケートだけを削除する", func(t *testing.T) {
+			// Arrange
+			registry := newRegistry()
+			ctx := context.Background()
+			expired := time.Now().Add(-closedSurveyRetention - time.Hour)
+			registry.SaveSurvey(ctx, &types.Survey{MessageID: "expired", Closed: true, ClosedAt: expired})
+			registry.SaveSurvey(ctx, &types.Survey{MessageID: "recent", Closed: true, ClosedAt: time.Now()})
+			registry.SaveSurvey(ctx, &types.Survey{MessageID: "open", CreatedAt: expired})
+
+			// Act
+			err := registry.SaveSurvey(ctx, &types.Survey{MessageID: "new", CreatedAt: time.Now()})
+
+			// Assert
+			if err != nil {
+				t.Fatalf("期待していないエラーが発生: %v", err)
+			}
+			if _, err := registry.GetSurvey(ctx, "expired"); !errors.Is(err, types.ErrSurveyNotFound) {
+				t.Errorf("保存期間を過ぎたアンケートが残っています: %v", err)
+			}
+			for _, messageID := range []string{"recent", "open", "new"} {
+				if _, err := registry.GetSurvey(ctx, messageID); err != nil {
+					t.Errorf("%sが削除されました: %v", messageID, err)
+				}
+			}
+		})
+	})
+}
+
+func TestSurveyRegistry_UpdateSurvey(t *testing.T) {
+	forEachRegistryBackend(t, func(t *testing.T, newRegistry func() types.SurveyRegistry) {
+		t.Run("正常系: 読み取った後に記録された投票を残して更新する", func(t *testing.T) {
+			// Arrange
+			registry := newRegistry()
+			ctx := context.Background()
+			registry.SaveSurvey(ctx, &types.Survey{MessageID: "message-1", Title: "旧タイトル", Options: []string{"A", "B"}})
+			registry.RecordVote(ctx, "message-1", types.Vote{UserID: "user-1", Choices: []int{1}})
+
+			// Act
+			result, err := registry.UpdateSurvey(ctx, "message-1", func(survey *types.Survey) error {
+				survey.Title = "新タイトル"
+				return nil
+			})
+
+			// Assert
+			if err != nil {
+				t.Fatalf("期待していないエラーが発生: %v", err)
+			}
+			stored, _ := registry.GetSurvey(ctx, "message-1")
+			for _, survey := range []*types.Survey{result, stored} {
+				if survey.Title != "新タイトル" || len(survey.Votes) != 1 {
+					t.Errorf("更新後のアンケートが期待値と異なります: got %+v", survey)
+				}
+			}
+		})
+
+		t.Run("異常系: 更新がエラーを返したら保存しない", func(t *testing.T) {
+			// Arrange
+			registry := newRegistry()
+			ctx := context.Background()
+			registry.SaveSurvey(ctx, &types.Survey{MessageID: "message-1", Title: "元のタイトル", Options: []string{"A"}})
+			refused := errors.New("refused")
+
+			// Act
+			_, err := registry.UpdateSurvey(ctx, "message-1", func(survey *types.Survey) error {
+				survey.Title = "途中の変更"
+				survey.Options[0] = "途中の変更"
+				return refused
+			})
+
+			// Assert
+			if !errors.Is(err, refused) {
+				t.Errorf("更新のエラーが期待されていましたが、%vが返されました", err)
+			}
+			stored, _ := registry.GetSurvey(ctx, "message-1")
+			if stored.Title != "元のタイトル" || stored.Options[0] != "A" {
+				t.Errorf("失敗した更新が保存されています: got %+v", stored)
+			}
+		})
+
+		t.Run("異常系: メッセージIDは変更できない", func(t *testing.T) {
+			// Arrange
+			registry := newRegistry()
+			ctx := context.Background()
+			registry.SaveSurvey(ctx, &types.Survey{MessageID: "message-1"})
+
+			// Act
+			_, err := registry.UpdateSurvey(ctx, "message-1", func(survey *types.Survey) error {
+				survey.MessageID = "message-2"
+				return nil
+			})
+
+			// Assert
+			if err == nil {
+				t.Error("エラーが期待されていましたが、nilが返されました")
+			}
+		})
+
+		t.Run("異常系: 登録されていないアンケート", func(t *testing.T) {
+			// Act
+			_, err := newRegistry().UpdateSurvey(context.Background(), "missing", func(*types.Survey) error { return nil })
+
+			// Assert
+			if !errors.Is(err, types.ErrSurveyNotFound) {
+				t.Errorf("ErrSurveyNotFoundが期待されていましたが、%vが返されました", err)
+			}
+		})
+	})
+}
@@ -0,0 +1,158 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+type memoryScheduleStore struct {
+	mu        sync.RWMutex
+	schedules map[string]map[string]*types.SurveySchedule
+}
+
+// NewMemoryScheduleStore creates a new in-memory survey schedule store
+func NewMemoryScheduleStore() types.ScheduleStore {
+	return &memoryScheduleStore{
+		schedules: make(map[string]map[string]*types.SurveySchedule),
+	}
+}
+
+func (m *memoryScheduleStore) SaveSchedule(ctx context.Context, schedule *types.SurveySchedule) error {
+	if err := validateSchedule(schedule); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	putSchedule(m.schedules, schedule)
+	return nil
+}
+
+func (m *memoryScheduleStore) GetSchedule(ctx context.Context, guildID, name string) (*types.SurveySchedule, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return findSchedule(m.schedules, guildID, name)
+}
+
+func (m *memoryScheduleStore) UpdateSchedule(ctx context.Context, guildID, name string, update func(*types.SurveySchedule) error) (*types.SurveySchedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	updated, err := applyScheduleUpdate(m.schedules, guildID, name, update)
+	if err != nil {
+		return nil, err
+	}
+
+	putSchedule(m.schedules, updated)
+	return copySchedule(updated), nil
+}
+
+func (m *memoryScheduleStore) ListSchedules(ctx context.Context, guildID string) ([]*types.SurveySchedule, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return sortedSchedules(m.schedules, guildID), nil
+}
+
+func (m *memoryScheduleStore) DeleteSchedule(ctx context.Context, guildID, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := removeSchedule(m.schedules, guildID, name)
+	return err
+}
+
+func validateSchedule(schedule *types.SurveySchedule) error {
+	if schedule == nil {
+		return fmt.Errorf("schedule cannot be nil")
+	}
+	if schedule.Name == "" {
+		return fmt.Errorf("schedule name cannot be empty")
+	}
+	if schedule.GuildID == "" {
+		return fmt.Errorf("schedule guild ID cannot be empty")
+	}
+	return nil
+}
+
+// applyScheduleUpdate runs update on a copy of the named schedule, so a failed update
+// leaves it untouched
+func applyScheduleUpdate(schedules map[string]map[string]*types.SurveySchedule, guildID, name string, update func(*types.SurveySchedule) error) (*types.SurveySchedule, error) {
+	updated, err := findSchedule(schedules, guildID, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := update(updated); err != nil {
+		return nil, err
+	}
+	if updated.GuildID != guildID || nameKey(updated.Name) != nameKey(name) {
+		return nil, fmt.Errorf("schedule guild and name cannot be changed")
+	}
+	return updated, nil
+}
+
+func putSchedule(schedules map[string]map[string]*types.SurveySchedule, schedule *types.SurveySchedule) {
+	if schedules[schedule.GuildID] == nil {
+		schedules[schedule.GuildID] = make(map[string]*types.SurveySchedule)
+	}
+	schedules[schedule.GuildID][nameKey(schedule.Name)] = copySchedule(schedule)
+}
+
+func findSchedule(schedules map[string]map[string]*types.SurveySchedule, guildID, name string) (*types.SurveySchedule, error) {
+	schedule, exists := schedules[guildID][nameKey(name)]
+	if !exists {
+		return nil, types.ErrScheduleNotFound
+	}
+	return copySchedule(schedule), nil
+}
+
+// removeSchedule deletes the named schedule and returns it
+func removeSchedule(schedules map[string]map[string]*types.SurveySchedule, guildID, name string) (*types.SurveySchedule, error) {
+	key := nameKey(name)
+	schedule, exists := schedules[guildID][key]
+	if !exists {
+		return nil, types.ErrScheduleNotFound
+	}
+
+	delete(schedules[guildID], key)
+	if len(schedules[guildID]) == 0 {
+		delete(schedules, guildID)
+	}
+	return schedule, nil
+}
+
+// sortedSchedules lists the guild's schedules by name, or every guild's ordered by guild
+// and name when guildID is empty
+func sortedSchedules(schedules map[string]map[string]*types.SurveySchedule, guildID string) []*types.SurveySchedule {
+	var result []*types.SurveySchedule
+	for id, guildSchedules := range schedules {
+		if guildID != "" && id != guildID {
+			continue
+		}
+		for _, schedule := range guildSchedules {
+			result = append(result, copySchedule(schedule))
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].GuildID != result[j].GuildID {
+			return result[i].GuildID < result[j].GuildID
+		}
+		return nameKey(result[i].Name) < nameKey(result[j].Name)
+	})
+	return result
+}
+
+func copySchedule(schedule *types.SurveySchedule) *types.SurveySchedule {
+	copied := *schedule
+	copied.Weekdays = append([]time.Weekday(nil), schedule.Weekdays...)
+	copied.Options = append([]string(nil), schedule.Options...)
+	return &copied
+}
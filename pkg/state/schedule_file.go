@@ -0,0 +1,108 @@
+package state
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+type fileScheduleStore struct {
+	mu        sync.RWMutex
+	path      string
+	schedules map[string]map[string]*types.SurveySchedule
+}
+
+// NewFileScheduleStore creates a survey schedule store that persists schedules as JSON at path
+func NewFileScheduleStore(path string) (types.ScheduleStore, error) {
+	m := &fileScheduleStore{
+		path:      path,
+		schedules: make(map[string]map[string]*types.SurveySchedule),
+	}
+
+	if err := readJSONFile(path, &m.schedules); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (m *fileScheduleStore) SaveSchedule(ctx context.Context, schedule *types.SurveySchedule) error {
+	if err := validateSchedule(schedule); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous, err := findSchedule(m.schedules, schedule.GuildID, schedule.Name)
+	existed := err == nil
+	putSchedule(m.schedules, schedule)
+
+	if err := writeJSONFile(m.path, m.schedules); err != nil {
+		// Keep memory consistent with what is on disk
+		if existed {
+			putSchedule(m.schedules, previous)
+		} else {
+			_, _ = removeSchedule(m.schedules, schedule.GuildID, schedule.Name)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (m *fileScheduleStore) GetSchedule(ctx context.Context, guildID, name string) (*types.SurveySchedule, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return findSchedule(m.schedules, guildID, name)
+}
+
+func (m *fileScheduleStore) UpdateSchedule(ctx context.Context, guildID, name string, update func(*types.SurveySchedule) error) (*types.SurveySchedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous, err := findSchedule(m.schedules, guildID, name)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := applyScheduleUpdate(m.schedules, guildID, name, update)
+	if err != nil {
+		return nil, err
+	}
+	putSchedule(m.schedules, updated)
+
+	if err := writeJSONFile(m.path, m.schedules); err != nil {
+		// Keep memory consistent with what is on disk
+		putSchedule(m.schedules, previous)
+		return nil, err
+	}
+
+	return copySchedule(updated), nil
+}
+
+func (m *fileScheduleStore) ListSchedules(ctx context.Context, guildID string) ([]*types.SurveySchedule, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return sortedSchedules(m.schedules, guildID), nil
+}
+
+func (m *fileScheduleStore) DeleteSchedule(ctx context.Context, guildID, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	removed, err := removeSchedule(m.schedules, guildID, name)
+	if err != nil {
+		return err
+	}
+
+	if err := writeJSONFile(m.path, m.schedules); err != nil {
+		// Keep memory consistent with what is on disk
+		putSchedule(m.schedules, removed)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,231 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+func TestScheduleStore(t *testing.T) {
+	backends := []struct {
+		name     string
+		newStore func(t *testing.T) types.ScheduleStore
+	}{
+		{
+			name: BackendMemory,
+			newStore: func(t *testing.T) types.ScheduleStore {
+				return NewMemoryScheduleStore()
+			},
+		},
+		{
+			name: BackendFile,
+			newStore: func(t *testing.T) types.ScheduleStore {
+				store, err := NewFileScheduleStore(filepath.Join(t.TempDir(), "schedules.json"))
+				if err != nil {
+					t.Fatalf("ファイルバックエンドの作成に失敗: %v", err)
+				}
+				return store
+			},
+		},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			t.Run("正常系: スケジュールを保存して大文字小文字を区別せず取得", func(t *testing.T) {
+				// Arrange
+				store := backend.newStore(t)
+				ctx := context.Background()
+				schedule := &types.SurveySchedule{
+					Name:       "Standup",
+					GuildID:    "guild-1",
+					ChannelID:  "channel-1",
+					Recurrence: types.Recurrence{Weekdays: []time.Weekday{time.Monday}, Hour: 10, Location: "Asia/Tokyo"},
+					Title:      "今週の出席",
+					Options:    []string{"出席", "欠席"},
+				}
+
+				// Act
+				err := store.SaveSchedule(ctx, schedule)
+
+				// Assert
+				if err != nil {
+					t.Fatalf("期待していないエラーが発生: %v", err)
+				}
+				result, err := store.GetSchedule(ctx, "guild-1", "standup")
+				if err != nil {
+					t.Fatalf("期待していないエラーが発生: %v", err)
+				}
+				if !reflect.DeepEqual(result, schedule) {
+					t.Errorf("スケジュールが期待値と異なります: got %+v, want %+v", result, schedule)
+				}
+			})
+
+			t.Run("正常系: ギルドを指定しないと全ギルドの一覧を取得", func(t *testing.T) {
+				// Arrange
+				store := backend.newStore(t)
+				ctx := context.Background()
+				store.SaveSchedule(ctx, &types.SurveySchedule{Name: "retro", GuildID: "guild-1"})
+				store.SaveSchedule(ctx, &types.SurveySchedule{Name: "standup", GuildID: "guild-1"})
+				store.SaveSchedule(ctx, &types.SurveySchedule{Name: "lunch", GuildID: "guild-2"})
+
+				// Act
+				guildSchedules, _ := store.ListSchedules(ctx, "guild-1")
+				allSchedules, err := store.ListSchedules(ctx, "")
+
+				// Assert
+				if err != nil {
+					t.Fatalf("期待していないエラーが発生: %v", err)
+				}
+				if len(guildSchedules) != 2 || guildSchedules[0].Name != "retro" || guildSchedules[1].Name != "standup" {
+					t.Errorf("ギルドの一覧が期待値と異なります: got %+v", guildSchedules)
+				}
+				if len(allSchedules) != 3 || allSchedules[2].Name != "lunch" {
+					t.Errorf("全ギルドの一覧が期待値と異なります: got %+v", allSchedules)
+				}
+			})
+
+			t.Run("正常系: スケジュールを削除", func(t *testing.T) {
+				// Arrange
+				store := backend.newStore(t)
+				ctx := context.Background()
+				store.SaveSchedule(ctx, &types.SurveySchedule{Name: "standup", GuildID: "guild-1"})
+
+				// Act
+				err := store.DeleteSchedule(ctx, "guild-1", "STANDUP")
+
+				// Assert
+				if err != nil {
+					t.Fatalf("期待していないエラーが発生: %v", err)
+				}
+				if _, err := store.GetSchedule(ctx, "guild-1", "standup"); !errors.Is(err, types.ErrScheduleNotFound) {
+					t.Errorf("ErrScheduleNotFoundが期待されていましたが、%vが返されました", err)
+				}
+			})
+
+			t.Run("正常系: 保存されているスケジュールを更新", func(t *testing.T) {
+				// Arrange
+				store := backend.newStore(t)
+				ctx := context.Background()
+				store.SaveSchedule(ctx, &types.SurveySchedule{Name: "standup", GuildID: "guild-1", Paused: true})
+				lastRun := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+				// Act
+				result, err := store.UpdateSchedule(ctx, "guild-1", "STANDUP", func(schedule *types.SurveySchedule) error {
+					schedule.LastRun = lastRun
+					return nil
+				})
+
+				// Assert
+				if err != nil {
+					t.Fatalf("期待していないエラーが発生: %v", err)
+				}
+				stored, _ := store.GetSchedule(ctx, "guild-1", "standup")
+				if !result.LastRun.Equal(lastRun) || !stored.LastRun.Equal(lastRun) || !stored.Paused {
+					t.Errorf("スケジュールが期待値と異なります: got %+v", stored)
+				}
+			})
+
+			t.Run("異常系: 更新がエラーを返したら保存しない", func(t *testing.T) {
+				// Arrange
+				store := backend.newStore(t)
+				ctx := context.Background()
+				store.SaveSchedule(ctx, &types.SurveySchedule{Name: "standup", GuildID: "guild-1"})
+				updateErr := errors.New("paused")
+
+				// Act
+				_, err := store.UpdateSchedule(ctx, "guild-1", "standup", func(schedule *types.SurveySchedule) error {
+					schedule.Title = "変更"
+					return updateErr
+				})
+				_, missingErr := store.UpdateSchedule(ctx, "guild-1", "retro", func(*types.SurveySchedule) error { return nil })
+				_, renameErr := store.UpdateSchedule(ctx, "guild-1", "standup", func(schedule *types.SurveySchedule) error {
+					schedule.Name = "retro"
+					return nil
+				})
+
+				// Assert
+				if !errors.Is(err, updateErr) {
+					t.Errorf("更新のエラーが期待されていましたが、%vが返されました", err)
+				}
+				if !errors.Is(missingErr, types.ErrScheduleNotFound) {
+					t.Errorf("ErrScheduleNotFoundが期待されていましたが、%vが返されました", missingErr)
+				}
+				if renameErr == nil {
+					t.Error("名前の変更はエラーが期待されていましたが、nilが返されました")
+				}
+				stored, _ := store.GetSchedule(ctx, "guild-1", "standup")
+				if stored.Title != "" {
+					t.Errorf("失敗した更新が保存されています: got %+v", stored)
+				}
+			})
+
+			t.Run("異常系: 存在しないスケジュール", func(t *testing.T) {
+				// Arrange
+				store := backend.newStore(t)
+				ctx := context.Background()
+				store.SaveSchedule(ctx, &types.SurveySchedule{Name: "standup", GuildID: "guild-1"})
+
+				// Act
+				_, getErr := store.GetSchedule(ctx, "guild-2", "standup")
+				deleteErr := store.DeleteSchedule(ctx, "guild-1", "retro")
+
+				// Assert
+				if !errors.Is(getErr, types.ErrScheduleNotFound) || !errors.Is(deleteErr, types.ErrScheduleNotFound) {
+					t.Errorf("ErrScheduleNotFoundが期待されていましたが、%v, %vが返されました", getErr, deleteErr)
+				}
+			})
+
+			t.Run("異常系: ギルドのないスケジュールを保存", func(t *testing.T) {
+				// Arrange
+				store := backend.newStore(t)
+
+				// Act
+				err := store.SaveSchedule(context.Background(), &types.SurveySchedule{Name: "standup"})
+
+				// Assert
+				if err == nil {
+					t.Error("エラーが期待されていましたが、nilが返されました")
+				}
+			})
+		})
+	}
+}
+
+func TestFileScheduleStore_Persistence(t *testing.T) {
+	t.Run("正常系: 再起動後もスケジュールが復元される", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		path := filepath.Join(t.TempDir(), "schedules.json")
+		store, err := NewFileScheduleStore(path)
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		store.SaveSchedule(ctx, &types.SurveySchedule{
+			Name:       "standup",
+			GuildID:    "guild-1",
+			Recurrence: types.Recurrence{Weekdays: []time.Weekday{time.Monday, time.Thursday}, Hour: 10, Location: "Asia/Tokyo"},
+			Duration:   2 * time.Hour,
+			Paused:     true,
+		})
+
+		// Act
+		reopened, err := NewFileScheduleStore(path)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		schedule, err := reopened.GetSchedule(ctx, "guild-1", "standup")
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if !reflect.DeepEqual(schedule.Weekdays, []time.Weekday{time.Monday, time.Thursday}) || schedule.Duration != 2*time.Hour || !schedule.Paused {
+			t.Errorf("復元されたスケジュールが期待値と異なります: got %+v", schedule)
+		}
+	})
+}
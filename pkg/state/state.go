@@ -0,0 +1,75 @@
+package state
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+const (
+	// BackendMemory keeps state in process memory only
+	BackendMemory = "memory"
+	// BackendFile persists state as JSON files under Config.StateDir
+	BackendFile = "file"
+)
+
+// NewStateManager creates the state manager selected by cfg.StateBackend
+func NewStateManager(cfg *types.Config) (types.StateManager, error) {
+	switch cfg.StateBackend {
+	case BackendMemory, "":
+		return NewMemoryStateManager(), nil
+	case BackendFile:
+		return NewFileStateManager(filepath.Join(cfg.StateDir, "states.json"))
+	default:
+		return nil, fmt.Errorf("unknown state backend: %s", cfg.StateBackend)
+	}
+}
+
+// NewSurveyRegistry creates the survey registry selected by cfg.StateBackend
+func NewSurveyRegistry(cfg *types.Config) (types.SurveyRegistry, error) {
+	switch cfg.StateBackend {
+	case BackendMemory, "":
+		return NewMemorySurveyRegistry(), nil
+	case BackendFile:
+		return NewFileSurveyRegistry(filepath.Join(cfg.StateDir, "surveys"))
+	default:
+		return nil, fmt.Errorf("unknown state backend: %s", cfg.StateBackend)
+	}
+}
+
+// NewTemplateStore creates the survey template store selected by cfg.StateBackend
+func NewTemplateStore(cfg *types.Config) (types.TemplateStore, error) {
+	switch cfg.StateBackend {
+	case BackendMemory, "":
+		return NewMemoryTemplateStore(), nil
+	case BackendFile:
+		return NewFileTemplateStore(filepath.Join(cfg.StateDir, "templates.json"))
+	default:
+		return nil, fmt.Errorf("unknown state backend: %s", cfg.StateBackend)
+	}
+}
+
+// NewScheduleStore creates the survey schedule store selected by cfg.StateBackend
+func NewScheduleStore(cfg *types.Config) (types.ScheduleStore, error) {
+	switch cfg.StateBackend {
+	case BackendMemory, "":
+		return NewMemoryScheduleStore(), nil
+	case BackendFile:
+		return NewFileScheduleStore(filepath.Join(cfg.StateDir, "schedules.json"))
+	default:
+		return nil, fmt.Errorf("unknown state backend: %s", cfg.StateBackend)
+	}
+}
+
+// NewGuildSettingsStore creates the guild settings store selected by cfg.StateBackend
+func NewGuildSettingsStore(cfg *types.Config) (types.GuildSettingsStore, error) {
+	switch cfg.StateBackend {
+	case BackendMemory, "":
+		return NewMemoryGuildSettingsStore(), nil
+	case BackendFile:
+		return NewFileGuildSettingsStore(filepath.Join(cfg.StateDir, "guilds.json"))
+	default:
+		return nil, fmt.Errorf("unknown state backend: %s", cfg.StateBackend)
+	}
+}
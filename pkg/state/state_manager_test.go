@@ -0,0 +1,467 @@
+package state
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+func TestStateManager_GetState(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newManager func() types.StateManager) {
+		t.Run("正常系: 存在するギルドの状態を取得", func(t *testing.T) {
+			// Arrange
+			manager := newManager()
+			ctx := context.Background()
+			guildID := "test-guild-123"
+			expectedState := &types.SurveyState{
+				Active: true,
+				Title:  "テストアンケート",
+			}
+
+			// 事前に状態を設定
+			err := manager.SetState(ctx, guildID, expectedState)
+			if err != nil {
+				t.Fatalf("事前設定でエラーが発生: %v", err)
+			}
+
+			// Act
+			result, err := manager.GetState(ctx, guildID)
+
+			// Assert
+			if err != nil {
+				t.Errorf("期待していないエラーが発生: %v", err)
+			}
+			if result.Active != expectedState.Active {
+				t.Errorf("Active状態が期待値と異なります: got %v, want %v", result.Active, expectedState.Active)
+			}
+			if result.Title != expectedState.Title {
+				t.Errorf("Titleが期待値と異なります: got %v, want %v", result.Title, expectedState.Title)
+			}
+		})
+
+		t.Run("正常系: 存在しないギルドの状態を取得", func(t *testing.T) {
+			// Arrange
+			manager := newManager()
+			ctx := context.Background()
+			guildID := "non-existent-guild"
+
+			// Act
+			result, err := manager.GetState(ctx, guildID)
+
+			// Assert
+			if err != nil {
+				t.Errorf("期待していないエラーが発生: %v", err)
+			}
+			if result.Active != false {
+				t.Errorf("初期状態のActiveが期待値と異なります: got %v, want %v", result.Active, false)
+			}
+			if result.Title != "" {
+				t.Errorf("初期状態のTitleが期待値と異なります: got %v, want %v", result.Title, "")
+			}
+		})
+
+		t.Run("正常系: 状態のコピーが返されることを確認", func(t *testing.T) {
+			// Arrange
+			manager := newManager()
+			ctx := context.Background()
+			guildID := "test-guild-copy"
+			originalState := &types.SurveyState{
+				Active: true,
+				Title:  "オリジナル",
+			}
+
+			err := manager.SetState(ctx, guildID, originalState)
+			if err != nil {
+				t.Fatalf("事前設定でエラーが発生: %v", err)
+			}
+
+			// Act
+			result1, _ := manager.GetState(ctx, guildID)
+			result2, _ := manager.GetState(ctx, guildID)
+
+			// 返された状態を変更
+			result1.Title = "変更後"
+
+			// Assert
+			if result2.Title != "オリジナル" {
+				t.Error("状態のコピーが正しく動作していません。元の状態が変更されています")
+			}
+		})
+	})
+}
+
+func TestStateManager_SetState(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newManager func() types.StateManager) {
+		t.Run("正常系: 新しい状態を設定", func(t *testing.T) {
+			// Arrange
+			manager := newManager()
+			ctx := context.Background()
+			guildID := "test-guild-set"
+			newState := &types.SurveyState{
+				Active: true,
+				Title:  "新しいアンケート",
+			}
+
+			// Act
+			err := manager.SetState(ctx, guildID, newState)
+
+			// Assert
+			if err != nil {
+				t.Errorf("期待していないエラーが発生: %v", err)
+			}
+
+			// 設定された状態を確認
+			result, _ := manager.GetState(ctx, guildID)
+			if result.Active != newState.Active {
+				t.Errorf("設定されたActive状態が期待値と異なります: got %v, want %v", result.Active, newState.Active)
+			}
+			if result.Title != newState.Title {
+				t.Errorf("設定されたTitleが期待値と異なります: got %v, want %v", result.Title, newState.Title)
+			}
+		})
+
+		t.Run("正常系: 既存の状態を更新", func(t *testing.T) {
+			// Arrange
+			manager := newManager()
+			ctx := context.Background()
+			guildID := "test-guild-update"
+
+			initialState := &types.SurveyState{
+				Active: false,
+				Title:  "初期タイトル",
+			}
+			updatedState := &types.SurveyState{
+				Active: true,
+				Title:  "更新後タイトル",
+			}
+
+			manager.SetState(ctx, guildID, initialState)
+
+			// Act
+			err := manager.SetState(ctx, guildID, updatedState)
+
+			// Assert
+			if err != nil {
+				t.Errorf("期待していないエラーが発生: %v", err)
+			}
+
+			result, _ := manager.GetState(ctx, guildID)
+			if result.Active != updatedState.Active {
+				t.Errorf("更新されたActive状態が期待値と異なります: got %v, want %v", result.Active, updatedState.Active)
+			}
+			if result.Title != updatedState.Title {
+				t.Errorf("更新されたTitleが期待値と異なります: got %v, want %v", result.Title, updatedState.Title)
+			}
+		})
+
+		t.Run("異常系: nilの状態を設定", func(t *testing.T) {
+			// Arrange
+			manager := newManager()
+			ctx := context.Background()
+			guildID := "test-guild-nil"
+
+			// Act
+			err := manager.SetState(ctx, guildID, nil)
+
+			// Assert
+			if err == nil {
+				t.Error("エラーが期待されていましたが、nilが返されました")
+			}
+		})
+
+		t.Run("正常系: 状態のコピーが保存されることを確認", func(t *testing.T) {
+			// Arrange
+			manager := newManager()
+			ctx := context.Background()
+			guildID := "test-guild-copy-set"
+			originalState := &types.SurveyState{
+				Active: true,
+				Title:  "元のタイトル",
+			}
+
+			// Act
+			err := manager.SetState(ctx, guildID, originalState)
+			if err != nil {
+				t.Fatalf("SetStateでエラーが発生: %v", err)
+			}
+
+			// 元の状態を変更
+			originalState.Title = "変更後のタイトル"
+
+			// Assert
+			result, _ := manager.GetState(ctx, guildID)
+			if result.Title != "元のタイトル" {
+				t.Error("状態のコピーが正しく保存されていません。元のオブジェクトの変更が影響しています")
+			}
+		})
+
+		t.Run("正常系: 下書きの回答項目もコピーされることを確認", func(t *testing.T) {
+			// Arrange
+			manager := newManager()
+			ctx := context.Background()
+			key := "test-guild-copy-options"
+			originalState := &types.SurveyState{
+				Active:  true,
+				Title:   "好きな言語",
+				Options: []string{"Go", "Rust"},
+			}
+			if err := manager.SetState(ctx, key, originalState); err != nil {
+				t.Fatalf("SetStateでエラーが発生: %v", err)
+			}
+
+			// Act
+			originalState.Options[0] = "変更後"
+			result, _ := manager.GetState(ctx, key)
+			result.Options[1] = "変更後"
+
+			// Assert
+			stored, _ := manager.GetState(ctx, key)
+			if len(stored.Options) != 2 || stored.Options[0] != "Go" || stored.Options[1] != "Rust" {
+				t.Errorf("保存された回答項目が変更の影響を受けています: %v", stored.Options)
+			}
+		})
+	})
+}
+
+func TestStateManager_ClearState(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newManager func() types.StateManager) {
+		t.Run("正常系: 存在する状態をクリア", func(t *testing.T) {
+			// Arrange
+			manager := newManager()
+			ctx := context.Background()
+			guildID := "test-guild-clear"
+			state := &types.SurveyState{
+				Active: true,
+				Title:  "削除予定",
+			}
+
+			manager.SetState(ctx, guildID, state)
+
+			// Act
+			err := manager.ClearState(ctx, guildID)
+
+			// Assert
+			if err != nil {
+				t.Errorf("期待していないエラーが発生: %v", err)
+			}
+
+			// 状態がクリアされていることを確認
+			result, _ := manager.GetState(ctx, guildID)
+			if result.Active != false {
+				t.Errorf("クリア後のActive状態が期待値と異なります: got %v, want %v", result.Active, false)
+			}
+			if result.Title != "" {
+				t.Errorf("クリア後のTitleが期待値と異なります: got %v, want %v", result.Title, "")
+			}
+		})
+
+		t.Run("正常系: 存在しない状態をクリア", func(t *testing.T) {
+			// Arrange
+			manager := newManager()
+			ctx := context.Background()
+			guildID := "non-existent-guild-clear"
+
+			// Act
+			err := manager.ClearState(ctx, guildID)
+
+			// Assert
+			if err != nil {
+				t.Errorf("期待していないエラーが発生: %v", err)
+			}
+		})
+	})
+}
+
+func TestStateManager_CompareAndClearState(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newManager func() types.StateManager) {
+		tests := []struct {
+			name     string
+			expected *types.SurveyState
+			cleared  bool
+		}{
+			{"正常系: 読んだときのままならクリア", &types.SurveyState{Active: true, Title: "公開予定", Options: []string{"A", "B"}}, true},
+			{"異常系: 変更されていればクリアしない", &types.SurveyState{Active: true, Title: "公開予定", Options: []string{"A"}}, false},
+			{"異常系: 状態がなければクリアしない", &types.SurveyState{}, false},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// Arrange
+				manager := newManager()
+				ctx := context.Background()
+				key := "guild:channel:user"
+				if tt.expected.Active {
+					manager.SetState(ctx, key, &types.SurveyState{Active: true, Title: "公開予定", Options: []string{"A", "B"}})
+				}
+
+				// Act
+				cleared, err := manager.CompareAndClearState(ctx, key, tt.expected)
+				again, _ := manager.CompareAndClearState(ctx, key, tt.expected)
+
+				// Assert
+				if err != nil {
+					t.Fatalf("期待していないエラーが発生: %v", err)
+				}
+				if cleared != tt.cleared {
+					t.Errorf("クリアの結果が期待値と異なります: got %v, want %v", cleared, tt.cleared)
+				}
+				if again {
+					t.Error("2回目もクリアされました")
+				}
+				result, _ := manager.GetState(ctx, key)
+				if want := tt.expected.Active && !tt.cleared; result.Active != want {
+					t.Errorf("クリア後のActive状態が期待値と異なります: got %v, want %v", result.Active, want)
+				}
+			})
+		}
+	})
+}
+
+func TestStateManager_Concurrency(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newManager func() types.StateManager) {
+		t.Run("正常系: 並行アクセスの安全性", func(t *testing.T) {
+			// Arrange
+			manager := newManager()
+			ctx := context.Background()
+			guildID := "test-guild-concurrent"
+
+			var wg sync.WaitGroup
+			numGoroutines := 100
+			numOperations := 10
+
+			// Act
+			for i := 0; i < numGoroutines; i++ {
+				wg.Add(1)
+				go func(id int) {
+					defer wg.Done()
+
+					for j := 0; j < numOperations; j++ {
+						// 並行して設定・取得・クリアを実行
+						state := &types.SurveyState{
+							Active: true,
+							Title:  "concurrent test",
+						}
+
+						manager.SetState(ctx, guildID, state)
+						manager.GetState(ctx, guildID)
+						manager.ClearState(ctx, guildID)
+					}
+				}(i)
+			}
+
+			// Assert
+			// デッドロックやpanicが発生しないことを確認
+			done := make(chan struct{})
+			go func() {
+				wg.Wait()
+				close(done)
+			}()
+
+			select {
+			case <-done:
+				// 正常終了
+			case <-ctx.Done():
+				t.Error("並行アクセステストがタイムアウトしました")
+			}
+		})
+
+		t.Run("正常系: 複数ギルドの並行操作", func(t *testing.T) {
+			// Arrange
+			manager := newManager()
+			ctx := context.Background()
+
+			var wg sync.WaitGroup
+			numGuilds := 50
+
+			// Act
+			for i := 0; i < numGuilds; i++ {
+				wg.Add(1)
+				go func(guildNum int) {
+					defer wg.Done()
+
+					guildID := string(rune('A' + guildNum))
+					state := &types.SurveyState{
+						Active: true,
+						Title:  guildID + "のアンケート",
+					}
+
+					manager.SetState(ctx, guildID, state)
+					result, _ := manager.GetState(ctx, guildID)
+
+					if result.Title != state.Title {
+						t.Errorf("ギルド %s の状態が期待値と異なります", guildID)
+					}
+				}(i)
+			}
+
+			wg.Wait()
+
+			// Assert
+			// 各ギルドの状態が正しく保存されていることを確認
+			for i := 0; i < numGuilds; i++ {
+				guildID := string(rune('A' + i))
+				result, _ := manager.GetState(ctx, guildID)
+				expectedTitle := guildID + "のアンケート"
+
+				if result.Title != expectedTitle {
+					t.Errorf("ギルド %s の最終状態が期待値と異なります: got %v, want %v",
+						guildID, result.Title, expectedTitle)
+				}
+			}
+		})
+	})
+}
+
+func TestStateManager_ListActiveStates(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newManager func() types.StateManager) {
+		t.Run("正常系: ギルド内のアクティブな下書きを一覧", func(t *testing.T) {
+			// Arrange
+			manager := newManager()
+			ctx := context.Background()
+			drafts := []*types.SurveyState{
+				{Active: true, Title: "B", GuildID: "guild-1", ChannelID: "channel-2", AuthorID: "user-1"},
+				{Active: true, Title: "A", GuildID: "guild-1", ChannelID: "channel-1", AuthorID: "user-2"},
+				{Active: false, Title: "終了", GuildID: "guild-1", ChannelID: "channel-1", AuthorID: "user-3"},
+				{Active: true, Title: "別ギルド", GuildID: "guild-2", ChannelID: "channel-3", AuthorID: "user-1"},
+			}
+			for _, draft := range drafts {
+				key := types.DraftKey{GuildID: draft.GuildID, ChannelID: draft.ChannelID, AuthorID: draft.AuthorID}
+				if err := manager.SetState(ctx, key.String(), draft); err != nil {
+					t.Fatalf("事前設定でエラーが発生: %v", err)
+				}
+			}
+
+			// Act
+			result, err := manager.ListActiveStates(ctx, "guild-1")
+
+			// Assert
+			if err != nil {
+				t.Fatalf("期待していないエラーが発生: %v", err)
+			}
+			if len(result) != 2 {
+				t.Fatalf("下書きの数が期待値と異なります: got %v, want %v", len(result), 2)
+			}
+			if result[0].Title != "A" || result[1].Title != "B" {
+				t.Errorf("下書きの並び順が期待値と異なります: got %v, %v", result[0].Title, result[1].Title)
+			}
+		})
+
+		t.Run("正常系: 下書きがない場合は空", func(t *testing.T) {
+			// Arrange
+			manager := newManager()
+
+			// Act
+			result, err := manager.ListActiveStates(context.Background(), "empty-guild")
+
+			// Assert
+			if err != nil {
+				t.Errorf("期待していないエラーが発生: %v", err)
+			}
+			if len(result) != 0 {
+				t.Errorf("空の一覧が期待されていましたが、%v件返されました", len(result))
+			}
+		})
+	})
+}
@@ -0,0 +1,122 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+type memoryTemplateStore struct {
+	mu        sync.RWMutex
+	templates map[string]map[string]*types.SurveyTemplate
+}
+
+// NewMemoryTemplateStore creates a new in-memory survey template store
+func NewMemoryTemplateStore() types.TemplateStore {
+	return &memoryTemplateStore{
+		templates: make(map[string]map[string]*types.SurveyTemplate),
+	}
+}
+
+func (m *memoryTemplateStore) SaveTemplate(ctx context.Context, guildID string, template *types.SurveyTemplate) error {
+	if err := validateTemplate(template); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	putTemplate(m.templates, guildID, template)
+	return nil
+}
+
+func (m *memoryTemplateStore) GetTemplate(ctx context.Context, guildID, name string) (*types.SurveyTemplate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return findTemplate(m.templates, guildID, name)
+}
+
+func (m *memoryTemplateStore) ListTemplates(ctx context.Context, guildID string) ([]*types.SurveyTemplate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return sortedTemplates(m.templates[guildID]), nil
+}
+
+func (m *memoryTemplateStore) DeleteTemplate(ctx context.Context, guildID, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := removeTemplate(m.templates, guildID, name)
+	return err
+}
+
+func validateTemplate(template *types.SurveyTemplate) error {
+	if template == nil {
+		return fmt.Errorf("template cannot be nil")
+	}
+	if template.Name == "" {
+		return fmt.Errorf("template name cannot be empty")
+	}
+	return nil
+}
+
+// nameKey makes template and schedule names case-insensitive
+func nameKey(name string) string {
+	return strings.ToLower(name)
+}
+
+func putTemplate(templates map[string]map[string]*types.SurveyTemplate, guildID string, template *types.SurveyTemplate) {
+	if templates[guildID] == nil {
+		templates[guildID] = make(map[string]*types.SurveyTemplate)
+	}
+	templates[guildID][nameKey(template.Name)] = copyTemplate(template)
+}
+
+func findTemplate(templates map[string]map[string]*types.SurveyTemplate, guildID, name string) (*types.SurveyTemplate, error) {
+	template, exists := templates[guildID][nameKey(name)]
+	if !exists {
+		return nil, types.ErrTemplateNotFound
+	}
+	return copyTemplate(template), nil
+}
+
+// removeTemplate deletes the named template and returns it
+func removeTemplate(templates map[string]map[string]*types.SurveyTemplate, guildID, name string) (*types.SurveyTemplate, error) {
+	key := nameKey(name)
+	template, exists := templates[guildID][key]
+	if !exists {
+		return nil, types.ErrTemplateNotFound
+	}
+
+	delete(templates[guildID], key)
+	if len(templates[guildID]) == 0 {
+		delete(templates, guildID)
+	}
+	return template, nil
+}
+
+func sortedTemplates(guildTemplates map[string]*types.SurveyTemplate) []*types.SurveyTemplate {
+	keys := make([]string, 0, len(guildTemplates))
+	for key := range guildTemplates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	templates := make([]*types.SurveyTemplate, len(keys))
+	for i, key := range keys {
+		templates[i] = copyTemplate(guildTemplates[key])
+	}
+	return templates
+}
+
+func copyTemplate(template *types.SurveyTemplate) *types.SurveyTemplate {
+	copied := *template
+	copied.Options = append([]string(nil), template.Options...)
+	return &copied
+}
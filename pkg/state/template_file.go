@@ -0,0 +1,85 @@
+package state
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+type fileTemplateStore struct {
+	mu        sync.RWMutex
+	path      string
+	templates map[string]map[string]*types.SurveyTemplate
+}
+
+// NewFileTemplateStore creates a survey template store that persists templates as JSON at path
+func NewFileTemplateStore(path string) (types.TemplateStore, error) {
+	m := &fileTemplateStore{
+		path:      path,
+		templates: make(map[string]map[string]*types.SurveyTemplate),
+	}
+
+	if err := readJSONFile(path, &m.templates); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (m *fileTemplateStore) SaveTemplate(ctx context.Context, guildID string, template *types.SurveyTemplate) error {
+	if err := validateTemplate(template); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous, err := findTemplate(m.templates, guildID, template.Name)
+	existed := err == nil
+	putTemplate(m.templates, guildID, template)
+
+	if err := writeJSONFile(m.path, m.templates); err != nil {
+		// Keep memory consistent with what is on disk
+		if existed {
+			putTemplate(m.templates, guildID, previous)
+		} else {
+			_, _ = removeTemplate(m.templates, guildID, template.Name)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (m *fileTemplateStore) GetTemplate(ctx context.Context, guildID, name string) (*types.SurveyTemplate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return findTemplate(m.templates, guildID, name)
+}
+
+func (m *fileTemplateStore) ListTemplates(ctx context.Context, guildID string) ([]*types.SurveyTemplate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return sortedTemplates(m.templates[guildID]), nil
+}
+
+func (m *fileTemplateStore) DeleteTemplate(ctx context.Context, guildID, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	removed, err := removeTemplate(m.templates, guildID, name)
+	if err != nil {
+		return err
+	}
+
+	if err := writeJSONFile(m.path, m.templates); err != nil {
+		// Keep memory consistent with what is on disk
+		putTemplate(m.templates, guildID, removed)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+func TestTemplateStore(t *testing.T) {
+	backends := []struct {
+		name     string
+		newStore func(t *testing.T) types.TemplateStore
+	}{
+		{
+			name: BackendMemory,
+			newStore: func(t *testing.T) types.TemplateStore {
+				return NewMemoryTemplateStore()
+			},
+		},
+		{
+			name: BackendFile,
+			newStore: func(t *testing.T) types.TemplateStore {
+				store, err := NewFileTemplateStore(filepath.Join(t.TempDir(), "templates.json"))
+				if err != nil {
+					t.Fatalf("ファイルバックエンドの作成に失敗: %v", err)
+				}
+				return store
+			},
+		},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			t.Run("正常系: テンプレートを保存して大文字小文字を区別せず取得", func(t *testing.T) {
+				// Arrange
+				store := backend.newStore(t)
+				ctx := context.Background()
+				template := &types.SurveyTemplate{Name: "Lunch", Title: "お昼どうする？", Options: []string{"カレー", "ラーメン"}}
+
+				// Act
+				err := store.SaveTemplate(ctx, "guild-1", template)
+
+				// Assert
+				if err != nil {
+					t.Fatalf("期待していないエラーが発生: %v", err)
+				}
+				result, err := store.GetTemplate(ctx, "guild-1", "lunch")
+				if err != nil {
+					t.Fatalf("期待していないエラーが発生: %v", err)
+				}
+				if !reflect.DeepEqual(result, template) {
+					t.Errorf("テンプレートが期待値と異なります: got %+v, want %+v", result, template)
+				}
+			})
+
+			t.Run("正常系: 同じ名前で保存すると上書きされる", func(t *testing.T) {
+				// Arrange
+				store := backend.newStore(t)
+				ctx := context.Background()
+				store.SaveTemplate(ctx, "guild-1", &types.SurveyTemplate{Name: "lunch", Title: "古い"})
+
+				// Act
+				err := store.SaveTemplate(ctx, "guild-1", &types.SurveyTemplate{Name: "LUNCH", Title: "新しい"})
+
+				// Assert
+				if err != nil {
+					t.Fatalf("期待していないエラーが発生: %v", err)
+				}
+				templates, _ := store.ListTemplates(ctx, "guild-1")
+				if len(templates) != 1 || templates[0].Title != "新しい" {
+					t.Errorf("テンプレートが上書きされていません: got %+v", templates)
+				}
+			})
+
+			t.Run("正常系: ギルドごとに名前順で一覧を取得", func(t *testing.T) {
+				// Arrange
+				store := backend.newStore(t)
+				ctx := context.Background()
+				store.SaveTemplate(ctx, "guild-1", &types.SurveyTemplate{Name: "retro"})
+				store.SaveTemplate(ctx, "guild-1", &types.SurveyTemplate{Name: "lunch"})
+				store.SaveTemplate(ctx, "guild-2", &types.SurveyTemplate{Name: "other"})
+
+				// Act
+				templates, err := store.ListTemplates(ctx, "guild-1")
+
+				// Assert
+				if err != nil {
+					t.Fatalf("期待していないエラーが発生: %v", err)
+				}
+				if len(templates) != 2 || templates[0].Name != "lunch" || templates[1].Name != "retro" {
+					t.Errorf("一覧が期待値と異なります: got %+v", templates)
+				}
+			})
+
+			t.Run("正常系: テンプレートを削除", func(t *testing.T) {
+				// Arrange
+				store := backend.newStore(t)
+				ctx := context.Background()
+				store.SaveTemplate(ctx, "guild-1", &types.SurveyTemplate{Name: "lunch"})
+
+				// Act
+				err := store.DeleteTemplate(ctx, "guild-1", "Lunch")
+
+				// Assert
+				if err != nil {
+					t.Fatalf("期待していないエラーが発生: %v", err)
+				}
+				if _, err := store.GetTemplate(ctx, "guild-1", "lunch"); !errors.Is(err, types.ErrTemplateNotFound) {
+					t.Errorf("ErrTemplateNotFoundが期待されていましたが、%vが返されました", err)
+				}
+			})
+
+			t.Run("異常系: 存在しないテンプレート", func(t *testing.T) {
+				// Arrange
+				store := backend.newStore(t)
+				ctx := context.Background()
+				store.SaveTemplate(ctx, "guild-1", &types.SurveyTemplate{Name: "lunch"})
+
+				// Act
+				_, getErr := store.GetTemplate(ctx, "guild-2", "lunch")
+				deleteErr := store.DeleteTemplate(ctx, "guild-1", "retro")
+
+				// Assert
+				if !errors.Is(getErr, types.ErrTemplateNotFound) || !errors.Is(deleteErr, types.ErrTemplateNotFound) {
+					t.Errorf("ErrTemplateNotFoundが期待されていましたが、%v, %vが返されました", getErr, deleteErr)
+				}
+			})
+
+			t.Run("正常系: 取得したテンプレートを変更しても保存内容は変わらない", func(t *testing.T) {
+				// Arrange
+				store := backend.newStore(t)
+				ctx := context.Background()
+				store.SaveTemplate(ctx, "guild-1", &types.SurveyTemplate{Name: "lunch", Options: []string{"カレー"}})
+
+				// Act
+				result, _ := store.GetTemplate(ctx, "guild-1", "lunch")
+				result.Options[0] = "変更"
+
+				// Assert
+				again, _ := store.GetTemplate(ctx, "guild-1", "lunch")
+				if again.Options[0] != "カレー" {
+					t.Errorf("保存内容が変更されています: got %v", again.Options)
+				}
+			})
+
+			t.Run("異常系: 名前のないテンプレートを保存", func(t *testing.T) {
+				// Arrange
+				store := backend.newStore(t)
+
+				// Act
+				err := store.SaveTemplate(context.Background(), "guild-1", &types.SurveyTemplate{})
+
+				// Assert
+				if err == nil {
+					t.Error("エラーが期待されていましたが、nilが返されました")
+				}
+			})
+		})
+	}
+}
+
+func TestFileTemplateStore_Persistence(t *testing.T) {
+	t.Run("正常系: 再起動後もテンプレートが復元される", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		path := filepath.Join(t.TempDir(), "templates.json")
+		store, err := NewFileTemplateStore(path)
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		store.SaveTemplate(ctx, "guild-1", &types.SurveyTemplate{Name: "lunch", Title: "お昼どうする？", Options: []string{"カレー"}})
+		store.SaveTemplate(ctx, "guild-1", &types.SurveyTemplate{Name: "retro"})
+		store.DeleteTemplate(ctx, "guild-1", "retro")
+
+		// Act
+		reopened, err := NewFileTemplateStore(path)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		templates, _ := reopened.ListTemplates(ctx, "guild-1")
+		if len(templates) != 1 || templates[0].Title != "お昼どうする？" {
+			t.Errorf("復元されたテンプレートが期待値と異なります: got %+v", templates)
+		}
+	})
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/Logta/SurveyBot/handlers"
 	"github.com/Logta/SurveyBot/pkg/logger"
+	"github.com/Logta/SurveyBot/pkg/scheduler"
 	"github.com/Logta/SurveyBot/pkg/state"
 	"github.com/Logta/SurveyBot/types"
 	"github.com/Logta/SurveyBot/utils"
@@ -13,27 +14,37 @@ import (
 
 // TestHelper provides common test utilities and mocks
 type TestHelper struct {
-	StateManager  types.StateManager
-	EmojiProvider types.EmojiProvider
-	Shuffler      types.Shuffler
-	Coupler       types.Coupler
-	Logger        types.Logger
+	StateManager   types.StateManager
+	SurveyRegistry types.SurveyRegistry
+	GuildSettings  types.GuildSettingsStore
+	Templates      types.TemplateStore
+	Schedules      types.ScheduleStore
+	Scheduler      types.Scheduler
+	EmojiProvider  types.EmojiProvider
+	Shuffler       types.Shuffler
+	Coupler        types.Coupler
+	Logger         types.Logger
 }
 
 // NewTestHelper creates a new test helper with real implementations
 func NewTestHelper(t *testing.T) *TestHelper {
 	return &TestHelper{
-		StateManager:  state.NewMemoryStateManager(),
-		EmojiProvider: utils.NewEmojiProvider(),
-		Shuffler:      utils.NewShuffler(),
-		Coupler:       utils.NewCoupler(),
-		Logger:        logger.New(),
+		StateManager:   state.NewMemoryStateManager(),
+		SurveyRegistry: state.NewMemorySurveyRegistry(),
+		GuildSettings:  state.NewMemoryGuildSettingsStore(),
+		Templates:      state.NewMemoryTemplateStore(),
+		Schedules:      state.NewMemoryScheduleStore(),
+		Scheduler:      scheduler.New(),
+		EmojiProvider:  utils.NewEmojiProvider(),
+		Shuffler:       utils.NewShuffler(),
+		Coupler:        utils.NewCoupler(),
+		Logger:         logger.New(),
 	}
 }
 
 // CreateSurveyHandler creates a survey handler for testing
 func (h *TestHelper) CreateSurveyHandler() types.Handler {
-	return handlers.NewSurveyHandler(h.StateManager, h.EmojiProvider, h.Logger)
+	return handlers.NewSurveyHandler(h.StateManager, h.SurveyRegistry, h.GuildSettings, h.Templates, h.Schedules, h.Scheduler, h.EmojiProvider, h.Logger)
 }
 
 // CreateShuffleHandler creates a shuffle handler for testing
@@ -2,6 +2,9 @@ package types
 
 import (
 	"context"
+	"errors"
+	"time"
+
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -9,12 +12,209 @@ import (
 type Config struct {
 	DiscordToken string
 	GoEnv        string
+	StateBackend string
+	StateDir     string
+}
+
+// VoteMode selects how members vote on a survey
+type VoteMode string
+
+const (
+	// VoteModeReaction counts emoji reactions on the survey message
+	VoteModeReaction VoteMode = "reaction"
+	// VoteModeComponent records votes cast through buttons or a select menu
+	VoteModeComponent VoteMode = "component"
+	// VoteModeRanked records an ordered ranking of the options sent with !rank
+	VoteModeRanked VoteMode = "ranked"
+)
+
+// EmojiSet selects the emoji that mark a survey's options
+type EmojiSet string
+
+const (
+	// EmojiSetNumber uses keycap numbers 1️⃣–🔟
+	EmojiSetNumber EmojiSet = "number"
+	// EmojiSetAlphabet uses regional indicator letters 🇦–🇹
+	EmojiSetAlphabet EmojiSet = "alphabet"
+	// EmojiSetCircle uses colored circles
+	EmojiSetCircle EmojiSet = "circle"
+	// EmojiSetCustom uses the guild's custom emojis
+	EmojiSetCustom EmojiSet = "custom"
+)
+
+// SurveySettings holds the options chosen when a survey is created
+type SurveySettings struct {
+	VoteMode   VoteMode
+	MaxChoices int       // 0 allows choosing every option
+	Deadline   time.Time // zero keeps the survey open until it is closed by hand
+	Anonymous  bool      // votes are stored as salted hashes and only totals are shown
+	EmojiSet   EmojiSet  // empty falls back to the guild's default
+	// EligibleRoleID limits voting to members with the role; empty lets everyone vote
+	EligibleRoleID string
+	Quorum         int  // voters needed for the result to stand; zero sets no quorum
+	QuorumPercent  int  // percentage of the eligible members needed to vote, used instead of Quorum
+	AutoClose      bool // close as soon as the leading option can no longer be overtaken
+	// ReminderBefore sends an automatic reminder this long before the deadline; zero sends none
+	ReminderBefore time.Duration
+	ReminderRoleID string // members reminded; empty reminds everyone in the guild
+	ReminderDM     bool   // remind by direct message instead of mentioning in the channel
+}
+
+// GuildSettings holds preferences that apply to every survey in a guild
+type GuildSettings struct {
+	EmojiSet EmojiSet // empty uses EmojiSetNumber
 }
 
 // SurveyState represents the state of a survey creation
 type SurveyState struct {
-	Active bool
-	Title  string
+	Active    bool
+	Title     string
+	Options   []string // set once the options are entered; the draft then awaits confirmation
+	GuildID   string
+	ChannelID string
+	AuthorID  string // empty for drafts shared by the whole channel
+	// PreviewMessageID is the preview whose buttons publish the draft; older previews are stale
+	PreviewMessageID string
+	// TargetChannelID is where the survey is posted; empty posts it where it was written
+	TargetChannelID string
+	SurveySettings
+}
+
+// DraftKey identifies a survey draft by where and by whom it is being written
+type DraftKey struct {
+	GuildID   string
+	ChannelID string
+	AuthorID  string // empty for drafts shared by the whole channel
+}
+
+// String returns the key used to store the draft in a StateManager
+func (k DraftKey) String() string {
+	guildID := k.GuildID
+	if guildID == "" {
+		guildID = "dm" // Direct messages have no guild
+	}
+	return guildID + ":" + k.ChannelID + ":" + k.AuthorID
+}
+
+// Survey represents a published survey message
+type Survey struct {
+	MessageID string
+	ChannelID string
+	GuildID   string
+	AuthorID  string
+	Title     string
+	Options   []string
+	Emojis    []string // Emojis[i] is the reaction for Options[i]
+	CreatedAt time.Time
+	Closed    bool
+	ClosedAt  time.Time // closed surveys are dropped from the registry some time after this
+	Votes     []Vote    // recorded server-side for component votes
+	VoterSalt string    // keys the voter hashes of an anonymous survey
+	Reminded  bool      // the automatic reminder has been sent
+	SurveySettings
+}
+
+// Vote represents the options a member chose on a survey
+type Vote struct {
+	UserID  string // a salted hash of the member ID on anonymous surveys
+	Choices []int  // indices into Survey.Options, most preferred first on ranked surveys
+	VotedAt time.Time
+}
+
+// ErrSurveyNotFound is returned when no survey is registered for a lookup
+var ErrSurveyNotFound = errors.New("survey not found")
+
+// ErrSurveyClosed is returned when a survey is changed or closed after it was closed
+var ErrSurveyClosed = errors.New("survey is closed")
+
+// OptionResult represents the tally of a single survey option
+type OptionResult struct {
+	Emoji      string
+	Label      string
+	Count      int
+	Percentage float64
+}
+
+// SurveyResult represents the tallied results of a survey
+type SurveyResult struct {
+	Title      string
+	Options    []OptionResult
+	TotalVotes int
+	Winners    []int
+	Turnout    *Turnout      // nil unless the eligible members were counted
+	Quorum     *QuorumResult // nil unless the survey sets a quorum
+}
+
+// QuorumResult tells whether enough members voted for a result to stand
+type QuorumResult struct {
+	Required int
+	Voters   int
+	Met      bool
+}
+
+// Turnout compares the eligible members who voted with all eligible members
+type Turnout struct {
+	RoleID  string
+	Voters  int // eligible members who voted
+	Members int // eligible members, not counting bots
+	Ignored int // voters without the role, left out of the counts
+}
+
+// MentionChunk is one message of a reminder that mentions UserIDs
+type MentionChunk struct {
+	Content string
+	UserIDs []string
+}
+
+// RankedRound is one round of an instant-runoff count
+type RankedRound struct {
+	Counts     []int // top preferences among the remaining options, indexed like the options
+	Remaining  []int // options still in the count during this round
+	Eliminated []int // options dropped after this round
+	Exhausted  int   // ballots that rank none of the remaining options
+}
+
+// RankedResult represents the tallied results of a ranked-choice survey
+type RankedResult struct {
+	Title        string
+	Options      []OptionResult // Count holds first preferences
+	TotalVotes   int
+	Rounds       []RankedRound
+	Winners      []int // instant-runoff winners; several when the last round is tied
+	BordaScores  []int
+	BordaWinners []int
+	Turnout      *Turnout      // nil unless the eligible members were counted
+	Quorum       *QuorumResult // nil unless the survey sets a quorum
+}
+
+// SurveyExport is the downloadable record of a survey's results
+type SurveyExport struct {
+	MessageID  string         `json:"message_id"`
+	Title      string         `json:"title"`
+	VoteMode   VoteMode       `json:"vote_mode"`
+	Anonymous  bool           `json:"anonymous"`
+	Closed     bool           `json:"closed"`
+	TotalVotes int            `json:"total_votes"`
+	Options    []ExportOption `json:"options"`
+	Votes      []ExportVote   `json:"votes,omitempty"` // left out of anonymous surveys
+	ExportedAt time.Time      `json:"exported_at"`
+}
+
+// ExportOption is an option's tally in a SurveyExport
+type ExportOption struct {
+	Emoji      string  `json:"emoji"`
+	Label      string  `json:"label"`
+	Count      int     `json:"count"` // first preferences on ranked surveys
+	Percentage float64 `json:"percentage"`
+}
+
+// ExportVote is one option chosen by one member in a SurveyExport
+type ExportVote struct {
+	UserID      string    `json:"user_id"`
+	DisplayName string    `json:"display_name"`
+	Option      string    `json:"option"`
+	Rank        int       `json:"rank,omitempty"` // the preference, on ranked surveys
+	VotedAt     time.Time `json:"voted_at"`
 }
 
 // Command represents a Discord command
@@ -23,11 +223,22 @@ type Command string
 const (
 	CmdHelp       Command = "!help"
 	CmdSurvey     Command = "!survey"
+	CmdPoll       Command = "!poll"
 	CmdTitle      Command = "!title"
 	CmdContent    Command = "!content"
+	CmdPublish    Command = "!publish"
 	CmdCancel     Command = "!cancel"
+	CmdClose      Command = "!close"
 	CmdCheckState Command = "!check state"
 	CmdCheckTitle Command = "!check title"
+	CmdDrafts     Command = "!drafts"
+	CmdEmoji      Command = "!emoji"
+	CmdRank       Command = "!rank"
+	CmdEdit       Command = "!edit"
+	CmdExport     Command = "!export"
+	CmdTemplate   Command = "!template"
+	CmdSchedule   Command = "!schedule"
+	CmdRemind     Command = "!remind"
 	CmdShuffle    Command = "!shuffle"
 	CmdCoupling   Command = "!coupling"
 )
@@ -39,11 +250,131 @@ type Handler interface {
 	Name() string
 }
 
-// StateManager manages survey state
+// InteractionHandler handles Discord interactions such as slash commands.
+// Handlers passed to Bot.RegisterHandler that implement it also receive interactions.
+type InteractionHandler interface {
+	ApplicationCommands() []*discordgo.ApplicationCommand
+	CanHandleInteraction(i *discordgo.InteractionCreate) bool
+	HandleInteraction(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error
+}
+
+// ReactionHandler handles reactions added to or removed from messages.
+// Handlers passed to Bot.RegisterHandler that implement it also receive reaction events.
+type ReactionHandler interface {
+	HandleReactionAdd(ctx context.Context, s *discordgo.Session, r *discordgo.MessageReactionAdd) error
+	HandleReactionRemove(ctx context.Context, s *discordgo.Session, r *discordgo.MessageReactionRemove) error
+}
+
+// StartupHandler runs once the Discord session is open, e.g. to resume scheduled work.
+// Handlers passed to Bot.RegisterHandler that implement it are started by Bot.Start.
+type StartupHandler interface {
+	OnStart(ctx context.Context, s *discordgo.Session) error
+}
+
+// StateManager manages survey state keyed by DraftKey.String()
 type StateManager interface {
-	GetState(ctx context.Context, guildID string) (*SurveyState, error)
-	SetState(ctx context.Context, guildID string, state *SurveyState) error
-	ClearState(ctx context.Context, guildID string) error
+	GetState(ctx context.Context, key string) (*SurveyState, error)
+	SetState(ctx context.Context, key string, state *SurveyState) error
+	ClearState(ctx context.Context, key string) error
+	// CompareAndClearState clears the state stored under key only while it still equals
+	// expected, and reports whether it did, so a draft is taken by one caller at most
+	CompareAndClearState(ctx context.Context, key string, expected *SurveyState) (bool, error)
+	ListActiveStates(ctx context.Context, guildID string) ([]*SurveyState, error)
+}
+
+// SurveyRegistry records published surveys
+type SurveyRegistry interface {
+	SaveSurvey(ctx context.Context, survey *Survey) error
+	GetSurvey(ctx context.Context, messageID string) (*Survey, error)
+	GetLatestSurvey(ctx context.Context, channelID string) (*Survey, error)
+	// UpdateSurvey changes the stored survey with update and saves it in one step, so votes
+	// recorded meanwhile are kept, and returns the result. Nothing is saved when update
+	// returns an error. update runs under the registry's lock and must not call it.
+	UpdateSurvey(ctx context.Context, messageID string, update func(*Survey) error) (*Survey, error)
+	// RecordVote replaces the member's vote; a vote without choices withdraws it. Votes on
+	// a closed survey are refused with ErrSurveyClosed.
+	RecordVote(ctx context.Context, messageID string, vote Vote) (*Survey, error)
+	// ListOpenSurveys returns every survey that has not been closed, oldest first
+	ListOpenSurveys(ctx context.Context) ([]*Survey, error)
+}
+
+// GuildSettingsStore stores GuildSettings keyed by guild ID
+type GuildSettingsStore interface {
+	// GetGuildSettings returns zero settings for a guild that has none stored
+	GetGuildSettings(ctx context.Context, guildID string) (*GuildSettings, error)
+	SetGuildSettings(ctx context.Context, guildID string, settings *GuildSettings) error
+}
+
+// SurveyTemplate is a survey title and options saved in a guild for reuse
+type SurveyTemplate struct {
+	Name      string
+	Title     string
+	Options   []string
+	AuthorID  string
+	UpdatedAt time.Time
+}
+
+// ErrTemplateNotFound is returned when a guild has no template with the given name
+var ErrTemplateNotFound = errors.New("template not found")
+
+// TemplateStore stores SurveyTemplates per guild. Template names are case-insensitive.
+type TemplateStore interface {
+	// SaveTemplate adds the template or replaces the one with the same name
+	SaveTemplate(ctx context.Context, guildID string, template *SurveyTemplate) error
+	GetTemplate(ctx context.Context, guildID, name string) (*SurveyTemplate, error)
+	// ListTemplates returns the guild's templates sorted by name
+	ListTemplates(ctx context.Context, guildID string) ([]*SurveyTemplate, error)
+	DeleteTemplate(ctx context.Context, guildID, name string) error
+}
+
+// Recurrence is a weekly wall-clock time at which a survey repeats
+type Recurrence struct {
+	Weekdays []time.Weekday // empty repeats every day
+	Hour     int
+	Minute   int
+	Location string // IANA time zone name such as "Asia/Tokyo"
+}
+
+// SurveySchedule posts a survey on a Recurrence, named per guild
+type SurveySchedule struct {
+	Name      string
+	GuildID   string
+	ChannelID string
+	AuthorID  string
+	Recurrence
+	Template string // when set, the guild template is read at every run instead of Title and Options
+	Title    string
+	Options  []string
+	SurveySettings
+	Duration time.Duration // closes each survey this long after it is posted; zero keeps it open
+	Paused   bool
+	LastRun  time.Time
+}
+
+// ErrScheduleNotFound is returned when a guild has no schedule with the given name
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+// ScheduleStore stores SurveySchedules per guild. Schedule names are case-insensitive.
+type ScheduleStore interface {
+	// SaveSchedule adds the schedule or replaces the one with the same guild and name
+	SaveSchedule(ctx context.Context, schedule *SurveySchedule) error
+	GetSchedule(ctx context.Context, guildID, name string) (*SurveySchedule, error)
+	// UpdateSchedule changes the stored schedule with update and saves it in one step, so
+	// pauses and removals made meanwhile are not undone, and returns the result. Nothing
+	// is saved when update returns an error. update runs under the store's lock and must
+	// not call it.
+	UpdateSchedule(ctx context.Context, guildID, name string, update func(*SurveySchedule) error) (*SurveySchedule, error)
+	// ListSchedules returns the guild's schedules sorted by name, or every guild's when guildID is empty
+	ListSchedules(ctx context.Context, guildID string) ([]*SurveySchedule, error)
+	DeleteSchedule(ctx context.Context, guildID, name string) error
+}
+
+// Scheduler runs jobs at a given time, keyed by id.
+// Scheduling an id again replaces its pending job.
+type Scheduler interface {
+	Schedule(id string, at time.Time, job func())
+	Cancel(id string)
+	Stop()
 }
 
 // EmojiProvider provides emoji utilities
@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// NewVoterSalt returns a random salt for hashing the voters of one anonymous survey
+func NewVoterSalt() (string, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate voter salt: %w", err)
+	}
+	return hex.EncodeToString(salt), nil
+}
+
+// HashVoter derives the ID an anonymous survey records for userID. The same member always
+// maps to the same hash within a survey, so votes can be replaced, while different salts
+// keep a member's votes on different surveys from being linked.
+func HashVoter(salt, userID string) string {
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(userID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestNewVoterSalt(t *testing.T) {
+	t.Run("正常系: 毎回異なるソルトを生成", func(t *testing.T) {
+		// Act
+		first, err := NewVoterSalt()
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		second, err := NewVoterSalt()
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+
+		// Assert
+		if first == "" || first == second {
+			t.Errorf("ソルトが一意ではありません: %v, %v", first, second)
+		}
+	})
+}
+
+func TestHashVoter(t *testing.T) {
+	t.Run("正常系: 同じソルトとユーザーは同じハッシュになる", func(t *testing.T) {
+		// Act
+		first := HashVoter("salt-1", "user-1")
+		second := HashVoter("salt-1", "user-1")
+
+		// Assert
+		if first != second {
+			t.Errorf("ハッシュが一致しません: %v != %v", first, second)
+		}
+	})
+
+	t.Run("正常系: ユーザーIDを含まない", func(t *testing.T) {
+		// Act
+		result := HashVoter("salt-1", "123456789")
+
+		// Assert
+		if result == "123456789" || len(result) != 64 {
+			t.Errorf("ハッシュが期待する形式ではありません: %v", result)
+		}
+	})
+
+	t.Run("正常系: ソルトやユーザーが異なればハッシュも異なる", func(t *testing.T) {
+		// Arrange
+		base := HashVoter("salt-1", "user-1")
+
+		// Act
+		otherSalt := HashVoter("salt-2", "user-1")
+		otherUser := HashVoter("salt-1", "user-2")
+
+		// Assert
+		if base == otherSalt || base == otherUser {
+			t.Errorf("ハッシュが衝突しています: %v, %v, %v", base, otherSalt, otherUser)
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// ErrUnclosedQuote is returned when a quoted item has no closing quote
+var ErrUnclosedQuote = errors.New("unclosed quote")
+
+// closingQuotes maps each opening quote to the quote that ends it. Phones often type
+// curly quotes, so they count as well.
+var closingQuotes = map[rune]rune{
+	'"': '"',
+	'“': '”',
+}
+
+// CommandText returns what follows the command word of a message, e.g. "東京 駅" for
+// "!title 東京 駅" or for "!title" followed by "東京 駅" on the next line
+func CommandText(content string) string {
+	content = strings.TrimSpace(content)
+	end := strings.IndexFunc(content, unicode.IsSpace)
+	if end < 0 {
+		return ""
+	}
+	return strings.TrimSpace(content[end:])
+}
+
+// ParseLines reads one item per line, so items keep their spaces and commas. Blank lines
+// are skipped. A line that starts with a quote instead holds quoted items separated by
+// spaces or commas, as ParseList reads them.
+func ParseLines(text string) ([]string, error) {
+	var items []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if _, quoted := closingQuotes[[]rune(line)[0]]; !quoted {
+			items = append(items, line)
+			continue
+		}
+
+		quotedItems, err := ParseList(line)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, quotedItems...)
+	}
+	return items, nil
+}
+
+// ParseList reads items separated by spaces, commas or line breaks, as typed into a slash
+// command option. Quoting an item keeps its separators, e.g. `"Go, with generics" Rust`,
+// and a doubled quote inside it stands for the quote itself.
+func ParseList(text string) ([]string, error) {
+	var items []string
+	var item strings.Builder
+	inItem := false
+	var closing rune
+	inQuote := false
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case inQuote && r == closing:
+			if i+1 < len(runes) && runes[i+1] == closing {
+				item.WriteRune(r)
+				i++
+				continue
+			}
+			inQuote = false
+		case inQuote:
+			item.WriteRune(r)
+		case r == ',' || unicode.IsSpace(r):
+			if inItem {
+				items = appendItem(items, &item)
+				inItem = false
+			}
+		default:
+			if end, ok := closingQuotes[r]; ok && !inItem {
+				closing, inQuote, inItem = end, true, true
+				continue
+			}
+			item.WriteRune(r)
+			inItem = true
+		}
+	}
+
+	if inQuote {
+		return nil, ErrUnclosedQuote
+	}
+	if inItem {
+		items = appendItem(items, &item)
+	}
+	return items, nil
+}
+
+// appendItem adds the item being read unless it is empty, as "" would be, and resets it
+func appendItem(items []string, item *strings.Builder) []string {
+	if item.Len() > 0 {
+		items = append(items, item.String())
+	}
+	item.Reset()
+	return items
+}
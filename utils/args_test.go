@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCommandText(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"正常系: 改行の後のタイトル", "!title\n好きな 言語は？", "好きな 言語は？"},
+		{"正常系: 同じ行のタイトル", "!title Go, TypeScript どっち？", "Go, TypeScript どっち？"},
+		{"正常系: 複数行はそのまま残す", "!content\r\nGo\r\nRust\r\n", "Go\r\nRust"},
+		{"異常系: コマンドだけ", "!title", ""},
+		{"異常系: コマンドの後が空白だけ", "!title \n ", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			result := CommandText(tt.content)
+
+			// Assert
+			if result != tt.expected {
+				t.Errorf("CommandText() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{"正常系: 1行に1項目", "Go\nTypeScript\nRust", []string{"Go", "TypeScript", "Rust"}},
+		{"正常系: カンマを含む項目", "Go, with generics\nRust", []string{"Go, with generics", "Rust"}},
+		{"正常系: 空白を含む項目", "東京 駅\n大阪 駅", []string{"東京 駅", "大阪 駅"}},
+		{"正常系: CRLFと空行と前後の空白", "\r\n  Go  \r\n\r\nRust\r\n", []string{"Go", "Rust"}},
+		{"正常系: 引用符で1行に複数の項目", `"東京 駅", "大阪 駅"`, []string{"東京 駅", "大阪 駅"}},
+		{"正常系: 引用符で囲んだ1項目", `"Go, with generics"`, []string{"Go, with generics"}},
+		{"正常系: 途中の引用符は文字として残す", `彼は "Go" 派`, []string{`彼は "Go" 派`}},
+		{"正常系: 全角の引用符", "“東京 駅” “大阪 駅”", []string{"東京 駅", "大阪 駅"}},
+		{"正常系: 重ねた引用符は引用符そのもの", `"a ""quoted"" word"`, []string{`a "quoted" word`}},
+		{"正常系: 削除の印", "Go\n-\nRust", []string{"Go", "-", "Rust"}},
+		{"正常系: 空", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			result, err := ParseLines(tt.text)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("期待していないエラーが発生: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ParseLines() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("異常系: 閉じていない引用符", func(t *testing.T) {
+		// Act
+		_, err := ParseLines("Go\n\"東京 駅")
+
+		// Assert
+		if !errors.Is(err, ErrUnclosedQuote) {
+			t.Errorf("ErrUnclosedQuoteが期待されていましたが、%vが返されました", err)
+		}
+	})
+}
+
+func TestParseList(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{"正常系: 空白区切り", "田中 佐藤 鈴木", []string{"田中", "佐藤", "鈴木"}},
+		{"正常系: カンマ区切りと連続した区切り", "田中,, 佐藤 ,鈴木", []string{"田中", "佐藤", "鈴木"}},
+		{"正常系: 全角の空白", "田中　佐藤", []string{"田中", "佐藤"}},
+		{"正常系: 引用符で区切りを残す", `"Go, with generics" Rust`, []string{"Go, with generics", "Rust"}},
+		{"正常系: 空の引用符は項目にしない", `"" Go`, []string{"Go"}},
+		{"正常系: 番号の並び", "3,1 2", []string{"3", "1", "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			result, err := ParseList(tt.text)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("期待していないエラーが発生: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ParseList() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("異常系: 閉じていない引用符", func(t *testing.T) {
+		// Act
+		_, err := ParseList(`Go "Rust`)
+
+		// Assert
+		if !errors.Is(err, ErrUnclosedQuote) {
+			t.Errorf("ErrUnclosedQuoteが期待されていましたが、%vが返されました", err)
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+// EmbedDescriptionLimit is the most characters Discord accepts in an embed description
+const EmbedDescriptionLimit = 4096
+
+// barEighths are the block characters for one to seven eighths of a full block
+var barEighths = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+// chartLayout is a bar width and label length to try; a label length of 0 keeps labels whole
+type chartLayout struct {
+	barWidth   int
+	labelWidth int
+}
+
+// chartLayouts are tried in order until the chart fits, shortening long labels before
+// narrowing the bars
+var chartLayouts = []chartLayout{
+	{barWidth: 20},
+	{barWidth: 20, labelWidth: 40},
+	{barWidth: 10, labelWidth: 40},
+	{barWidth: 10, labelWidth: 20},
+	{barWidth: 5, labelWidth: 10},
+}
+
+// RenderBarChart draws each option's count as a bar scaled to the most voted option,
+// inside a code block of at most limit characters. Options that still do not fit in the
+// narrowest layout are left out and counted on the last line.
+func RenderBarChart(options []types.OptionResult, limit int) string {
+	for _, layout := range chartLayouts {
+		if chart := renderBarChart(options, layout, len(options)); utf8.RuneCountInString(chart) <= limit {
+			return chart
+		}
+	}
+
+	narrowest := chartLayouts[len(chartLayouts)-1]
+	for shown := len(options) - 1; shown > 0; shown-- {
+		if chart := renderBarChart(options, narrowest, shown); utf8.RuneCountInString(chart) <= limit {
+			return chart
+		}
+	}
+	return ""
+}
+
+func renderBarChart(options []types.OptionResult, layout chartLayout, shown int) string {
+	maxCount := 0
+	for _, option := range options {
+		maxCount = max(maxCount, option.Count)
+	}
+
+	var b strings.Builder
+	b.WriteString("```\n")
+	for i, option := range options[:shown] {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, chartLabel(option.Label, layout.labelWidth))
+		fmt.Fprintf(&b, "%s %d票 (%.1f%%)\n", bar(option.Count, maxCount, layout.barWidth), option.Count, option.Percentage)
+	}
+	if hidden := len(options) - shown; hidden > 0 {
+		fmt.Fprintf(&b, "…ほか%d件\n", hidden)
+	}
+	b.WriteString("```")
+	return b.String()
+}
+
+// bar draws count out of maxCount as width blocks, padded with spaces so that the text
+// after every bar lines up
+func bar(count, maxCount, width int) string {
+	eighths := 0
+	if maxCount > 0 && count > 0 {
+		eighths = (count*width*8 + maxCount/2) / maxCount
+	}
+
+	full, partial := eighths/8, eighths%8
+	drawn := strings.Repeat("█", full) + barEighths[partial]
+	cells := full
+	if partial > 0 {
+		cells++
+	}
+	return drawn + strings.Repeat(" ", width-cells)
+}
+
+// chartLabel keeps a label from closing the code block and shortens it to width runes
+func chartLabel(label string, width int) string {
+	label = strings.ReplaceAll(label, "`", "'")
+	runes := []rune(label)
+	if width <= 0 || len(runes) <= width {
+		return label
+	}
+	return string(runes[:width-1]) + "…"
+}
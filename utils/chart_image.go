@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+
+	"github.com/Logta/SurveyBot/types"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// ChartTheme holds the colors of a rendered chart
+type ChartTheme struct {
+	Background color.Color
+	Text       color.Color
+	Bar        color.Color
+	Winner     color.Color // bars of the winning options
+}
+
+// SurveyChartTheme matches the embeds the bot sends: survey blue bars, with the winners
+// in the help embed's green
+var SurveyChartTheme = ChartTheme{
+	Background: color.White,
+	Text:       EmbedColor(0x2E3338),
+	Bar:        EmbedColor(0x141DB8),
+	Winner:     EmbedColor(0xA4B814),
+}
+
+// EmbedColor converts a Discord embed color such as 0x141DB8 to an opaque color
+func EmbedColor(rgb int) color.RGBA {
+	return color.RGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 0xFF}
+}
+
+const (
+	chartWidth     = 600
+	chartPadding   = 16
+	chartRowHeight = 32
+	chartBarHeight = 20
+	// chartLabelWidth and chartValueWidth leave room for "25." and "999 (100.0%)"
+	chartLabelWidth = 40
+	chartValueWidth = 110
+)
+
+// RenderBarChartPNG draws the options as horizontal bars scaled to the most voted option,
+// coloring the winners apart. The embedded font only covers ASCII, so bars are labeled
+// with their option numbers as in RenderBarChart.
+func RenderBarChartPNG(options []types.OptionResult, winners []int, theme ChartTheme) ([]byte, error) {
+	height := chartPadding*2 + chartRowHeight*max(len(options), 1)
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(theme.Background), image.Point{}, draw.Src)
+
+	isWinner := make(map[int]bool, len(winners))
+	for _, i := range winners {
+		isWinner[i] = true
+	}
+
+	maxCount := 0
+	for _, option := range options {
+		maxCount = max(maxCount, option.Count)
+	}
+
+	drawer := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(theme.Text),
+		Face: basicfont.Face7x13,
+	}
+	barLeft := chartPadding + chartLabelWidth
+	barMaxWidth := chartWidth - barLeft - chartValueWidth - chartPadding
+
+	for i, option := range options {
+		top := chartPadding + i*chartRowHeight
+		// basicfont's 13px glyphs sit 11px above the baseline
+		baseline := top + (chartRowHeight+11)/2
+
+		drawText(drawer, chartPadding, baseline, fmt.Sprintf("%d.", i+1))
+
+		width := 0
+		if maxCount > 0 {
+			width = (option.Count*barMaxWidth + maxCount/2) / maxCount
+		}
+		barColor := theme.Bar
+		if isWinner[i] {
+			barColor = theme.Winner
+		}
+		barTop := top + (chartRowHeight-chartBarHeight)/2
+		draw.Draw(img, image.Rect(barLeft, barTop, barLeft+width, barTop+chartBarHeight), image.NewUniform(barColor), image.Point{}, draw.Src)
+
+		drawText(drawer, barLeft+barMaxWidth+8, baseline, fmt.Sprintf("%d (%.1f%%)", option.Count, option.Percentage))
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func drawText(drawer *font.Drawer, x, y int, text string) {
+	drawer.Dot = fixed.P(x, y)
+	drawer.DrawString(text)
+}
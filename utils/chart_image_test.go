@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+func TestRenderBarChartPNG(t *testing.T) {
+	t.Run("正常系: 当選した選択肢のバーを別の色で描く", func(t *testing.T) {
+		// Arrange
+		options := TallyResults("", []types.OptionResult{
+			{Label: "Go", Count: 4},
+			{Label: "Rust", Count: 2},
+			{Label: "Python", Count: 0},
+		}).Options
+
+		// Act
+		data, err := RenderBarChartPNG(options, []int{0}, SurveyChartTheme)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("PNGとして読み込めません: %v", err)
+		}
+		if size := img.Bounds().Size(); size.X != chartWidth || size.Y != chartPadding*2+chartRowHeight*3 {
+			t.Fatalf("画像の大きさが期待値と異なります: got %v", size)
+		}
+
+		// 各行のバーの左端付近の色を確認する
+		x := chartPadding + chartLabelWidth + 1
+		rowCenter := func(row int) int { return chartPadding + row*chartRowHeight + chartRowHeight/2 }
+		testCases := []struct {
+			row      int
+			expected color.Color
+		}{
+			{0, SurveyChartTheme.Winner},
+			{1, SurveyChartTheme.Bar},
+			{2, SurveyChartTheme.Background},
+		}
+		for _, tc := range testCases {
+			if !sameColor(img.At(x, rowCenter(tc.row)), tc.expected) {
+				t.Errorf("%d行目のバーの色が期待値と異なります: got %v, want %v", tc.row+1, img.At(x, rowCenter(tc.row)), tc.expected)
+			}
+		}
+
+		// 2位のバーは最多得票の半分の長さになる
+		barMaxWidth := chartWidth - chartPadding - chartLabelWidth - chartValueWidth - chartPadding
+		half := chartPadding + chartLabelWidth + barMaxWidth/2
+		if !sameColor(img.At(half-1, rowCenter(1)), SurveyChartTheme.Bar) || !sameColor(img.At(half+1, rowCenter(1)), SurveyChartTheme.Background) {
+			t.Errorf("2位のバーの長さが期待値と異なります")
+		}
+	})
+
+	t.Run("正常系: 回答項目がなくても画像を生成する", func(t *testing.T) {
+		// Act
+		data, err := RenderBarChartPNG(nil, nil, SurveyChartTheme)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+			t.Errorf("PNGとして読み込めません: %v", err)
+		}
+	})
+}
+
+func TestEmbedColor(t *testing.T) {
+	t.Run("正常系: 埋め込みの色をRGBに変換する", func(t *testing.T) {
+		// Act
+		c := EmbedColor(0x141DB8)
+
+		// Assert
+		expected := color.RGBA{R: 0x14, G: 0x1D, B: 0xB8, A: 0xFF}
+		if c != expected {
+			t.Errorf("色が期待値と異なります: got %v, want %v", c, expected)
+		}
+	})
+}
+
+func sameColor(a, b color.Color) bool {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	return ar == br && ag == bg && ab == bb && aa == ba
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// assertGolden compares got with testdata/name, rewriting the file when -update is set
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("ゴールデンファイルを書き込めません: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ゴールデンファイルを読み込めません: %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("出力がゴールデンファイル %s と異なります:\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func TestRenderBarChart(t *testing.T) {
+	testCases := []struct {
+		name    string
+		golden  string
+		options []types.OptionResult
+		limit   int
+	}{
+		{
+			name:   "正常系: 最多得票を基準に端数をブロック文字で描く",
+			golden: "chart_basic.golden",
+			options: TallyResults("", []types.OptionResult{
+				{Label: "Go", Count: 7},
+				{Label: "Rust", Count: 3},
+				{Label: "Python", Count: 1},
+				{Label: "なし", Count: 0},
+			}).Options,
+			limit: EmbedDescriptionLimit,
+		},
+		{
+			name:   "正常系: 投票がない場合は空のバーになる",
+			golden: "chart_empty.golden",
+			options: []types.OptionResult{
+				{Label: "A"},
+				{Label: "`B`"},
+			},
+			limit: EmbedDescriptionLimit,
+		},
+		{
+			name:   "正常系: 上限に収まるよう項目名とバーを縮める",
+			golden: "chart_narrow.golden",
+			options: []types.OptionResult{
+				{Label: strings.Repeat("長い回答項目", 10), Count: 2, Percentage: 66.7},
+				{Label: "短い", Count: 1, Percentage: 33.3},
+			},
+			limit: 80,
+		},
+		{
+			name:   "正常系: 収まらない項目は件数だけ表示する",
+			golden: "chart_overflow.golden",
+			options: []types.OptionResult{
+				{Label: "A", Count: 3, Percentage: 50},
+				{Label: "B", Count: 2, Percentage: 33.3},
+				{Label: "C", Count: 1, Percentage: 16.7},
+			},
+			limit: 60,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			chart := RenderBarChart(tc.options, tc.limit)
+
+			// Assert
+			if n := utf8.RuneCountInString(chart); n > tc.limit {
+				t.Errorf("文字数が上限を超えています: got %v, limit %v", n, tc.limit)
+			}
+			assertGolden(t, tc.golden, chart)
+		})
+	}
+}
+
+func TestBar(t *testing.T) {
+	t.Run("正常系: 8分の1単位で描く", func(t *testing.T) {
+		testCases := []struct {
+			count    int
+			expected string
+		}{
+			{8, "████"},
+			{4, "██  "},
+			{3, "█▌  "},
+			{1, "▌   "},
+			{0, "    "},
+		}
+
+		for _, tc := range testCases {
+			// Act
+			result := bar(tc.count, 8, 4)
+
+			// Assert
+			if result != tc.expected {
+				t.Errorf("バーが期待値と異なります: count=%v, got %q, want %q", tc.count, result, tc.expected)
+			}
+		}
+	})
+}
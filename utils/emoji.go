@@ -21,6 +21,31 @@ func NewEmojiProvider() types.EmojiProvider {
 	}
 }
 
+// NewAlphabetEmojiProvider creates an emoji provider of regional indicator letters 🇦–🇹,
+// stopping at 20 so that every letter fits within Discord's reaction limit
+func NewAlphabetEmojiProvider() types.EmojiProvider {
+	emojis := make([]string, 20)
+	for i := range emojis {
+		emojis[i] = string(rune(0x1F1E6 + i))
+	}
+	return &emojiProvider{emojis: emojis}
+}
+
+// NewCircleEmojiProvider creates an emoji provider of colored circles
+func NewCircleEmojiProvider() types.EmojiProvider {
+	return &emojiProvider{
+		emojis: []string{"🔴", "🟠", "🟡", "🟢", "🔵", "🟣", "🟤", "⚫", "⚪"},
+	}
+}
+
+// NewCustomEmojiProvider creates an emoji provider from custom emojis in Discord's message
+// format, e.g. "<:name:id>"
+func NewCustomEmojiProvider(emojis []string) types.EmojiProvider {
+	return &emojiProvider{
+		emojis: append([]string(nil), emojis...),
+	}
+}
+
 func (e *emojiProvider) GetEmoji(ctx context.Context, index int) (string, error) {
 	if index < 0 || index >= len(e.emojis) {
 		return "", fmt.Errorf("emoji index %d out of range [0-%d]", index, len(e.emojis)-1)
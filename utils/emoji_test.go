@@ -119,3 +119,67 @@ func TestFindEmoji_BackwardCompatibility(t *testing.T) {
 		}
 	})
 }
+
+func TestAlphabetEmojiProvider(t *testing.T) {
+	t.Run("正常系: AからTまでの20文字", func(t *testing.T) {
+		// Arrange
+		provider := NewAlphabetEmojiProvider()
+		ctx := context.Background()
+
+		// Act
+		first, firstErr := provider.GetEmoji(ctx, 0)
+		last, lastErr := provider.GetEmoji(ctx, provider.GetMaxEmojis()-1)
+
+		// Assert
+		if provider.GetMaxEmojis() != 20 {
+			t.Errorf("最大絵文字数が期待値と異なります: got %v, want %v", provider.GetMaxEmojis(), 20)
+		}
+		if firstErr != nil || first != "🇦" {
+			t.Errorf("最初の絵文字が期待値と異なります: got %v, want %v", first, "🇦")
+		}
+		if lastErr != nil || last != "🇹" {
+			t.Errorf("最後の絵文字が期待値と異なります: got %v, want %v", last, "🇹")
+		}
+	})
+}
+
+func TestCircleEmojiProvider(t *testing.T) {
+	t.Run("正常系: 色付きの丸を取得", func(t *testing.T) {
+		// Arrange
+		provider := NewCircleEmojiProvider()
+
+		// Act
+		result, err := provider.GetEmoji(context.Background(), 0)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if result != "🔴" {
+			t.Errorf("絵文字が期待値と異なります: got %v, want %v", result, "🔴")
+		}
+	})
+}
+
+func TestCustomEmojiProvider(t *testing.T) {
+	t.Run("正常系: 指定したカスタム絵文字を順に返す", func(t *testing.T) {
+		// Arrange
+		emojis := []string{"<:cat:111>", "<a:dog:222>"}
+		provider := NewCustomEmojiProvider(emojis)
+
+		// Act
+		emojis[0] = "変更後"
+		result, err := provider.GetEmoji(context.Background(), 0)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if result != "<:cat:111>" {
+			t.Errorf("絵文字が期待値と異なります: got %v, want %v", result, "<:cat:111>")
+		}
+		if provider.GetMaxEmojis() != 2 {
+			t.Errorf("最大絵文字数が期待値と異なります: got %v, want %v", provider.GetMaxEmojis(), 2)
+		}
+	})
+}
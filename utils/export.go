@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+// utf8BOM lets spreadsheet applications detect that the CSV is UTF-8, which keeps
+// Japanese text readable in Excel
+const utf8BOM = "\ufeff"
+
+// ExportResultsCSV writes one row per option with its count and percentage
+func ExportResultsCSV(export *types.SurveyExport) ([]byte, error) {
+	rows := [][]string{{"emoji", "option", "count", "percentage"}}
+	for _, option := range export.Options {
+		rows = append(rows, []string{
+			option.Emoji,
+			option.Label,
+			strconv.Itoa(option.Count),
+			strconv.FormatFloat(option.Percentage, 'f', 1, 64),
+		})
+	}
+	return writeCSV(rows)
+}
+
+// ExportVotesCSV writes one row per option chosen by each member
+func ExportVotesCSV(export *types.SurveyExport) ([]byte, error) {
+	header := []string{"user_id", "display_name", "option", "voted_at"}
+	ranked := export.VoteMode == types.VoteModeRanked
+	if ranked {
+		header = []string{"user_id", "display_name", "rank", "option", "voted_at"}
+	}
+
+	rows := [][]string{header}
+	for _, vote := range export.Votes {
+		votedAt := ""
+		if !vote.VotedAt.IsZero() {
+			votedAt = vote.VotedAt.Format(time.RFC3339)
+		}
+		if ranked {
+			rows = append(rows, []string{vote.UserID, vote.DisplayName, strconv.Itoa(vote.Rank), vote.Option, votedAt})
+		} else {
+			rows = append(rows, []string{vote.UserID, vote.DisplayName, vote.Option, votedAt})
+		}
+	}
+	return writeCSV(rows)
+}
+
+// ExportJSON writes the whole export as indented JSON
+func ExportJSON(export *types.SurveyExport) ([]byte, error) {
+	return json.MarshalIndent(export, "", "  ")
+}
+
+func writeCSV(rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(utf8BOM)
+
+	w := csv.NewWriter(&buf)
+	for _, row := range rows {
+		for i, cell := range row {
+			row[i] = escapeFormula(cell)
+		}
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// escapeFormula prefixes a cell that a spreadsheet would run as a formula with a quote, so
+// option labels and display names chosen by members are shown as text
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
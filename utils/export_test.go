@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+func newTestExport() *types.SurveyExport {
+	return &types.SurveyExport{
+		MessageID:  "message-1",
+		Title:      "好きな言語",
+		VoteMode:   types.VoteModeComponent,
+		TotalVotes: 2,
+		Options: []types.ExportOption{
+			{Emoji: "1️⃣", Label: "Go", Count: 2, Percentage: 100},
+			{Emoji: "2️⃣", Label: "Rust, C++", Count: 0, Percentage: 0},
+		},
+		Votes: []types.ExportVote{
+			{UserID: "user-1", DisplayName: "太郎", Option: "Go", VotedAt: time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)},
+			{UserID: "user-2", DisplayName: "花子", Option: "Go"},
+		},
+	}
+}
+
+func TestExportResultsCSV(t *testing.T) {
+	t.Run("正常系: 選択肢ごとの得票数を出力する", func(t *testing.T) {
+		// Arrange
+		export := newTestExport()
+
+		// Act
+		data, err := ExportResultsCSV(export)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		expected := utf8BOM + "emoji,option,count,percentage\n" +
+			"1️⃣,Go,2,100.0\n" +
+			"2️⃣,\"Rust, C++\",0,0.0\n"
+		if string(data) != expected {
+			t.Errorf("CSVが期待値と異なります: got %q, want %q", data, expected)
+		}
+	})
+}
+
+func TestExportVotesCSV(t *testing.T) {
+	t.Run("正常系: メンバーごとの投票を出力する", func(t *testing.T) {
+		// Arrange
+		export := newTestExport()
+
+		// Act
+		data, err := ExportVotesCSV(export)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		expected := utf8BOM + "user_id,display_name,option,voted_at\n" +
+			"user-1,太郎,Go,2030-01-02T03:04:05Z\n" +
+			"user-2,花子,Go,\n"
+		if string(data) != expected {
+			t.Errorf("CSVが期待値と異なります: got %q, want %q", data, expected)
+		}
+	})
+
+	t.Run("正常系: 順位付けのアンケートは順位の列を含む", func(t *testing.T) {
+		// Arrange
+		export := newTestExport()
+		export.VoteMode = types.VoteModeRanked
+		export.Votes = []types.ExportVote{{UserID: "user-1", DisplayName: "太郎", Option: "Go", Rank: 1}}
+
+		// Act
+		data, err := ExportVotesCSV(export)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		if !strings.Contains(string(data), "user_id,display_name,rank,option,voted_at\nuser-1,太郎,1,Go,\n") {
+			t.Errorf("CSVが期待値と異なります: got %q", data)
+		}
+	})
+}
+
+func TestExportVotesCSV_Formula(t *testing.T) {
+	tests := []struct {
+		name     string
+		cell     string
+		expected string
+	}{
+		{"異常系: =で始まる名前は数式にしない", "=HYPERLINK(\"http://example.com\")", "\"'=HYPERLINK(\"\"http://example.com\"\")\""},
+		{"異常系: +で始まる名前は数式にしない", "+cmd|' /C calc'!A0", "'+cmd|' /C calc'!A0"},
+		{"異常系: -で始まる名前は数式にしない", "-2+3", "'-2+3"},
+		{"異常系: @で始まる名前は数式にしない", "@SUM(1)", "'@SUM(1)"},
+		{"異常系: タブで始まる名前は数式にしない", "\t=1", "'\t=1"},
+		{"異常系: 改行で始まる名前は数式にしない", "\r=1", "\"'\r=1\""},
+		{"正常系: 途中の記号はそのまま", "太郎=1", "太郎=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			export := newTestExport()
+			export.Votes = []types.ExportVote{{UserID: "user-1", DisplayName: tt.cell, Option: "Go"}}
+
+			// Act
+			data, err := ExportVotesCSV(export)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("期待していないエラーが発生: %v", err)
+			}
+			if !strings.Contains(string(data), "\nuser-1,"+tt.expected+",Go,\n") {
+				t.Errorf("CSVが期待値と異なります: got %q, want cell %q", data, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExportJSON(t *testing.T) {
+	t.Run("正常系: 匿名アンケートは投票者を含まない", func(t *testing.T) {
+		// Arrange
+		export := newTestExport()
+		export.Anonymous = true
+		export.Votes = nil
+
+		// Act
+		data, err := ExportJSON(export)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("期待していないエラーが発生: %v", err)
+		}
+		var decoded map[string]any
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("JSONとして読み込めません: %v", err)
+		}
+		if _, ok := decoded["votes"]; ok {
+			t.Errorf("匿名アンケートに投票者が含まれています: %s", data)
+		}
+		if decoded["title"] != "好きな言語" || decoded["total_votes"] != float64(2) {
+			t.Errorf("JSONが期待値と異なります: %s", data)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"unicode/utf8"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+const (
+	// MessageContentLimit is the most characters Discord accepts in a message
+	MessageContentLimit = 2000
+	// MaxMentionsPerMessage keeps each reminder under the mention spam limits servers set
+	// with AutoMod, which start at 20 by default
+	MaxMentionsPerMessage = 20
+	// maxMentionLength is the longest user mention: "<@", a snowflake of up to 20 digits and ">"
+	maxMentionLength = 23
+)
+
+// TruncateRunes shortens text to at most limit runes, ending it with "…" when it is cut
+func TruncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	if limit <= 0 || len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+// ChunkMentions splits mentions of userIDs into messages of at most limit characters and
+// maxMentions mentions. The header starts the first message, shortened so that at least
+// one mention fits after it.
+func ChunkMentions(header string, userIDs []string, limit, maxMentions int) []types.MentionChunk {
+	header = TruncateRunes(header, limit-len("\n")-maxMentionLength)
+	var chunks []types.MentionChunk
+	current := types.MentionChunk{Content: header}
+	length := utf8.RuneCountInString(header)
+
+	for _, id := range userIDs {
+		mention := "<@" + id + ">"
+		separator := ""
+		if current.Content != "" {
+			separator = " "
+			if len(current.UserIDs) == 0 {
+				separator = "\n"
+			}
+		}
+
+		full := len(current.UserIDs) >= maxMentions || length+len(separator)+len(mention) > limit
+		if full && len(current.UserIDs) > 0 {
+			chunks = append(chunks, current)
+			current = types.MentionChunk{}
+			length = 0
+			separator = ""
+		}
+
+		current.Content += separator + mention
+		current.UserIDs = append(current.UserIDs, id)
+		length += len(separator) + len(mention)
+	}
+
+	if len(current.UserIDs) > 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestChunkMentions(t *testing.T) {
+	t.Run("正常系: 見出しの次の行にメンションを並べる", func(t *testing.T) {
+		// Act
+		chunks := ChunkMentions("未回答のメンバー", []string{"1", "2"}, MessageContentLimit, MaxMentionsPerMessage)
+
+		// Assert
+		if len(chunks) != 1 {
+			t.Fatalf("メッセージ数が期待値と異なります: got %v, want %v", len(chunks), 1)
+		}
+		if chunks[0].Content != "未回答のメンバー\n<@1> <@2>" {
+			t.Errorf("本文が期待値と異なります: got %q", chunks[0].Content)
+		}
+		if !reflect.DeepEqual(chunks[0].UserIDs, []string{"1", "2"}) {
+			t.Errorf("メンションするユーザーが期待値と異なります: got %v", chunks[0].UserIDs)
+		}
+	})
+
+	t.Run("正常系: メンション数の上限で分割", func(t *testing.T) {
+		// Act
+		chunks := ChunkMentions("見出し", []string{"1", "2", "3", "4", "5"}, MessageContentLimit, 2)
+
+		// Assert
+		if len(chunks) != 3 {
+			t.Fatalf("メッセージ数が期待値と異なります: got %v, want %v", len(chunks), 3)
+		}
+		if chunks[1].Content != "<@3> <@4>" || chunks[2].Content != "<@5>" {
+			t.Errorf("本文が期待値と異なります: got %q, %q", chunks[1].Content, chunks[2].Content)
+		}
+	})
+
+	t.Run("正常系: 文字数の上限で分割", func(t *testing.T) {
+		// Arrange
+		ids := make([]string, 200)
+		for i := range ids {
+			ids[i] = strings.Repeat("9", 18)
+		}
+
+		// Act
+		chunks := ChunkMentions("見出し", ids, 100, 1000)
+
+		// Assert
+		total := 0
+		for _, chunk := range chunks {
+			if utf8.RuneCountInString(chunk.Content) > 100 {
+				t.Errorf("文字数が上限を超えています: got %v", utf8.RuneCountInString(chunk.Content))
+			}
+			total += len(chunk.UserIDs)
+		}
+		if total != len(ids) {
+			t.Errorf("メンションの総数が期待値と異なります: got %v, want %v", total, len(ids))
+		}
+	})
+
+	t.Run("正常系: 長すぎる見出しは1人目のメンションが収まるように切り詰める", func(t *testing.T) {
+		// Arrange
+		header := strings.Repeat("長", MessageContentLimit)
+		id := strings.Repeat("9", 20)
+
+		// Act
+		chunks := ChunkMentions(header, []string{id}, MessageContentLimit, MaxMentionsPerMessage)
+
+		// Assert
+		if len(chunks) != 1 {
+			t.Fatalf("メッセージ数が期待値と異なります: got %v, want %v", len(chunks), 1)
+		}
+		if utf8.RuneCountInString(chunks[0].Content) > MessageContentLimit {
+			t.Errorf("文字数が上限を超えています: got %v", utf8.RuneCountInString(chunks[0].Content))
+		}
+		if !strings.HasSuffix(chunks[0].Content, "…\n<@"+id+">") {
+			t.Errorf("切り詰めた見出しの後にメンションがありません: got %q", chunks[0].Content[len(chunks[0].Content)-40:])
+		}
+	})
+
+	t.Run("正常系: 対象がいなければ空", func(t *testing.T) {
+		// Act
+		chunks := ChunkMentions("見出し", nil, MessageContentLimit, MaxMentionsPerMessage)
+
+		// Assert
+		if len(chunks) != 0 {
+			t.Errorf("メッセージ数が期待値と異なります: got %v, want %v", len(chunks), 0)
+		}
+	})
+}
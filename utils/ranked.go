@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"github.com/Logta/SurveyBot/types"
+)
+
+// TallyRanked runs instant-runoff and Borda counts over the rankings in votes
+func TallyRanked(title string, options []types.OptionResult, votes []types.Vote) *types.RankedResult {
+	ballots := RankedBallots(len(options), votes)
+
+	result := &types.RankedResult{
+		Title:      title,
+		Options:    make([]types.OptionResult, len(options)),
+		TotalVotes: len(ballots),
+	}
+	copy(result.Options, options)
+
+	result.Rounds, result.Winners = InstantRunoff(len(options), ballots)
+	if len(result.Rounds) > 0 {
+		for i, count := range result.Rounds[0].Counts {
+			result.Options[i].Count = count
+			if result.TotalVotes > 0 {
+				result.Options[i].Percentage = float64(count) / float64(result.TotalVotes) * 100
+			}
+		}
+	}
+
+	result.BordaScores = BordaCount(len(options), ballots)
+	result.BordaWinners = topIndices(result.BordaScores)
+
+	return result
+}
+
+// RankedBallots returns each vote's ranking without out-of-range or repeated options,
+// dropping votes that rank nothing
+func RankedBallots(optionCount int, votes []types.Vote) [][]int {
+	var ballots [][]int
+	for _, vote := range votes {
+		seen := make(map[int]bool, len(vote.Choices))
+		var ballot []int
+		for _, choice := range vote.Choices {
+			if choice < 0 || choice >= optionCount || seen[choice] {
+				continue
+			}
+			seen[choice] = true
+			ballot = append(ballot, choice)
+		}
+		if len(ballot) > 0 {
+			ballots = append(ballots, ballot)
+		}
+	}
+	return ballots
+}
+
+// InstantRunoff counts each ballot for its most preferred remaining option, eliminating the
+// option with the fewest votes until one holds a majority of the ballots still in play.
+// A tie for the fewest votes eliminates the tied option with the lowest Borda score, and
+// options still tied are eliminated together; if that would eliminate every remaining
+// option, they share the win instead. There is no winner without ballots.
+func InstantRunoff(optionCount int, ballots [][]int) ([]types.RankedRound, []int) {
+	borda := BordaCount(optionCount, ballots)
+
+	remaining := make(map[int]bool, optionCount)
+	for i := 0; i < optionCount; i++ {
+		remaining[i] = true
+	}
+
+	var rounds []types.RankedRound
+	for len(remaining) > 0 {
+		round := types.RankedRound{Counts: make([]int, optionCount)}
+		for i := 0; i < optionCount; i++ {
+			if remaining[i] {
+				round.Remaining = append(round.Remaining, i)
+			}
+		}
+
+		for _, ballot := range ballots {
+			counted := false
+			for _, choice := range ballot {
+				if remaining[choice] {
+					round.Counts[choice]++
+					counted = true
+					break
+				}
+			}
+			if !counted {
+				round.Exhausted++
+			}
+		}
+
+		continuing := len(ballots) - round.Exhausted
+		if continuing == 0 {
+			rounds = append(rounds, round)
+			return rounds, nil
+		}
+
+		for _, option := range round.Remaining {
+			if round.Counts[option]*2 > continuing {
+				rounds = append(rounds, round)
+				return rounds, []int{option}
+			}
+		}
+
+		fewest := continuing
+		for _, option := range round.Remaining {
+			fewest = min(fewest, round.Counts[option])
+		}
+		var tied []int
+		lowestBorda := -1
+		for _, option := range round.Remaining {
+			if round.Counts[option] != fewest {
+				continue
+			}
+			tied = append(tied, option)
+			if lowestBorda < 0 || borda[option] < lowestBorda {
+				lowestBorda = borda[option]
+			}
+		}
+		for _, option := range tied {
+			if borda[option] == lowestBorda {
+				round.Eliminated = append(round.Eliminated, option)
+			}
+		}
+
+		if len(round.Eliminated) == len(round.Remaining) {
+			round.Eliminated = nil
+			rounds = append(rounds, round)
+			return rounds, round.Remaining
+		}
+
+		for _, option := range round.Eliminated {
+			delete(remaining, option)
+		}
+		rounds = append(rounds, round)
+	}
+
+	return rounds, nil
+}
+
+// BordaCount scores each option by its position on every ballot: with n options, a first
+// preference earns n-1 points, a second n-2 and so on, while unranked options earn nothing
+func BordaCount(optionCount int, ballots [][]int) []int {
+	scores := make([]int, optionCount)
+	for _, ballot := range ballots {
+		for position, choice := range ballot {
+			scores[choice] += optionCount - 1 - position
+		}
+	}
+	return scores
+}
+
+// topIndices returns the indices holding the highest positive value
+func topIndices(values []int) []int {
+	best := 0
+	for _, value := range values {
+		best = max(best, value)
+	}
+
+	var indices []int
+	if best == 0 {
+		return indices
+	}
+	for i, value := range values {
+		if value == best {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
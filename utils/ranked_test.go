@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+func TestInstantRunoff(t *testing.T) {
+	t.Run("正常系: 過半数を得た選択肢が勝つ", func(t *testing.T) {
+		// Arrange
+		ballots := [][]int{{0, 1}, {0, 2}, {1, 0}}
+
+		// Act
+		rounds, winners := InstantRunoff(3, ballots)
+
+		// Assert
+		if len(rounds) != 1 {
+			t.Fatalf("ラウンド数が期待値と異なります: got %v, want %v", len(rounds), 1)
+		}
+		if !reflect.DeepEqual(winners, []int{0}) {
+			t.Errorf("勝者が期待値と異なります: got %v, want %v", winners, []int{0})
+		}
+	})
+
+	t.Run("正常系: 最下位を除外して票を移す", func(t *testing.T) {
+		// Arrange
+		// A: 4票, B: 3票, C: 2票(第2希望はB) → C除外後 B: 5票で過半数
+		ballots := [][]int{
+			{0}, {0}, {0}, {0},
+			{1}, {1}, {1},
+			{2, 1}, {2, 1},
+		}
+
+		// Act
+		rounds, winners := InstantRunoff(3, ballots)
+
+		// Assert
+		if len(rounds) != 2 {
+			t.Fatalf("ラウンド数が期待値と異なります: got %v, want %v", len(rounds), 2)
+		}
+		if !reflect.DeepEqual(rounds[0].Counts, []int{4, 3, 2}) {
+			t.Errorf("第1回の得票数が期待値と異なります: got %v", rounds[0].Counts)
+		}
+		if !reflect.DeepEqual(rounds[0].Eliminated, []int{2}) {
+			t.Errorf("第1回の除外が期待値と異なります: got %v", rounds[0].Eliminated)
+		}
+		if !reflect.DeepEqual(rounds[1].Counts, []int{4, 5, 0}) || !reflect.DeepEqual(rounds[1].Remaining, []int{0, 1}) {
+			t.Errorf("第2回が期待値と異なります: got %+v", rounds[1])
+		}
+		if !reflect.DeepEqual(winners, []int{1}) {
+			t.Errorf("勝者が期待値と異なります: got %v, want %v", winners, []int{1})
+		}
+	})
+
+	t.Run("正常系: 順位を付け切った票は無効票になる", func(t *testing.T) {
+		// Arrange
+		// A: 2票, B: 2票, C: 1票(他の順位なし) → C除外で無効票1、A・B同数で引き分け
+		ballots := [][]int{{0}, {0}, {1}, {1}, {2}}
+
+		// Act
+		rounds, winners := InstantRunoff(3, ballots)
+
+		// Assert
+		last := rounds[len(rounds)-1]
+		if last.Exhausted != 1 {
+			t.Errorf("無効票の数が期待値と異なります: got %v, want %v", last.Exhausted, 1)
+		}
+		if !reflect.DeepEqual(winners, []int{0, 1}) {
+			t.Errorf("同数の勝者が期待値と異なります: got %v, want %v", winners, []int{0, 1})
+		}
+	})
+
+	t.Run("正常系: 最下位が同数の場合はボルダ得点の低い方を除外する", func(t *testing.T) {
+		// Arrange
+		// 全員が1票ずつ。ボルダ得点は A: 3, B: 4, C: 2 → C除外後 B が過半数
+		ballots := [][]int{{0, 1}, {1, 0}, {2, 1}}
+
+		// Act
+		rounds, winners := InstantRunoff(3, ballots)
+
+		// Assert
+		if !reflect.DeepEqual(rounds[0].Eliminated, []int{2}) {
+			t.Errorf("第1回の除外が期待値と異なります: got %v, want %v", rounds[0].Eliminated, []int{2})
+		}
+		if !reflect.DeepEqual(winners, []int{1}) {
+			t.Errorf("勝者が期待値と異なります: got %v, want %v", winners, []int{1})
+		}
+	})
+
+	t.Run("正常系: 誰にも選ばれていない選択肢は最初に除外される", func(t *testing.T) {
+		// Arrange
+		ballots := [][]int{{0, 1}, {1, 0}, {0}}
+
+		// Act
+		rounds, winners := InstantRunoff(4, ballots)
+
+		// Assert
+		if !reflect.DeepEqual(rounds[0].Counts, []int{2, 1, 0, 0}) {
+			t.Errorf("第1回の得票数が期待値と異なります: got %v", rounds[0].Counts)
+		}
+		if !reflect.DeepEqual(winners, []int{0}) {
+			t.Errorf("勝者が期待値と異なります: got %v, want %v", winners, []int{0})
+		}
+	})
+
+	t.Run("正常系: 投票がない場合は勝者なし", func(t *testing.T) {
+		// Act
+		rounds, winners := InstantRunoff(2, nil)
+
+		// Assert
+		if len(rounds) != 1 || len(winners) != 0 {
+			t.Errorf("結果が期待値と異なります: rounds %v, winners %v", rounds, winners)
+		}
+	})
+}
+
+func TestBordaCount(t *testing.T) {
+	t.Run("正常系: 順位に応じて点数を付ける", func(t *testing.T) {
+		// Arrange
+		ballots := [][]int{{0, 1, 2}, {1, 0}, {2}}
+
+		// Act
+		scores := BordaCount(3, ballots)
+
+		// Assert
+		// A: 2+1, B: 1+2, C: 0+2
+		if !reflect.DeepEqual(scores, []int{3, 3, 2}) {
+			t.Errorf("点数が期待値と異なります: got %v, want %v", scores, []int{3, 3, 2})
+		}
+	})
+}
+
+func TestRankedBallots(t *testing.T) {
+	t.Run("異常系: 範囲外と重複した順位を取り除く", func(t *testing.T) {
+		// Arrange
+		votes := []types.Vote{
+			{UserID: "user-1", Choices: []int{2, 2, 5, 0}},
+			{UserID: "user-2", Choices: []int{-1}},
+		}
+
+		// Act
+		ballots := RankedBallots(3, votes)
+
+		// Assert
+		if !reflect.DeepEqual(ballots, [][]int{{2, 0}}) {
+			t.Errorf("票が期待値と異なります: got %v, want %v", ballots, [][]int{{2, 0}})
+		}
+	})
+}
+
+func TestTallyRanked(t *testing.T) {
+	t.Run("正常系: 決選投票とボルダ得点をまとめる", func(t *testing.T) {
+		// Arrange
+		options := []types.OptionResult{{Label: "A"}, {Label: "B"}, {Label: "C"}}
+		votes := []types.Vote{
+			{UserID: "user-1", Choices: []int{0, 1}},
+			{UserID: "user-2", Choices: []int{1, 0}},
+			{UserID: "user-3", Choices: []int{2, 1}},
+		}
+
+		// Act
+		result := TallyRanked("会場", options, votes)
+
+		// Assert
+		if result.Title != "会場" || result.TotalVotes != 3 {
+			t.Errorf("結果が期待値と異なります: %+v", result)
+		}
+		if result.Options[0].Count != 1 || result.Options[0].Percentage == 0 {
+			t.Errorf("第1希望の集計が期待値と異なります: %+v", result.Options[0])
+		}
+		if !reflect.DeepEqual(result.Winners, []int{1}) {
+			t.Errorf("決選投票の勝者が期待値と異なります: got %v, want %v", result.Winners, []int{1})
+		}
+		if !reflect.DeepEqual(result.BordaScores, []int{3, 4, 2}) || !reflect.DeepEqual(result.BordaWinners, []int{1}) {
+			t.Errorf("ボルダ得点が期待値と異なります: got %v %v", result.BordaScores, result.BordaWinners)
+		}
+		if options[0].Count != 0 {
+			t.Error("元のスライスが変更されてしまいました")
+		}
+	})
+}
@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+// ErrInvalidRecurrence is returned when a schedule cannot be parsed
+var ErrInvalidRecurrence = errors.New("invalid recurrence")
+
+var (
+	everyWeekday = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	everyWeekend = []time.Weekday{time.Saturday, time.Sunday}
+)
+
+// dayNames maps the English and Japanese names of a day to it
+var dayNames = map[string]time.Weekday{}
+
+// weekdayLabels are the Japanese day names indexed by time.Weekday
+var weekdayLabels = []string{"日", "月", "火", "水", "木", "金", "土"}
+
+func init() {
+	english := []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+	for day, label := range weekdayLabels {
+		weekday := time.Weekday(day)
+		dayNames[english[day]] = weekday
+		dayNames[strings.ToLower(weekday.String())] = weekday
+		dayNames[label] = weekday
+		dayNames[label+"曜"] = weekday
+		dayNames[label+"曜日"] = weekday
+	}
+}
+
+// timeZoneAliases are abbreviations accepted in place of IANA names
+var timeZoneAliases = map[string]string{
+	"JST": "Asia/Tokyo",
+}
+
+// ParseRecurrence reads a schedule from the start of args: the days ("daily", "weekdays",
+// "weekends", "mon,thu" or 毎日, 平日, 週末, "月,木"), a 24-hour time such as "10:00" and
+// optionally a time zone such as "Asia/Tokyo" or "JST". Without a time zone the schedule
+// follows defaultLocation. It returns how many args it consumed.
+func ParseRecurrence(args []string, defaultLocation *time.Location) (types.Recurrence, int, error) {
+	var recurrence types.Recurrence
+	if len(args) < 2 {
+		return recurrence, 0, fmt.Errorf("%w: expected days and time", ErrInvalidRecurrence)
+	}
+
+	weekdays, err := parseWeekdays(args[0])
+	if err != nil {
+		return recurrence, 0, err
+	}
+	recurrence.Weekdays = weekdays
+
+	hour, minute, err := parseClock(args[1])
+	if err != nil {
+		return recurrence, 0, err
+	}
+	recurrence.Hour, recurrence.Minute = hour, minute
+
+	recurrence.Location = defaultLocation.String()
+	if len(args) > 2 {
+		if location, ok := parseTimeZone(args[2]); ok {
+			recurrence.Location = location.String()
+			return recurrence, 3, nil
+		}
+	}
+	return recurrence, 2, nil
+}
+
+func parseWeekdays(value string) ([]time.Weekday, error) {
+	switch strings.ToLower(value) {
+	case "daily", "毎日":
+		return nil, nil
+	case "weekdays", "平日":
+		return append([]time.Weekday(nil), everyWeekday...), nil
+	case "weekends", "週末", "土日":
+		return append([]time.Weekday(nil), everyWeekend...), nil
+	}
+
+	seen := make(map[time.Weekday]bool)
+	var weekdays []time.Weekday
+	for _, name := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '、' || r == '・' }) {
+		weekday, ok := dayNames[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown day %q", ErrInvalidRecurrence, name)
+		}
+		if !seen[weekday] {
+			seen[weekday] = true
+			weekdays = append(weekdays, weekday)
+		}
+	}
+	if len(weekdays) == 0 {
+		return nil, fmt.Errorf("%w: no days in %q", ErrInvalidRecurrence, value)
+	}
+
+	// Weeks start on Monday, so "fri,mon" reads back as 月・金
+	sort.Slice(weekdays, func(i, j int) bool {
+		return (weekdays[i]+6)%7 < (weekdays[j]+6)%7
+	})
+	return weekdays, nil
+}
+
+func parseClock(value string) (int, int, error) {
+	hourText, minuteText, ok := strings.Cut(value, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("%w: time %q is not HH:MM", ErrInvalidRecurrence, value)
+	}
+	hour, err := strconv.Atoi(hourText)
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, 0, fmt.Errorf("%w: invalid hour in %q", ErrInvalidRecurrence, value)
+	}
+	minute, err := strconv.Atoi(minuteText)
+	if err != nil || len(minuteText) != 2 || minute < 0 || minute > 59 {
+		return 0, 0, fmt.Errorf("%w: invalid minute in %q", ErrInvalidRecurrence, value)
+	}
+	return hour, minute, nil
+}
+
+// parseTimeZone accepts IANA names and UTC, leaving other words such as flags alone
+func parseTimeZone(value string) (*time.Location, bool) {
+	if alias, ok := timeZoneAliases[strings.ToUpper(value)]; ok {
+		value = alias
+	}
+	if value != "UTC" && !strings.Contains(value, "/") {
+		return nil, false
+	}
+	location, err := time.LoadLocation(value)
+	if err != nil {
+		return nil, false
+	}
+	return location, true
+}
+
+// NextOccurrence returns the first time after after at which recurrence fires, reading its
+// days and time on the wall clock of its time zone
+func NextOccurrence(recurrence types.Recurrence, after time.Time) (time.Time, error) {
+	location, err := time.LoadLocation(recurrence.Location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to load time zone %q: %w", recurrence.Location, err)
+	}
+
+	local := after.In(location)
+	// A week and a day covers a time earlier today on the only scheduled day
+	for day := 0; day <= 7; day++ {
+		candidate := time.Date(local.Year(), local.Month(), local.Day()+day, recurrence.Hour, recurrence.Minute, 0, 0, location)
+		if candidate.After(after) && firesOn(recurrence, candidate.Weekday()) {
+			return candidate, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: no upcoming day", ErrInvalidRecurrence)
+}
+
+func firesOn(recurrence types.Recurrence, weekday time.Weekday) bool {
+	if len(recurrence.Weekdays) == 0 {
+		return true
+	}
+	for _, day := range recurrence.Weekdays {
+		if day == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatRecurrence describes recurrence in Japanese, e.g. "毎週 月・木 10:00 (Asia/Tokyo)"
+func FormatRecurrence(recurrence types.Recurrence) string {
+	days := ""
+	switch {
+	case len(recurrence.Weekdays) == 0:
+		days = "毎日"
+	case sameWeekdays(recurrence.Weekdays, everyWeekday):
+		days = "平日"
+	case sameWeekdays(recurrence.Weekdays, everyWeekend):
+		days = "週末"
+	default:
+		labels := make([]string, len(recurrence.Weekdays))
+		for i, day := range recurrence.Weekdays {
+			labels[i] = weekdayLabels[day]
+		}
+		days = "毎週 " + strings.Join(labels, "・")
+	}
+	location := recurrence.Location
+	if location == time.Local.String() {
+		location = "サーバーの時刻"
+	}
+	return fmt.Sprintf("%s %02d:%02d (%s)", days, recurrence.Hour, recurrence.Minute, location)
+}
+
+func sameWeekdays(a, b []time.Weekday) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected types.Recurrence
+		consumed int
+	}{
+		{
+			name:     "正常系: 曜日と時刻とタイムゾーン",
+			args:     []string{"mon", "10:00", "Asia/Tokyo", "--single"},
+			expected: types.Recurrence{Weekdays: []time.Weekday{time.Monday}, Hour: 10, Location: "Asia/Tokyo"},
+			consumed: 3,
+		},
+		{
+			name:     "正常系: 日本語の曜日を月曜始まりに並べる",
+			args:     []string{"金,月曜", "9:30", "JST"},
+			expected: types.Recurrence{Weekdays: []time.Weekday{time.Monday, time.Friday}, Hour: 9, Minute: 30, Location: "Asia/Tokyo"},
+			consumed: 3,
+		},
+		{
+			name:     "正常系: 毎日でタイムゾーン省略",
+			args:     []string{"毎日", "18:00", "--single"},
+			expected: types.Recurrence{Hour: 18, Location: "UTC"},
+			consumed: 2,
+		},
+		{
+			name:     "正常系: 平日",
+			args:     []string{"weekdays", "08:05", "UTC"},
+			expected: types.Recurrence{Weekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}, Hour: 8, Minute: 5, Location: "UTC"},
+			consumed: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			result, consumed, err := ParseRecurrence(tt.args, time.UTC)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("期待していないエラーが発生: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("スケジュールが期待値と異なります: got %+v, want %+v", result, tt.expected)
+			}
+			if consumed != tt.consumed {
+				t.Errorf("読み取った引数の数が期待値と異なります: got %v, want %v", consumed, tt.consumed)
+			}
+		})
+	}
+
+	errorTests := []struct {
+		name string
+		args []string
+	}{
+		{"異常系: 時刻がない", []string{"mon"}},
+		{"異常系: 不明な曜日", []string{"someday", "10:00"}},
+		{"異常系: 範囲外の時刻", []string{"mon", "24:00"}},
+		{"異常系: 分が1桁", []string{"mon", "10:0"}},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, _, err := ParseRecurrence(tt.args, time.UTC)
+
+			// Assert
+			if !errors.Is(err, ErrInvalidRecurrence) {
+				t.Errorf("ErrInvalidRecurrenceが期待されていましたが、%vが返されました", err)
+			}
+		})
+	}
+}
+
+func TestNextOccurrence(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	tests := []struct {
+		name       string
+		recurrence types.Recurrence
+		after      time.Time
+		expected   time.Time
+	}{
+		{
+			name:       "正常系: 当日のまだ来ていない時刻",
+			recurrence: types.Recurrence{Hour: 10, Location: "Asia/Tokyo"},
+			after:      time.Date(2026, 10, 19, 9, 0, 0, 0, tokyo),
+			expected:   time.Date(2026, 10, 19, 10, 0, 0, 0, tokyo),
+		},
+		{
+			name:       "正常系: 同じ時刻ちょうどは翌週",
+			recurrence: types.Recurrence{Weekdays: []time.Weekday{time.Monday}, Hour: 10, Location: "Asia/Tokyo"},
+			after:      time.Date(2026, 10, 19, 10, 0, 0, 0, tokyo),
+			expected:   time.Date(2026, 10, 26, 10, 0, 0, 0, tokyo),
+		},
+		{
+			name:       "正常系: タイムゾーンの曜日で判定",
+			recurrence: types.Recurrence{Weekdays: []time.Weekday{time.Monday}, Hour: 10, Location: "Asia/Tokyo"},
+			// Sunday 20:00 UTC is already Monday 05:00 in Tokyo
+			after:    time.Date(2026, 10, 18, 20, 0, 0, 0, time.UTC),
+			expected: time.Date(2026, 10, 19, 10, 0, 0, 0, tokyo),
+		},
+		{
+			name:       "正常系: 夏時間の終わりをまたいでも壁時計の時刻を保つ",
+			recurrence: types.Recurrence{Weekdays: []time.Weekday{time.Monday}, Hour: 9, Location: "America/New_York"},
+			after:      time.Date(2026, 10, 27, 9, 0, 0, 0, newYork),
+			expected:   time.Date(2026, 11, 2, 9, 0, 0, 0, newYork),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			result, err := NextOccurrence(tt.recurrence, tt.after)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("期待していないエラーが発生: %v", err)
+			}
+			if !result.Equal(tt.expected) {
+				t.Errorf("次回の時刻が期待値と異なります: got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("異常系: 不明なタイムゾーン", func(t *testing.T) {
+		// Act
+		_, err := NextOccurrence(types.Recurrence{Location: "Mars/Olympus"}, time.Now())
+
+		// Assert
+		if err == nil {
+			t.Error("エラーが期待されていましたが、nilが返されました")
+		}
+	})
+}
+
+func TestFormatRecurrence(t *testing.T) {
+	tests := []struct {
+		name       string
+		recurrence types.Recurrence
+		expected   string
+	}{
+		{"正常系: 毎日", types.Recurrence{Hour: 18, Location: "UTC"}, "毎日 18:00 (UTC)"},
+		{"正常系: 曜日指定", types.Recurrence{Weekdays: []time.Weekday{time.Monday, time.Thursday}, Hour: 10, Location: "Asia/Tokyo"}, "毎週 月・木 10:00 (Asia/Tokyo)"},
+		{"正常系: 平日", types.Recurrence{Weekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}, Hour: 9, Minute: 5, Location: "UTC"}, "平日 09:05 (UTC)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			result := FormatRecurrence(tt.recurrence)
+
+			// Assert
+			if result != tt.expected {
+				t.Errorf("表示が期待値と異なります: got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"github.com/Logta/SurveyBot/types"
+)
+
+// TallyResults computes percentages and winners from per-option vote counts
+func TallyResults(title string, options []types.OptionResult) *types.SurveyResult {
+	result := &types.SurveyResult{
+		Title:   title,
+		Options: make([]types.OptionResult, len(options)),
+	}
+
+	maxCount := 0
+	for i, option := range options {
+		if option.Count < 0 {
+			option.Count = 0
+		}
+		result.Options[i] = option
+		result.TotalVotes += option.Count
+		if option.Count > maxCount {
+			maxCount = option.Count
+		}
+	}
+
+	for i := range result.Options {
+		if result.TotalVotes > 0 {
+			result.Options[i].Percentage = float64(result.Options[i].Count) / float64(result.TotalVotes) * 100
+		}
+		// Ties share the win; nobody wins a survey without votes
+		if maxCount > 0 && result.Options[i].Count == maxCount {
+			result.Winners = append(result.Winners, i)
+		}
+	}
+
+	return result
+}
+
+// ReplaceVote drops any earlier vote by the same member and appends vote unless it is empty
+func ReplaceVote(votes []types.Vote, vote types.Vote) []types.Vote {
+	result := make([]types.Vote, 0, len(votes)+1)
+	for _, existing := range votes {
+		if existing.UserID != vote.UserID {
+			result = append(result, existing)
+		}
+	}
+	if len(vote.Choices) > 0 {
+		vote.Choices = append([]int(nil), vote.Choices...)
+		result = append(result, vote)
+	}
+	return result
+}
+
+// CountVotes counts how many members chose each option, ignoring out-of-range and
+// repeated choices within a single vote
+func CountVotes(optionCount int, votes []types.Vote) []int {
+	counts := make([]int, optionCount)
+	for _, vote := range votes {
+		seen := make(map[int]bool, len(vote.Choices))
+		for _, choice := range vote.Choices {
+			if choice < 0 || choice >= optionCount || seen[choice] {
+				continue
+			}
+			seen[choice] = true
+			counts[choice]++
+		}
+	}
+	return counts
+}
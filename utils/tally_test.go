@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Logta/SurveyBot/types"
+)
+
+func TestTallyResults(t *testing.T) {
+	t.Run("正常系: 得票数から割合と最多得票を算出", func(t *testing.T) {
+		// Arrange
+		options := []types.OptionResult{
+			{Emoji: "1️⃣", Label: "Go", Count: 3},
+			{Emoji: "2️⃣", Label: "Rust", Count: 1},
+		}
+
+		// Act
+		result := TallyResults("好きな言語", options)
+
+		// Assert
+		if result.Title != "好きな言語" {
+			t.Errorf("タイトルが期待値と異なります: got %v, want %v", result.Title, "好きな言語")
+		}
+		if result.TotalVotes != 4 {
+			t.Errorf("総投票数が期待値と異なります: got %v, want %v", result.TotalVotes, 4)
+		}
+		if result.Options[0].Percentage != 75 {
+			t.Errorf("割合が期待値と異なります: got %v, want %v", result.Options[0].Percentage, 75)
+		}
+		if result.Options[1].Percentage != 25 {
+			t.Errorf("割合が期待値と異なります: got %v, want %v", result.Options[1].Percentage, 25)
+		}
+		if !reflect.DeepEqual(result.Winners, []int{0}) {
+			t.Errorf("最多得票が期待値と異なります: got %v, want %v", result.Winners, []int{0})
+		}
+	})
+
+	t.Run("正常系: 同数の場合は全て最多得票", func(t *testing.T) {
+		// Arrange
+		options := []types.OptionResult{
+			{Label: "A", Count: 2},
+			{Label: "B", Count: 1},
+			{Label: "C", Count: 2},
+		}
+
+		// Act
+		result := TallyResults("同数", options)
+
+		// Assert
+		if !reflect.DeepEqual(result.Winners, []int{0, 2}) {
+			t.Errorf("最多得票が期待値と異なります: got %v, want %v", result.Winners, []int{0, 2})
+		}
+	})
+
+	t.Run("正常系: 投票がない場合", func(t *testing.T) {
+		// Arrange
+		options := []types.OptionResult{
+			{Label: "A", Count: 0},
+			{Label: "B", Count: 0},
+		}
+
+		// Act
+		result := TallyResults("投票なし", options)
+
+		// Assert
+		if result.TotalVotes != 0 {
+			t.Errorf("総投票数が期待値と異なります: got %v, want %v", result.TotalVotes, 0)
+		}
+		if len(result.Winners) != 0 {
+			t.Errorf("最多得票が空ではありません: %v", result.Winners)
+		}
+		for _, option := range result.Options {
+			if option.Percentage != 0 {
+				t.Errorf("割合が0ではありません: %v", option.Percentage)
+			}
+		}
+	})
+
+	t.Run("異常系: 負の得票数は0として扱う", func(t *testing.T) {
+		// Arrange
+		options := []types.OptionResult{
+			{Label: "A", Count: -1},
+			{Label: "B", Count: 1},
+		}
+
+		// Act
+		result := TallyResults("負の値", options)
+
+		// Assert
+		if result.Options[0].Count != 0 {
+			t.Errorf("得票数が期待値と異なります: got %v, want %v", result.Options[0].Count, 0)
+		}
+		if result.TotalVotes != 1 {
+			t.Errorf("総投票数が期待値と異なります: got %v, want %v", result.TotalVotes, 1)
+		}
+	})
+
+	t.Run("正常系: 元のスライスを変更しない", func(t *testing.T) {
+		// Arrange
+		options := []types.OptionResult{{Label: "A", Count: 1}}
+
+		// Act
+		TallyResults("コピー", options)
+
+		// Assert
+		if options[0].Percentage != 0 {
+			t.Error("元のスライスが変更されてしまいました")
+		}
+	})
+}
+
+func TestCountVotes(t *testing.T) {
+	t.Run("正常系: 選択肢ごとの投票者数を数える", func(t *testing.T) {
+		// Arrange
+		votes := []types.Vote{
+			{UserID: "user-1", Choices: []int{0}},
+			{UserID: "user-2", Choices: []int{0, 2}},
+			{UserID: "user-3", Choices: []int{2}},
+		}
+
+		// Act
+		counts := CountVotes(3, votes)
+
+		// Assert
+		if !reflect.DeepEqual(counts, []int{2, 0, 2}) {
+			t.Errorf("得票数が期待値と異なります: got %v, want %v", counts, []int{2, 0, 2})
+		}
+	})
+
+	t.Run("異常系: 範囲外と重複した選択は数えない", func(t *testing.T) {
+		// Arrange
+		votes := []types.Vote{
+			{UserID: "user-1", Choices: []int{-1, 1, 1, 5}},
+		}
+
+		// Act
+		counts := CountVotes(2, votes)
+
+		// Assert
+		if !reflect.DeepEqual(counts, []int{0, 1}) {
+			t.Errorf("得票数が期待値と異なります: got %v, want %v", counts, []int{0, 1})
+		}
+	})
+
+	t.Run("正常系: 投票がない場合", func(t *testing.T) {
+		// Act
+		counts := CountVotes(2, nil)
+
+		// Assert
+		if !reflect.DeepEqual(counts, []int{0, 0}) {
+			t.Errorf("得票数が期待値と異なります: got %v, want %v", counts, []int{0, 0})
+		}
+	})
+}